@@ -0,0 +1,232 @@
+package ldb
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/samber/lo"
+)
+
+// Record operations of a collection that enforce the access control callbacks
+// of its schema. Callbacks are evaluated before field validation, so denied
+// requests are rejected regardless of their data:
+//
+//  1. ViewFilter restricts the visible records; records hidden by it are
+//     reported as ErrRecordNotFound by GetRecord, UpdateRecord and DeleteRecord
+//  2. AllowCreate, AllowUpdate or AllowDelete decide whether the operation may
+//     be performed; ErrPermissionDenied is returned otherwise
+//  3. the data is validated by the transaction using FieldType.ValidateValue
+//
+// Missing callbacks allow everything.
+
+var ErrPermissionDenied = errors.New("permission denied")
+
+// returns the field types of the collection keyed by field name
+func (c Collection) Fields() map[string]FieldType {
+	fields := map[string]FieldType{}
+	for _, field := range c.withImplicitFields().Schema.Fields {
+		fields[field.Name] = field.Schema.Type
+	}
+
+	return fields
+}
+
+// returns the filters restricting the visible records
+func (c Collection) viewFilters() []Filter {
+	if c.Schema.ViewFilter == nil {
+		return nil
+	}
+
+	return c.Schema.ViewFilter()
+}
+
+// inserts the given data as new record if permitted by AllowCreate; sets the
+// timestamps and the initial version if enabled
+func (c Collection) CreateRecord(tx DatabaseTransaction, data map[string]any) (string, error) {
+	if c.Schema.AllowCreate != nil && !c.Schema.AllowCreate(data) {
+		return "", ErrPermissionDenied
+	}
+
+	return tx.CreateRecord(c.Name, c.Fields(), c.createVersion(c.createTimestamps(data)))
+}
+
+// inserts the given data as new record or updates the record with the same
+// values of the conflict columns, which must be the primary key, a unique field
+// or the columns of the composite primary key, a unique index or a unique
+// constraint. Creating and updating are subject to the same callbacks as
+// CreateRecord and UpdateRecord. Returns the id of the inserted or updated
+// record.
+func (c Collection) UpsertRecord(tx DatabaseTransaction, conflictColumns []string, data map[string]any) (string, error) {
+	if !c.uniqueColumns(conflictColumns) {
+		return "", fmt.Errorf("invalid conflict columns %q, expected primary key or unique fields", conflictColumns)
+	}
+
+	fields := c.Fields()
+	primaryKey, ok := primaryKeyField(fields)
+	if !ok {
+		return "", fmt.Errorf("collection %q has no primary key", c.Name)
+	}
+
+	values, err := conflictValues(fields, conflictColumns, data)
+	if err != nil {
+		return "", err
+	}
+
+	filters := lo.Map(conflictColumns, func(name string, i int) Filter {
+		return Filter{Field: name, Operator: FilterEqual, Value: values[i]}
+	})
+
+	// hidden records are found as well, so that updating them is rejected
+	records, _, err := tx.ListRecords(c.Name, fields, ListOptions{Filters: filters, Limit: 1})
+	if err != nil {
+		return "", err
+	}
+
+	if len(records) == 0 {
+		return c.CreateRecord(tx, data)
+	}
+
+	id := recordIdString(records[0][primaryKey])
+	update := lo.OmitByKeys(data, append(slices.Clone(conflictColumns), primaryKey))
+
+	// upserts without version overwrite the current record
+	if _, ok := update[VersionField]; c.Schema.Versioned && !ok {
+		update[VersionField] = records[0][VersionField]
+	}
+
+	if err := c.UpdateRecord(tx, id, update); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// returns whether the given columns identify a record by being the primary
+// key, the columns of the composite primary key, a unique field, the columns
+// of a unique index or the columns of a unique constraint
+func (c Collection) uniqueColumns(columns []string) bool {
+	if len(columns) == 1 {
+		field := c.Schema.field(columns[0])
+		if field == nil {
+			return false
+		}
+
+		if _, ok := primaryKeyField(map[string]FieldType{field.Name: field.Schema.Type}); ok || field.Schema.Unique {
+			return true
+		}
+	}
+
+	// columns are matched regardless of their order
+	sameColumns := func(other []string) bool {
+		return len(other) == len(columns) && lo.Every(other, columns)
+	}
+
+	if len(c.Schema.PrimaryKey) > 0 && sameColumns(c.Schema.PrimaryKey) {
+		return true
+	}
+
+	return lo.ContainsBy(c.Schema.Indexes, func(index IndexSchema) bool {
+		return index.Unique && sameColumns(index.Columns)
+	}) || lo.ContainsBy(c.Schema.UniqueConstraints, sameColumns)
+}
+
+// returns the record with the given id if it is visible according to ViewFilter
+func (c Collection) GetRecord(tx DatabaseTransaction, id string) (map[string]any, error) {
+	filters := c.viewFilters()
+	if len(filters) == 0 {
+		return tx.GetRecord(c.Name, c.Fields(), id)
+	}
+
+	fields := c.Fields()
+	primaryKey, ok := primaryKeyField(fields)
+	if !ok {
+		return nil, fmt.Errorf("collection %q has no primary key", c.Name)
+	}
+
+	filters = append(filters, Filter{Field: primaryKey, Operator: FilterEqual, Value: id})
+
+	records, _, err := tx.ListRecords(c.Name, fields, ListOptions{Filters: filters, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return records[0], nil
+}
+
+// updates the record with the given id if it is visible and the update is
+// permitted by AllowUpdate; the callback receives the current record and the
+// update timestamp is set if enabled. Updates of versioned collections must
+// contain the current version and fail with ErrVersionConflict if the record
+// has been updated since.
+func (c Collection) UpdateRecord(tx DatabaseTransaction, id string, data map[string]any) error {
+	if c.Schema.AllowUpdate != nil || c.Schema.ViewFilter != nil {
+		record, err := c.GetRecord(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if c.Schema.AllowUpdate != nil && !c.Schema.AllowUpdate(record, data) {
+			return ErrPermissionDenied
+		}
+	}
+
+	if c.Schema.Versioned {
+		version, data, err := c.updateVersion(data)
+		if err != nil {
+			return err
+		}
+
+		return tx.UpdateRecordVersion(c.Name, c.Fields(), id, version, c.updateTimestamps(data))
+	}
+
+	return tx.UpdateRecord(c.Name, c.Fields(), id, c.updateTimestamps(data))
+}
+
+// deletes the record with the given id if it is visible and the deletion is
+// permitted by AllowDelete; the callback receives the current record
+func (c Collection) DeleteRecord(tx DatabaseTransaction, id string) error {
+	if c.Schema.AllowDelete != nil || c.Schema.ViewFilter != nil {
+		record, err := c.GetRecord(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if c.Schema.AllowDelete != nil && !c.Schema.AllowDelete(record) {
+			return ErrPermissionDenied
+		}
+	}
+
+	return tx.DeleteRecord(c.Name, c.Fields(), id)
+}
+
+// returns the records matching the given options that are visible according
+// to ViewFilter
+func (c Collection) ListRecords(tx DatabaseTransaction, opts ListOptions) ([]map[string]any, int64, error) {
+	opts.Filters = append(c.viewFilters(), opts.Filters...)
+	return tx.ListRecords(c.Name, c.Fields(), opts)
+}
+
+// calls fn for each record matching the given options that is visible according
+// to ViewFilter; see DatabaseTransaction.IterateRecords
+func (c Collection) IterateRecords(tx DatabaseTransaction, opts ListOptions, fn func(record map[string]any) error) error {
+	opts.Filters = append(c.viewFilters(), opts.Filters...)
+	return tx.IterateRecords(c.Name, c.Fields(), opts, fn)
+}
+
+// returns the number of records matching the given options that are visible
+// according to ViewFilter
+func (c Collection) CountRecords(tx DatabaseTransaction, opts ListOptions) (int64, error) {
+	opts.Filters = append(c.viewFilters(), opts.Filters...)
+	return tx.CountRecords(c.Name, c.Fields(), opts)
+}
+
+// computes the given aggregates over the records visible according to ViewFilter
+func (c Collection) Aggregate(tx DatabaseTransaction, spec AggregateSpec) ([]map[string]any, error) {
+	spec.Filters = append(c.viewFilters(), spec.Filters...)
+	return tx.Aggregate(c.Name, c.Fields(), spec)
+}
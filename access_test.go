@@ -0,0 +1,82 @@
+package ldb_test
+
+import (
+	"errors"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestAccessControl(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			authors := *testAuthors.Clone()
+			authors.Schema.ViewFilter = func() []ldb.Filter {
+				return []ldb.Filter{{Field: "name", Operator: ldb.FilterNotEqual, Value: "Hidden"}}
+			}
+			authors.Schema.AllowCreate = func(data map[string]any) bool {
+				return data["name"] != "Forbidden"
+			}
+			authors.Schema.AllowUpdate = func(record map[string]any, data map[string]any) bool {
+				return record["name"] != "Locked"
+			}
+			authors.Schema.AllowDelete = func(record map[string]any) bool {
+				return record["name"] != "Locked"
+			}
+
+			// permission is checked before validation
+			if _, err := authors.CreateRecord(tx, map[string]any{"id": "invalid", "name": "Forbidden"}); !errors.Is(err, ldb.ErrPermissionDenied) {
+				t.Fatalf("expected ErrPermissionDenied, got %v", err)
+			}
+
+			for _, data := range []map[string]any{{"id": testId0, "name": "Locked"}, {"id": testId1, "name": "Hidden"}} {
+				if _, err := authors.CreateRecord(tx, data); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, err := authors.GetRecord(tx, testId1); !errors.Is(err, ldb.ErrRecordNotFound) {
+				t.Fatalf("expected ErrRecordNotFound for hidden record, got %v", err)
+			}
+
+			if err := authors.UpdateRecord(tx, testId1, map[string]any{"name": "Visible"}); !errors.Is(err, ldb.ErrRecordNotFound) {
+				t.Fatalf("expected ErrRecordNotFound for hidden record, got %v", err)
+			}
+
+			if err := authors.UpdateRecord(tx, testId0, map[string]any{"name": "Unlocked"}); !errors.Is(err, ldb.ErrPermissionDenied) {
+				t.Fatalf("expected ErrPermissionDenied, got %v", err)
+			}
+
+			if err := authors.DeleteRecord(tx, testId0); !errors.Is(err, ldb.ErrPermissionDenied) {
+				t.Fatalf("expected ErrPermissionDenied, got %v", err)
+			}
+
+			records, total, err := authors.ListRecords(tx, ldb.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if total != 1 || len(records) != 1 || records[0]["id"] != testId0 {
+				t.Fatalf("expected only the visible record, got %v (total %v)", records, total)
+			}
+
+			record, err := authors.GetRecord(tx, testId0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["name"] != "Locked" {
+				t.Fatalf("unexpected record %v", record)
+			}
+		})
+	}
+}
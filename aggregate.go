@@ -0,0 +1,230 @@
+package ldb
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type AggregateFunction string
+
+const (
+	AggregateCount AggregateFunction = "COUNT"
+	AggregateSum   AggregateFunction = "SUM"
+	AggregateAvg   AggregateFunction = "AVG"
+	AggregateMin   AggregateFunction = "MIN"
+	AggregateMax   AggregateFunction = "MAX"
+)
+
+// applies a function to the values of a field; COUNT without field counts the
+// matching records, COUNT with field the non-null values of the field
+type Aggregate struct {
+	Function AggregateFunction
+	Field    string
+	// key of the result in the returned rows; defaults to the lowercase
+	// function name followed by an underscore and the field name, e.g. sum_views
+	As string
+}
+
+// Results of aggregates have the following types:
+//
+//   - COUNT: int64
+//   - SUM, MIN and MAX: the type of the field, i.e. int64, float64 or decimal
+//     strings; decimals are aggregated as floating point numbers by SQLite
+//   - AVG: float64
+//
+// Aggregates over no values other than COUNT result in nil.
+type AggregateSpec struct {
+	Aggregates []Aggregate
+	// fields to group by; the returned rows contain their values and are
+	// ordered by them
+	GroupBy []string
+	// filters are combined using AND and applied before grouping
+	Filters []Filter
+}
+
+// returns the key of the aggregate in the result rows
+func (a Aggregate) key() string {
+	if a.As != "" {
+		return a.As
+	}
+
+	if a.Field == "" {
+		return strings.ToLower(string(a.Function))
+	}
+
+	return strings.ToLower(string(a.Function)) + "_" + a.Field
+}
+
+func isNumeric(fieldType FieldType) bool {
+	switch fieldType.(type) {
+	case FieldTypeInt, FieldTypeFloat, FieldTypeDecimal:
+		return true
+	default:
+		return false
+	}
+}
+
+// builds the select expression of the given aggregate; numericSQL converts a
+// column into a value the database can compute with
+func aggregateSQL(dialect Dialect, fields map[string]FieldType, aggregate Aggregate, numericSQL func(column string, fieldType FieldType) string) (string, error) {
+	if aggregate.Function == AggregateCount && aggregate.Field == "" {
+		return "COUNT(*)", nil
+	}
+
+	fieldType, ok := fields[aggregate.Field]
+	if !ok {
+		return "", fmt.Errorf("invalid aggregate, unknown field %q", aggregate.Field)
+	}
+
+	if isMultiRelation(fieldType) {
+		return "", fmt.Errorf("invalid aggregate, cannot aggregate multi relation field %q", aggregate.Field)
+	}
+
+	column := dialect.Quote(aggregate.Field)
+
+	switch aggregate.Function {
+	case AggregateCount:
+		return "COUNT(" + column + ")", nil
+
+	case AggregateSum, AggregateAvg, AggregateMin, AggregateMax:
+		if !isNumeric(fieldType) {
+			return "", fmt.Errorf("invalid aggregate, expected numeric field, got %q", aggregate.Field)
+		}
+
+	default:
+		return "", fmt.Errorf("invalid aggregate on field %q, unknown function %q", aggregate.Field, aggregate.Function)
+	}
+
+	sql := fmt.Sprintf("%s(%s)", aggregate.Function, numericSQL(column, fieldType))
+
+	// DuckDB sums integers as HUGEINT
+	if _, ok := fieldType.(FieldTypeInt); ok && aggregate.Function == AggregateSum {
+		return "CAST(" + sql + " AS BIGINT)", nil
+	}
+
+	if aggregate.Function == AggregateAvg {
+		return "CAST(" + sql + " AS DOUBLE)", nil
+	}
+
+	return sql, nil
+}
+
+func aggregateRecords(tx contextTx, codec valueCodec, collection string, fields map[string]FieldType, spec AggregateSpec, numericSQL func(column string, fieldType FieldType) string) ([]map[string]any, error) {
+	if len(spec.Aggregates) == 0 {
+		return nil, fmt.Errorf("invalid aggregate, expected at least one aggregate")
+	}
+
+	keys := map[string]bool{}
+	columns := []string{}
+	groupColumns := []string{}
+
+	for _, name := range spec.GroupBy {
+		fieldType, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid group, unknown field %q", name)
+		}
+
+		if isMultiRelation(fieldType) {
+			return nil, fmt.Errorf("invalid group, cannot group by multi relation field %q", name)
+		}
+
+		if keys[name] {
+			return nil, fmt.Errorf("invalid group, duplicate field %q", name)
+		}
+
+		keys[name] = true
+		columns = append(columns, codec.selectSQL(fieldType, tx.dialect.Quote(name)))
+		groupColumns = append(groupColumns, tx.dialect.Quote(name))
+	}
+
+	groups := strings.Join(groupColumns, ", ")
+
+	for _, aggregate := range spec.Aggregates {
+		sql, err := aggregateSQL(tx.dialect, fields, aggregate, numericSQL)
+		if err != nil {
+			return nil, err
+		}
+
+		if keys[aggregate.key()] {
+			return nil, fmt.Errorf("invalid aggregate, duplicate result key %q", aggregate.key())
+		}
+
+		keys[aggregate.key()] = true
+		columns = append(columns, sql)
+	}
+
+	where, args, err := whereSQL(tx.dialect, codec, fields, spec.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(columns, ", "), tx.dialect.Quote(collection), where)
+	if groups != "" {
+		query += " GROUP BY " + groups + " ORDER BY " + groups
+	}
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		dest := lo.Map(values, func(value any, i int) any { return &values[i] })
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		result := map[string]any{}
+		for i, name := range spec.GroupBy {
+			if values[i] == nil {
+				result[name] = nil
+				continue
+			}
+
+			value, err := codec.decodeValue(fields[name], values[i])
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", name, err)
+			}
+
+			result[name] = value
+		}
+
+		for i, aggregate := range spec.Aggregates {
+			value, err := decodeAggregate(codec, fields, aggregate, values[len(spec.GroupBy)+i])
+			if err != nil {
+				return nil, fmt.Errorf("aggregate %q: %w", aggregate.key(), err)
+			}
+
+			result[aggregate.key()] = value
+		}
+
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// converts the scanned result of an aggregate into its go representation
+func decodeAggregate(codec valueCodec, fields map[string]FieldType, aggregate Aggregate, value any) (any, error) {
+	if value == nil || aggregate.Function == AggregateCount || aggregate.Function == AggregateAvg {
+		return value, nil
+	}
+
+	fieldType := fields[aggregate.Field]
+
+	// decimals computed as floating point numbers
+	if ft, ok := fieldType.(FieldTypeDecimal); ok {
+		if f, ok := value.(float64); ok {
+			return new(big.Rat).SetFloat64(f).FloatString(ft.Scale), nil
+		}
+	}
+
+	return codec.decodeValue(fieldType, value)
+}
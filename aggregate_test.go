@@ -0,0 +1,113 @@
+package ldb_test
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestAggregate(t *testing.T) {
+	orders := ldb.Collection{
+		Name: "orders",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "category", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{Nullable: true}}},
+				{Name: "quantity", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{}}},
+				{Name: "weight", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeFloat{}}},
+				{Name: "price", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeDecimal{Precision: 10, Scale: 2}}},
+			},
+		},
+	}
+
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := tx.SaveCollection(orders); err != nil {
+				t.Fatal(err)
+			}
+
+			rows := []map[string]any{
+				{"category": "a", "quantity": int64(1), "weight": 0.5, "price": big.NewRat(125, 100)},
+				{"category": "a", "quantity": int64(3), "weight": 1.5, "price": big.NewRat(275, 100)},
+				{"category": "b", "quantity": int64(2), "weight": 2.0, "price": big.NewRat(10, 1)},
+				{"category": nil, "quantity": int64(4), "weight": 4.0, "price": big.NewRat(1, 1)},
+			}
+
+			if _, err := tx.CreateRecords("orders", fieldTypes(orders), rows); err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := orders.Aggregate(tx, ldb.AggregateSpec{
+				Aggregates: []ldb.Aggregate{
+					{Function: ldb.AggregateCount},
+					{Function: ldb.AggregateSum, Field: "quantity"},
+					{Function: ldb.AggregateAvg, Field: "weight", As: "average"},
+					{Function: ldb.AggregateMax, Field: "price"},
+				},
+				GroupBy: []string{"category"},
+				Filters: []ldb.Filter{{Field: "category", Operator: ldb.FilterNotEqual, Value: nil}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []map[string]any{
+				{"category": "a", "count": int64(2), "sum_quantity": int64(4), "average": 1.0, "max_price": "2.75"},
+				{"category": "b", "count": int64(1), "sum_quantity": int64(2), "average": 2.0, "max_price": "10.00"},
+			}
+
+			if !reflect.DeepEqual(results, expected) {
+				t.Fatalf("expected %v, got %v", expected, results)
+			}
+
+			// without groups a single row is returned
+			results, err = tx.Aggregate("orders", fieldTypes(orders), ldb.AggregateSpec{
+				Aggregates: []ldb.Aggregate{
+					{Function: ldb.AggregateCount, Field: "category"},
+					{Function: ldb.AggregateMin, Field: "quantity"},
+					{Function: ldb.AggregateSum, Field: "price"},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected = []map[string]any{{"count_category": int64(3), "min_quantity": int64(1), "sum_price": "15.00"}}
+			if !reflect.DeepEqual(results, expected) {
+				t.Fatalf("expected %v, got %v", expected, results)
+			}
+
+			invalid := []struct {
+				spec    ldb.AggregateSpec
+				message string
+			}{
+				{ldb.AggregateSpec{Aggregates: []ldb.Aggregate{{Function: ldb.AggregateSum, Field: "category"}}}, "expected numeric field"},
+				{ldb.AggregateSpec{Aggregates: []ldb.Aggregate{{Function: ldb.AggregateCount}}, GroupBy: []string{"unknown"}}, "unknown field"},
+				{ldb.AggregateSpec{Aggregates: []ldb.Aggregate{{Function: "MEDIAN", Field: "weight"}}}, "unknown function"},
+				{ldb.AggregateSpec{}, "at least one aggregate"},
+			}
+
+			for _, test := range invalid {
+				if _, err := tx.Aggregate("orders", fieldTypes(orders), test.spec); err == nil || !strings.Contains(err.Error(), test.message) {
+					t.Errorf("expected error containing %q, got %v", test.message, err)
+				}
+			}
+		})
+	}
+}
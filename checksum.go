@@ -0,0 +1,180 @@
+package ldb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Applied migrations are verified by checksums over the schema changes they
+// perform, i.e. the collections and views they save or drop. To verify an
+// applied migration, its up function runs again against a transaction that
+// records the schema changes without performing them; writes are skipped as
+// well and reads see the current data. Up functions therefore should not
+// derive schema changes from data nor have side effects outside the
+// transaction. Up functions failing during the verification, e.g. because
+// they read records they expect to have created, cannot be verified and are
+// skipped.
+
+var ErrMigrationChanged = errors.New("migration changed after it has been applied")
+
+// records the schema changes of a migration; forwards all calls to the
+// underlying transaction if apply is set
+type checksumTransaction struct {
+	DatabaseTransaction
+	apply   bool
+	changes []string
+}
+
+// runs the up function of the given migration and returns the checksum of its
+// schema changes; the changes are only performed if apply is set
+func migrationChecksum(tx DatabaseTransaction, migration *Migration, apply bool) (string, error) {
+	recorder := &checksumTransaction{DatabaseTransaction: tx, apply: apply}
+
+	if migration.Up != nil {
+		if err := migration.Up(recorder); err != nil {
+			return "", err
+		}
+	}
+
+	hash := sha256.New()
+	for _, change := range recorder.changes {
+		// changes are separated by a character not occurring in them
+		fmt.Fprintf(hash, "%s\x00", change)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (t *checksumTransaction) SaveCollection(collection Collection) error {
+	encoded, err := MarshalSchema(collection)
+	if err != nil {
+		return err
+	}
+
+	change := "save collection " + string(encoded)
+	if collection.original != nil {
+		change += " from " + collection.original.Name
+	}
+
+	t.changes = append(t.changes, change)
+
+	if !t.apply {
+		return nil
+	}
+
+	return t.DatabaseTransaction.SaveCollection(collection)
+}
+
+func (t *checksumTransaction) DropCollection(collection Collection) error {
+	t.changes = append(t.changes, "drop collection "+collection.Name)
+
+	if !t.apply {
+		return nil
+	}
+
+	return t.DatabaseTransaction.DropCollection(collection)
+}
+
+func (t *checksumTransaction) SaveView(view View) error {
+	t.changes = append(t.changes, fmt.Sprintf("save view %s from %s as %s", view.Name, view.originalName, view.Schema.Query))
+
+	if !t.apply {
+		return nil
+	}
+
+	return t.DatabaseTransaction.SaveView(view)
+}
+
+func (t *checksumTransaction) DropView(view View) error {
+	t.changes = append(t.changes, "drop view "+view.Name)
+
+	if !t.apply {
+		return nil
+	}
+
+	return t.DatabaseTransaction.DropView(view)
+}
+
+func (t *checksumTransaction) CreateRecord(collection string, fields map[string]FieldType, data map[string]any) (string, error) {
+	if !t.apply {
+		return "", nil
+	}
+
+	return t.DatabaseTransaction.CreateRecord(collection, fields, data)
+}
+
+func (t *checksumTransaction) CreateRecords(collection string, fields map[string]FieldType, rows []map[string]any) ([]string, error) {
+	if !t.apply {
+		return make([]string, len(rows)), nil
+	}
+
+	return t.DatabaseTransaction.CreateRecords(collection, fields, rows)
+}
+
+func (t *checksumTransaction) UpsertRecord(collection string, fields map[string]FieldType, conflictColumns []string, data map[string]any) (string, error) {
+	if !t.apply {
+		return "", nil
+	}
+
+	return t.DatabaseTransaction.UpsertRecord(collection, fields, conflictColumns, data)
+}
+
+func (t *checksumTransaction) UpdateRecord(collection string, fields map[string]FieldType, id string, data map[string]any) error {
+	if !t.apply {
+		return nil
+	}
+
+	return t.DatabaseTransaction.UpdateRecord(collection, fields, id, data)
+}
+
+func (t *checksumTransaction) UpdateRecordVersion(collection string, fields map[string]FieldType, id string, version int64, data map[string]any) error {
+	if !t.apply {
+		return nil
+	}
+
+	return t.DatabaseTransaction.UpdateRecordVersion(collection, fields, id, version, data)
+}
+
+func (t *checksumTransaction) DeleteRecord(collection string, fields map[string]FieldType, id string) error {
+	if !t.apply {
+		return nil
+	}
+
+	return t.DatabaseTransaction.DeleteRecord(collection, fields, id)
+}
+
+func (t *checksumTransaction) TruncateCollection(collection string, fields map[string]FieldType, opts TruncateOptions) error {
+	if !t.apply {
+		return nil
+	}
+
+	return t.DatabaseTransaction.TruncateCollection(collection, fields, opts)
+}
+
+// savepoints only guard the skipped writes
+func (t *checksumTransaction) Savepoint(name string) error {
+	if !t.apply {
+		return nil
+	}
+
+	return t.DatabaseTransaction.Savepoint(name)
+}
+
+func (t *checksumTransaction) RollbackTo(name string) error {
+	if !t.apply {
+		return nil
+	}
+
+	return t.DatabaseTransaction.RollbackTo(name)
+}
+
+// raw queries may write, so they are skipped as well
+func (t *checksumTransaction) Query(collection string, fields map[string]FieldType, sql string, args ...any) ([]map[string]any, error) {
+	if !t.apply {
+		return []map[string]any{}, nil
+	}
+
+	return t.DatabaseTransaction.Query(collection, fields, sql, args...)
+}
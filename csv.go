@@ -0,0 +1,158 @@
+package ldb
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// CSV files consist of a header row with field names followed by one row per
+// record. Values are formatted like in JSON responses of the HTTP handler,
+// strings without quotes, e.g. datetimes as RFC 3339 strings, bytes as base64
+// strings and arrays as JSON arrays. Empty cells are null values.
+
+// number of records inserted by a single statement when importing
+const csvBatchSize = 500
+
+// writes all records of the given collection as CSV to w
+func ExportCSV(tx DatabaseTransaction, collection string, fields map[string]FieldType, w io.Writer) error {
+	names := sortedFieldNames(fields)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(names); err != nil {
+		return err
+	}
+
+	err := tx.IterateRecords(collection, fields, ListOptions{}, func(record map[string]any) error {
+		row := make([]string, len(names))
+		for i, name := range names {
+			cell, err := formatCSVValue(fields[name], record[name])
+			if err != nil {
+				return fmt.Errorf("field %q: %w", name, err)
+			}
+
+			row[i] = cell
+		}
+
+		return writer.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formats a value of the given field type as CSV cell
+func formatCSVValue(fieldType FieldType, value any) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+
+	_, isJSON := fieldType.(FieldTypeJSON)
+	if str, ok := value.(string); ok && !isJSON {
+		return str, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	// e.g. datetimes and bytes are encoded as JSON strings; JSON values are
+	// kept as is since their cells are taken as JSON text
+	var str string
+	if !isJSON && json.Unmarshal(data, &str) == nil {
+		return str, nil
+	}
+
+	return string(data), nil
+}
+
+// reads records from the CSV in r and inserts them into the given collection;
+// each cell is validated by the type of the field named in the header and
+// missing ids are generated. Returns the number of inserted records.
+func ImportCSV(tx DatabaseTransaction, collection string, fields map[string]FieldType, r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	for _, name := range header {
+		if _, ok := fields[name]; !ok {
+			return 0, fmt.Errorf("line 1: unknown field %q", name)
+		}
+	}
+
+	count := 0
+	batch := []map[string]any{}
+	// line of the first row of the batch
+	batchLine := 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if _, err := tx.CreateRecords(collection, fields, batch); err != nil {
+			return fmt.Errorf("lines %d-%d: %w", batchLine, batchLine+len(batch)-1, err)
+		}
+
+		count += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			// parse errors include the line number
+			return count, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		data := map[string]any{}
+		for i, name := range header {
+			// empty cells are omitted so that defaults and ids are generated;
+			// computed values of exported records are ignored
+			if row[i] == "" || isComputed(fields[name]) {
+				continue
+			}
+
+			// cells are decoded like query parameters
+			value, err := decodeQueryValue(fields[name], row[i])
+			if err != nil {
+				return count, fmt.Errorf("line %d: field %q: %w", line, name, err)
+			}
+
+			data[name] = value
+		}
+
+		if len(batch) == 0 {
+			batchLine = line
+		}
+
+		batch = append(batch, data)
+		if len(batch) >= csvBatchSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
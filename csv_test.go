@@ -0,0 +1,115 @@
+package ldb_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"lehnert.dev/ldb"
+)
+
+func TestCSV(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+			fields := fieldTypes(testPosts)
+
+			if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			publishedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+			data := map[string]any{
+				"id":           testId1,
+				"title":        "Hello, world",
+				"views":        int64(42),
+				"rating":       4.5,
+				"published":    true,
+				"published_at": publishedAt,
+				"state":        "live",
+				"author":       testId0,
+			}
+			if _, err := tx.CreateRecord("posts", fields, data); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := ldb.ExportCSV(tx, "posts", fields, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := "author,id,published,published_at,rating,state,title,views\n" +
+				testId0 + "," + testId1 + ",true,2024-01-02T03:04:05Z,4.5,live,\"Hello, world\",42\n"
+			if buf.String() != expected {
+				t.Fatalf("expected %q, got %q", expected, buf.String())
+			}
+
+			if err := tx.DeleteRecord("posts", fields, testId1); err != nil {
+				t.Fatal(err)
+			}
+
+			count, err := ldb.ImportCSV(tx, "posts", fields, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if count != 1 {
+				t.Fatalf("expected 1 imported record, got %d", count)
+			}
+
+			record, err := tx.GetRecord("posts", fields, testId1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for key, value := range data {
+				if key == "published_at" {
+					if !record[key].(time.Time).Equal(publishedAt) {
+						t.Errorf("expected %v, got %v", publishedAt, record[key])
+					}
+				} else if record[key] != value {
+					t.Errorf("field %q: expected %v, got %v", key, value, record[key])
+				}
+			}
+
+			// ids are generated and empty cells take the default
+			count, err = ldb.ImportCSV(tx, "posts", fields, strings.NewReader("title,views\nFirst,\nSecond,2\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if count != 2 {
+				t.Fatalf("expected 2 imported records, got %d", count)
+			}
+
+			total, err := tx.CountRecords("posts", fields, ldb.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if total != 3 {
+				t.Fatalf("expected 3 records, got %d", total)
+			}
+
+			if _, err := ldb.ImportCSV(tx, "posts", fields, strings.NewReader("title,views\nFirst,1\nSecond,many\n")); err == nil || !strings.Contains(err.Error(), "line 3") {
+				t.Errorf("expected invalid value on line 3, got %v", err)
+			}
+
+			if _, err := ldb.ImportCSV(tx, "posts", fields, strings.NewReader("title,views\nFirst,1,2\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+				t.Errorf("expected malformed row on line 2, got %v", err)
+			}
+
+			if _, err := ldb.ImportCSV(tx, "posts", fields, strings.NewReader("title,unknown\n")); err == nil {
+				t.Error("expected error for unknown field")
+			}
+		})
+	}
+}
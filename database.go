@@ -1,8 +1,38 @@
 package ldb
 
+import (
+	"context"
+	"errors"
+)
+
+// returned by adapters for operations their database does not support
+var ErrUnsupported = errors.New("operation not supported by database adapter")
+
 type DatabaseAdapter interface {
 	Close() error
+	// verifies that the database is reachable; returns the driver error unchanged
+	Ping(ctx context.Context) error
 	Begin() (DatabaseTransaction, error)
+	// begins a transaction whose operations are bound to the given context;
+	// the transaction is rolled back if the context is cancelled
+	BeginTx(ctx context.Context) (DatabaseTransaction, error)
+	// begins a transaction recording all statements it executes; the
+	// transaction is always rolled back, even if committed
+	BeginDryRun() (DryRunTransaction, error)
+	// begins a transaction whose write methods return ErrReadOnly; adapters
+	// without a read-only mode in the database only reject the writes of the
+	// transaction, while statements run by Query may still write
+	BeginReadOnly() (DatabaseTransaction, error)
+}
+
+// Statements of a dry run are executed within the transaction, since later
+// statements usually depend on earlier ones (e.g. altering a table created
+// before), and discarded on commit. Reads are not recorded.
+type DryRunTransaction interface {
+	DatabaseTransaction
+
+	// returns the statements executed so far in order
+	Statements() []string
 }
 
 type DatabaseTransaction interface {
@@ -17,14 +47,84 @@ type DatabaseTransaction interface {
 	SaveView(view View) error
 	DropView(view View) error
 
+	// returns the names of the collections, i.e. the tables, of the database
+	// in alphabetical order; internal tables of ldb like the migration history
+	// and junction tables are omitted
+	ListCollections() ([]string, error)
+	// returns the names of the views of the database in alphabetical order
+	ListViews() ([]string, error)
+
 	// checks if the migration with the given name has already been performed
 	MigrationExists(migrationName string) (bool, error)
-	// saves the given migration name to the migration history
-	FinishMigration(migrationName string) error
-
-	// GetCollection(name string, fields map[string]FieldType) ([]any, error)
-	// GetRecord(collection string, fields map[string]FieldType, id string) (any, error)
-	// CreateRecord(collection string, fields map[string]FieldType, data map[string]any) (string, error)
-	// UpdateRecord(collection string, fields map[string]FieldType, id string, data map[string]any) error
-	// DeleteRecord(collection string, fields map[string]FieldType, id string) error
+	// saves the given migration name together with the checksum of its schema
+	// changes to the migration history
+	FinishMigration(migrationName string, checksum string) error
+	// returns an error wrapping ErrMigrationChanged if the migration with the
+	// given name has been applied with a different checksum; migrations
+	// applied without checksum are not verified
+	VerifyMigration(migrationName string, checksum string) error
+	// records the revert of the given migration in the migration history
+	RevertMigration(migrationName string) error
+	// lists the names of all performed migrations in the order they were applied
+	AppliedMigrations() ([]string, error)
+	// returns all applications and reverts of migrations in the order they
+	// were performed
+	MigrationHistory() ([]MigrationHistoryEntry, error)
+	// returns the version of the last entry of the migration history, i.e.
+	// the number of applications and reverts so far; 0 if there is none
+	CurrentSchemaVersion() (int, error)
+
+	// creates a savepoint with the given name; may return ErrUnsupported
+	Savepoint(name string) error
+	// reverts all changes made since the savepoint with the given name was
+	// created; the savepoint is kept and may be rolled back to again
+	RollbackTo(name string) error
+
+	// validates the given data and inserts it as new record;
+	// generates an id if no primary key value is given and returns it
+	CreateRecord(collection string, fields map[string]FieldType, data map[string]any) (string, error)
+	// validates all rows and inserts them at once; returns the ids in input
+	// order and performs no writes if any row is invalid
+	CreateRecords(collection string, fields map[string]FieldType, rows []map[string]any) ([]string, error)
+	// inserts the given data as new record or, if a record with the same values
+	// of the conflict columns exists, updates that record; the conflict columns
+	// must be covered by the primary key or a unique index. Returns the id of
+	// the inserted or updated record.
+	UpsertRecord(collection string, fields map[string]FieldType, conflictColumns []string, data map[string]any) (string, error)
+	// returns the record with the given id keyed by field name;
+	// returns ErrRecordNotFound if there is no such record
+	GetRecord(collection string, fields map[string]FieldType, id string) (map[string]any, error)
+	// validates and updates the given fields of the record with the given id;
+	// returns ErrRecordNotFound if there is no such record
+	UpdateRecord(collection string, fields map[string]FieldType, id string, data map[string]any) error
+	// like UpdateRecord, but only updates the record if its version field
+	// equals the given version and increments the version; returns
+	// ErrVersionConflict if the record has a different version
+	UpdateRecordVersion(collection string, fields map[string]FieldType, id string, version int64, data map[string]any) error
+	// deletes the record with the given id; returns ErrRecordNotFound if there
+	// is no such record and ErrRecordReferenced if a relation prevents deletion
+	DeleteRecord(collection string, fields map[string]FieldType, id string) error
+	// deletes all records of the collection; records of other collections
+	// referencing them are handled according to their relations, see the
+	// adapters for how foreign keys are checked. Returns ErrRecordReferenced if
+	// a relation prevents deletion.
+	TruncateCollection(collection string, fields map[string]FieldType, opts TruncateOptions) error
+	// returns the records matching the given options together with the total
+	// number of matching records regardless of limit and offset
+	ListRecords(collection string, fields map[string]FieldType, opts ListOptions) ([]map[string]any, int64, error)
+	// scans the records matching the given options one at a time and calls fn
+	// for each of them instead of loading all records into memory; stops and
+	// returns the error of fn if it fails
+	IterateRecords(collection string, fields map[string]FieldType, opts ListOptions, fn func(record map[string]any) error) error
+	// returns the number of records matching the filters of the given options;
+	// limit, offset and order are ignored
+	CountRecords(collection string, fields map[string]FieldType, opts ListOptions) (int64, error)
+	// runs the given parameterized query and returns its rows keyed by column
+	// name; columns named like fields are decoded according to the field
+	// types, other columns are returned as scanned. The arguments are passed
+	// to the database unchanged.
+	Query(collection string, fields map[string]FieldType, sql string, args ...any) ([]map[string]any, error)
+	// computes the given aggregates over the records matching the filters of
+	// the spec; returns one row per group or a single row without groups
+	Aggregate(collection string, fields map[string]FieldType, spec AggregateSpec) ([]map[string]any, error)
 }
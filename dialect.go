@@ -0,0 +1,139 @@
+package ldb
+
+import (
+	"strings"
+)
+
+// Dialect describes the SQL syntax of a database. The statement builders shared
+// by the adapters, i.e. the column definitions, indexes and junction tables of
+// SaveCollection as well as all record operations, are parameterized by the
+// dialect of the adapter, so that adapters only implement what actually
+// differs, e.g. how tables are altered.
+type Dialect interface {
+	// quotes the given table or column name
+	Quote(ident string) string
+	// returns the placeholder of the i-th parameter of a statement, starting
+	// at 1; shared statements are written using ? and rebound before they
+	// are executed
+	Placeholder(i int) string
+	// returns the column type storing values of the given field type; returns
+	// an empty string if the field type is not supported
+	ColumnType(fieldType FieldType) string
+	// returns an expression computing the length of the given quoted column
+	// in bytes
+	ByteLength(column string) string
+	// reports whether columns can be removed using ALTER TABLE DROP COLUMN;
+	// tables are rebuilt otherwise
+	SupportsDropColumn() bool
+	// returns the clause placing a column added by ALTER TABLE ADD COLUMN
+	// after the given quoted column, e.g. " AFTER col"; returns an empty
+	// string if columns are always appended at the end
+	ColumnPosition(after string) string
+	// returns the expression drawing the next value from the sequence with
+	// the given name, which is the DEFAULT of serial columns; returns an
+	// empty string if the database assigns serial primary keys itself when
+	// inserting null
+	SerialValue(sequence string) string
+}
+
+type duckdbDialect struct{}
+
+func (duckdbDialect) Quote(ident string) string {
+	return quoteIdent(ident)
+}
+
+func (duckdbDialect) Placeholder(i int) string {
+	return "?"
+}
+
+func (duckdbDialect) ColumnType(fieldType FieldType) string {
+	return columnType(fieldType)
+}
+
+func (duckdbDialect) ByteLength(column string) string {
+	return "strlen(" + column + ")"
+}
+
+// DuckDB refuses to drop columns of tables having had indexes at the beginning
+// of the transaction though; see DuckDBTransaction.SaveCollection
+func (duckdbDialect) SupportsDropColumn() bool {
+	return true
+}
+
+// columns are always appended
+func (duckdbDialect) ColumnPosition(after string) string {
+	return ""
+}
+
+func (duckdbDialect) SerialValue(sequence string) string {
+	return "nextval(" + quoteLiteral(sequence) + ")"
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Quote(ident string) string {
+	return quoteIdent(ident)
+}
+
+func (sqliteDialect) Placeholder(i int) string {
+	return "?"
+}
+
+func (sqliteDialect) ColumnType(fieldType FieldType) string {
+	return sqliteColumnType(fieldType)
+}
+
+// length counts characters of text values, but bytes of blobs
+func (sqliteDialect) ByteLength(column string) string {
+	return "length(CAST(" + column + " AS BLOB))"
+}
+
+// supported since SQLite 3.35, except for columns that are part of a
+// constraint; see SQLiteTransaction.SaveCollection
+func (sqliteDialect) SupportsDropColumn() bool {
+	return true
+}
+
+// columns are always appended
+func (sqliteDialect) ColumnPosition(after string) string {
+	return ""
+}
+
+// serial primary keys alias the rowid
+func (sqliteDialect) SerialValue(sequence string) string {
+	return ""
+}
+
+// replaces the ? placeholders of the given statement by the placeholders of
+// the dialect; question marks within quoted literals and identifiers are kept
+func rebindPlaceholders(dialect Dialect, query string) string {
+	if dialect == nil || dialect.Placeholder(1) == "?" {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	var quote rune
+
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			// doubled quotes close and reopen the quoted section
+			if r == quote {
+				quote = 0
+			}
+
+		case r == '\'' || r == '"':
+			quote = r
+
+		case r == '?':
+			n++
+			b.WriteString(dialect.Placeholder(n))
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package ldb_test
+
+import (
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestDialect(t *testing.T) {
+	duckdb := openTestAdapter(t)
+	defer duckdb.Close()
+
+	sqlite := openTestSQLiteAdapter(t)
+	defer sqlite.Close()
+
+	dialects := map[string]ldb.Dialect{
+		"duckdb": duckdb.Dialect(),
+		"sqlite": sqlite.Dialect(),
+	}
+
+	columnTypes := map[string]string{
+		"duckdb": "BIGINT",
+		"sqlite": "INTEGER",
+	}
+
+	for name, dialect := range dialects {
+		t.Run(name, func(t *testing.T) {
+			if quoted := dialect.Quote(`a"b`); quoted != `"a""b"` {
+				t.Errorf(`expected "a""b", got %s`, quoted)
+			}
+
+			if placeholder := dialect.Placeholder(2); placeholder != "?" {
+				t.Errorf("expected ?, got %s", placeholder)
+			}
+
+			if columnType := dialect.ColumnType(ldb.FieldTypeInt{}); columnType != columnTypes[name] {
+				t.Errorf("expected %s, got %s", columnTypes[name], columnType)
+			}
+
+			if !dialect.SupportsDropColumn() {
+				t.Error("expected DROP COLUMN to be supported")
+			}
+
+			if position := dialect.ColumnPosition(`"title"`); position != "" {
+				t.Errorf("expected columns to be appended, got %s", position)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package ldb
+
+import (
+	"github.com/samber/lo"
+)
+
+// changes of a collection since its last migration
+type CollectionDiff struct {
+	// collection name on last migration; empty for new collections
+	OriginalName string `json:"originalName,omitempty"`
+	Name         string `json:"name"`
+	Created      bool   `json:"created"`
+	Renamed      bool   `json:"renamed"`
+
+	// names of the new fields
+	AddedFields []string `json:"addedFields"`
+	// names of the removed fields on last migration
+	RemovedFields []string      `json:"removedFields"`
+	RenamedFields []FieldRename `json:"renamedFields"`
+	// names of the fields whose type or type options changed; functions such
+	// as CreateDefaultValue are not compared
+	RetypedFields []string `json:"retypedFields"`
+}
+
+type FieldRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// returns whether the diff contains any changes
+func (d CollectionDiff) Empty() bool {
+	return !d.Created && !d.Renamed && len(d.AddedFields) == 0 && len(d.RemovedFields) == 0 &&
+		len(d.RenamedFields) == 0 && len(d.RetypedFields) == 0
+}
+
+// DiffCollection computes the changes from old to new. Fields of new are
+// matched with the fields of old using the snapshot taken by Forward, so old is
+// usually the state of new on its last migration. If old is nil, the collection
+// is considered new.
+func DiffCollection(old, new *Collection) CollectionDiff {
+	diff := CollectionDiff{
+		Name:          new.Name,
+		AddedFields:   []string{},
+		RemovedFields: []string{},
+		RenamedFields: []FieldRename{},
+		RetypedFields: []string{},
+	}
+
+	if old == nil {
+		diff.Created = true
+		diff.AddedFields = lo.Map(new.Schema.Fields, func(field *Field, i int) string {
+			return field.Name
+		})
+
+		return diff
+	}
+
+	diff.OriginalName = old.Name
+	diff.Renamed = old.Name != new.Name
+
+	for _, field := range new.Schema.Fields {
+		if field.original == nil {
+			diff.AddedFields = append(diff.AddedFields, field.Name)
+			continue
+		}
+
+		if field.original.Name != field.Name {
+			diff.RenamedFields = append(diff.RenamedFields, FieldRename{From: field.original.Name, To: field.Name})
+		}
+
+		if !equalFieldTypes(field.original.Schema.Type, field.Schema.Type) {
+			diff.RetypedFields = append(diff.RetypedFields, field.Name)
+		}
+	}
+
+	for _, origField := range old.Schema.Fields {
+		kept := lo.ContainsBy(new.Schema.Fields, func(field *Field) bool {
+			return field.original != nil && field.original.Name == origField.Name
+		})
+
+		if !kept {
+			diff.RemovedFields = append(diff.RemovedFields, origField.Name)
+		}
+	}
+
+	return diff
+}
+
+// compares the given field types using Equal; nil is only equal to nil
+func equalFieldTypes(a, b FieldType) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return a.Equal(b)
+}
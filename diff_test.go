@@ -0,0 +1,67 @@
+package ldb_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestDiffCollection(t *testing.T) {
+	collection := *testPosts.Clone()
+
+	diff := ldb.DiffCollection(nil, &collection)
+	if !diff.Created || len(diff.AddedFields) != len(collection.Schema.Fields) {
+		t.Fatalf("expected all fields to be added, got %+v", diff)
+	}
+
+	collection.Forward()
+	original := collection.Clone()
+
+	if diff := ldb.DiffCollection(original, &collection); !diff.Empty() {
+		t.Fatalf("expected empty diff, got %+v", diff)
+	}
+
+	collection.Name = "articles"
+	collection.Schema.Fields[1].Name = "headline"
+	collection.Schema.Fields[2].Schema.Type = ldb.FieldTypeFloat{}
+	collection.Schema.Fields = slices.Delete(collection.Schema.Fields, 3, 4)
+	collection.Schema.Fields = append(collection.Schema.Fields, &ldb.Field{Name: "body", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}})
+
+	diff = ldb.DiffCollection(original, &collection)
+
+	if !diff.Renamed || diff.OriginalName != "posts" || diff.Name != "articles" {
+		t.Fatalf("expected collection rename, got %+v", diff)
+	}
+
+	if !slices.Equal(diff.AddedFields, []string{"body"}) {
+		t.Fatalf("unexpected added fields %v", diff.AddedFields)
+	}
+
+	if !slices.Equal(diff.RemovedFields, []string{"rating"}) {
+		t.Fatalf("unexpected removed fields %v", diff.RemovedFields)
+	}
+
+	if !slices.Equal(diff.RenamedFields, []ldb.FieldRename{{From: "title", To: "headline"}}) {
+		t.Fatalf("unexpected renamed fields %v", diff.RenamedFields)
+	}
+
+	if !slices.Equal(diff.RetypedFields, []string{"views"}) {
+		t.Fatalf("unexpected retyped fields %v", diff.RetypedFields)
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded ldb.CollectionDiff
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(decoded.RenamedFields, diff.RenamedFields) {
+		t.Fatalf("expected %v after round trip, got %v", diff.RenamedFields, decoded.RenamedFields)
+	}
+}
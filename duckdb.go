@@ -1,27 +1,104 @@
 package ldb
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"math/big"
 	"strings"
+	"time"
 
-	_ "github.com/marcboeker/go-duckdb"
+	"github.com/marcboeker/go-duckdb"
 	"github.com/samber/lo"
 )
 
 var _ DatabaseAdapter = DuckDBAdapter{}
 var _ DatabaseTransaction = DuckDBTransaction{}
+var _ DryRunTransaction = DuckDBTransaction{}
 
 type DuckDBAdapter struct {
 	db *sql.DB
 }
 
-func OpenDuckDBAdapter(databaseFilePath string) (*DuckDBAdapter, error) {
-	db, err := sql.Open("duckdb", databaseFilePath)
+// option of OpenDuckDBAdapter
+type DuckDBOption func(options *duckdbOptions)
+
+type duckdbOptions struct {
+	readOnly     bool
+	maxOpenConns int
+	// name and value pairs in order of declaration
+	pragmas [][2]string
+}
+
+// opens the database in read-only mode, which allows opening the same file
+// from multiple processes; the database file must already exist
+func WithReadOnly() DuckDBOption {
+	return func(options *duckdbOptions) {
+		options.readOnly = true
+	}
+}
+
+// limits the number of open connections; see sql.DB.SetMaxOpenConns
+func WithMaxOpenConns(n int) DuckDBOption {
+	return func(options *duckdbOptions) {
+		options.maxOpenConns = n
+	}
+}
+
+// executes "PRAGMA name = value" on each new connection, e.g. to set the
+// memory_limit or the number of threads
+func WithPragma(name string, value string) DuckDBOption {
+	return func(options *duckdbOptions) {
+		options.pragmas = append(options.pragmas, [2]string{name, value})
+	}
+}
+
+func OpenDuckDBAdapter(databaseFilePath string, opts ...DuckDBOption) (*DuckDBAdapter, error) {
+	options := duckdbOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	dsn := databaseFilePath
+	if options.readOnly {
+		dsn += "?access_mode=read_only"
+	}
+
+	pragmas := []string{}
+	for _, pragma := range options.pragmas {
+		if err := ValidateIdentifier(pragma[0]); err != nil {
+			return nil, fmt.Errorf("invalid pragma: %w", err)
+		}
+
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA %s = %s", pragma[0], quoteLiteral(pragma[1])))
+	}
+
+	connector, err := duckdb.NewConnector(dsn, func(execer driver.ExecerContext) error {
+		for _, pragma := range pragmas {
+			if _, err := execer.ExecContext(context.Background(), pragma, nil); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	db := sql.OpenDB(connector)
+	if options.maxOpenConns > 0 {
+		db.SetMaxOpenConns(options.maxOpenConns)
+	}
+
+	// connect once so that invalid pragmas are reported immediately
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return &DuckDBAdapter{db}, nil
 }
 
@@ -29,21 +106,192 @@ func (s DuckDBAdapter) Close() error {
 	return s.db.Close()
 }
 
+// DB returns the underlying database as an escape hatch for queries not modeled
+// by the adapter. Statements executed on it bypass validation and must not
+// alter tables managed by SaveCollection.
+func (s DuckDBAdapter) DB() *sql.DB {
+	return s.db
+}
+
+// Dialect returns the SQL dialect of the adapter.
+func (s DuckDBAdapter) Dialect() Dialect {
+	return duckdbDialect{}
+}
+
+// conflicts between concurrent transactions are reported as transaction errors
+func (s DuckDBAdapter) isRetryable(err error) bool {
+	var duckdbErr *duckdb.Error
+	return errors.As(err, &duckdbErr) && duckdbErr.Type == duckdb.ErrorTypeTransaction
+}
+
+func (s DuckDBAdapter) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
 func (s DuckDBAdapter) Begin() (DatabaseTransaction, error) {
+	return s.BeginTx(context.Background())
+}
+
+func (s DuckDBAdapter) BeginTx(ctx context.Context) (DatabaseTransaction, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return DatabaseTransaction(DuckDBTransaction{contextTx{tx, ctx, nil, duckdbDialect{}}}), nil
+}
+
+func (s DuckDBAdapter) BeginDryRun() (DryRunTransaction, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 
-	return DatabaseTransaction(DuckDBTransaction{tx}), nil
+	return DryRunTransaction(DuckDBTransaction{contextTx{tx, context.Background(), &[]string{}, duckdbDialect{}}}), nil
+}
+
+// the driver does not support read-only transactions of database/sql, so the
+// transaction is started manually
+func (s DuckDBAdapter) BeginReadOnly() (DatabaseTransaction, error) {
+	ctx := context.Background()
+	tx, err := beginConnTx(s.db, ctx, "", "BEGIN TRANSACTION READ ONLY")
+	if err != nil {
+		return nil, err
+	}
+
+	return DatabaseTransaction(readOnlyTransaction{DuckDBTransaction{contextTx{tx, ctx, nil, duckdbDialect{}}}}), nil
+}
+
+// reflectColumns implements reflectingAdapter using information_schema.
+func (s DuckDBAdapter) reflectColumns() ([]reflectedColumn, error) {
+	rows, err := s.db.Query(`SELECT c.table_name, c.column_name, c.data_type, c.is_nullable = 'YES', COALESCE(c.numeric_precision, 0), COALESCE(c.numeric_scale, 0), COALESCE(c.column_default, '') LIKE 'nextval(%'
+		FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = current_schema() AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name, c.ordinal_position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []reflectedColumn{}
+	for rows.Next() {
+		var column reflectedColumn
+		var dataType string
+		var precision, scale int
+		if err := rows.Scan(&column.table, &column.name, &dataType, &column.nullable, &precision, &scale, &column.serial); err != nil {
+			return nil, err
+		}
+
+		if column.fieldType, err = duckdbFieldType(dataType, precision, scale); err != nil {
+			return nil, fmt.Errorf("table %q column %q: %w", column.table, column.name, err)
+		}
+
+		columns = append(columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	find := func(table, name string) *reflectedColumn {
+		for i := range columns {
+			if columns[i].table == table && columns[i].name == name {
+				return &columns[i]
+			}
+		}
+
+		return nil
+	}
+
+	// the referenced table is resolved through the unique constraint a
+	// foreign key refers to
+	rows, err = s.db.Query(`SELECT kcu.table_name, kcu.constraint_name, kcu.column_name, tc.constraint_type, COALESCE(ref.table_name, ''), COALESCE(rc.delete_rule, ''), COALESCE(rc.update_rule, '')
+		FROM information_schema.key_column_usage kcu
+		JOIN information_schema.table_constraints tc ON tc.table_schema = kcu.table_schema AND tc.table_name = kcu.table_name AND tc.constraint_name = kcu.constraint_name
+		LEFT JOIN information_schema.referential_constraints rc ON rc.constraint_schema = kcu.table_schema AND rc.constraint_name = kcu.constraint_name
+		LEFT JOIN information_schema.table_constraints ref ON ref.table_schema = rc.unique_constraint_schema AND ref.constraint_name = rc.unique_constraint_name
+		WHERE kcu.table_schema = current_schema()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type constraint struct {
+		table, name, column, constraintType, references, deleteRule, updateRule string
+	}
+
+	constraints := []constraint{}
+	columnCounts := map[[2]string]int{}
+	for rows.Next() {
+		var c constraint
+		if err := rows.Scan(&c.table, &c.name, &c.column, &c.constraintType, &c.references, &c.deleteRule, &c.updateRule); err != nil {
+			return nil, err
+		}
+
+		constraints = append(constraints, c)
+		columnCounts[[2]string{c.table, c.name}]++
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range constraints {
+		// only single column constraints map to fields
+		column := find(c.table, c.column)
+		if column == nil || columnCounts[[2]string{c.table, c.name}] != 1 {
+			continue
+		}
+
+		switch c.constraintType {
+		case "PRIMARY KEY":
+			column.primaryKey = true
+
+		case "UNIQUE":
+			column.unique = true
+
+		case "FOREIGN KEY":
+			column.references = c.references
+			column.onDelete = reflectedDeleteAction(c.deleteRule)
+			column.cascadeUpdate = c.updateRule == "CASCADE"
+		}
+	}
+
+	// unique fields are enforced by indexes named after the field
+	rows, err = s.db.Query("SELECT table_name, index_name FROM duckdb_indexes() WHERE schema_name = current_schema()")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, index string
+		if err := rows.Scan(&table, &index); err != nil {
+			return nil, err
+		}
+
+		if name, ok := strings.CutPrefix(index, "_ldb_unique_"+table+"_"); ok {
+			if column := find(table, name); column != nil {
+				column.unique = true
+			}
+		}
+	}
+
+	return columns, rows.Err()
 }
 
 type DuckDBTransaction struct {
-	tx *sql.Tx
+	tx contextTx
 }
 
 // Commit implements DatabaseTransaction.
 func (s DuckDBTransaction) Commit() error {
+	// dry runs are never committed
+	if s.tx.statements != nil {
+		return s.tx.Rollback()
+	}
+
 	return s.tx.Commit()
 }
 
@@ -52,24 +300,69 @@ func (s DuckDBTransaction) Rollback() error {
 	return s.tx.Rollback()
 }
 
+// Statements implements DryRunTransaction.
+func (s DuckDBTransaction) Statements() []string {
+	return s.tx.recordedStatements()
+}
+
 // SaveCollection implements DatabaseTransaction.
 func (s DuckDBTransaction) SaveCollection(collection Collection) error {
+	collection = collection.withImplicitFields()
+
+	if err := validateCollectionIdentifiers(collection); err != nil {
+		return err
+	}
+
+	if err := validateFieldTypes(collection); err != nil {
+		return err
+	}
+
+	if err := validateIndexes(collection); err != nil {
+		return err
+	}
+
+	if err := validateCompositePrimaryKey(collection); err != nil {
+		return err
+	}
+
+	if err := validateUniqueConstraints(collection); err != nil {
+		return err
+	}
+
+	if err := validateDuckDBReferentialActions(collection); err != nil {
+		return err
+	}
+
+	if err := s.loadSpatial(collection); err != nil {
+		return err
+	}
+
+	dialect := s.tx.dialect
+
+	createSequences, dropSequences := collection.serialSequenceChanges()
+
 	// create collection if not exists
 	if collection.original == nil {
-		columns := []string{}
-		for _, field := range collection.Schema.Fields {
-			columns = append(columns, columnSQL(field.Name, field.Schema.Type))
+		if err := s.createSequences(collection.Name, createSequences); err != nil {
+			return err
 		}
 
-		sql := fmt.Sprintf("CREATE TABLE %s (%s)", collection.Name, strings.Join(columns, ", "))
+		statements, err := createTableStatements(dialect, collection, s.junctionTableSQL)
+		if err != nil {
+			return err
+		}
 
-		_, err := s.tx.Exec(sql)
+		return s.execAll(statements)
+	}
+
+	dropIndexes, createIndexes := indexStatements(dialect, collection, false)
+	if err := s.execAll(dropIndexes); err != nil {
 		return err
 	}
 
 	// rename collection if neccessary
 	if collection.original.Name != collection.Name {
-		sql := fmt.Sprintf("ALTER TABLE %s RENAME TO %s", collection.original.Name, collection.Name)
+		sql := fmt.Sprintf("ALTER TABLE %s RENAME TO %s", dialect.Quote(collection.original.Name), dialect.Quote(collection.Name))
 		_, err := s.tx.Exec(sql)
 		if err != nil {
 
@@ -77,41 +370,311 @@ func (s DuckDBTransaction) SaveCollection(collection Collection) error {
 		}
 	}
 
-	createFields := lo.Filter(collection.Schema.Fields, func(field *Field, i int) bool {
-		return field.original == nil
-	})
+	createFields, renameFields, removeFields := collection.fieldChanges()
+	retypeFields := collection.retypedFields(dialect)
+	nullChangedFields := collection.nullChangedFields()
 
-	renameFields := lo.Filter(collection.Schema.Fields, func(field *Field, i int) bool {
-		return field.original.original.Name != field.Name
-	})
+	// DuckDB refuses to drop or alter columns of tables that had indexes at the
+	// beginning of the transaction, even if the indexes have been dropped since;
+	// such tables are rebuilt instead
+	rebuild := len(collection.original.indexes()) > 0 &&
+		(len(removeFields) > 0 || len(retypeFields) > 0 || len(nullChangedFields) > 0 || len(collection.defaultChangedFields()) > 0)
 
-	removeFields := []*Field{}
-	if collection.original != nil {
-		removeFields = lo.Filter(collection.original.Schema.Fields, func(origField *Field, i int) bool {
-			_, found := lo.Find(collection.Schema.Fields, func(field *Field) bool {
-				return field.original != nil && field.original.Name == origField.Name
-			})
+	backfills, err := nullBackfills(s.tx, s, collection)
+	if err != nil {
+		return err
+	}
+
+	// null values are replaced while copying the rows; see nullBackfills.
+	// Primary and foreign keys cannot be changed without rebuilding the table
+	// either.
+	rebuild = rebuild || len(backfills) > 0 || collection.primaryKeyChanged() || len(collection.referenceChangedFields()) > 0
+
+	// neither can unique constraints
+	rebuild = rebuild || collection.uniqueConstraintsChanged()
+
+	// generated columns can neither be added nor altered
+	rebuild = rebuild || len(collection.computedChangedFields()) > 0
 
-			return !found
+	// the defaults of serial columns whose sequence changed are replaced by
+	// rebuilding the table, which assigns values to new serial columns as well
+	rebuild = rebuild || len(createSequences) > 0
+
+	// DuckDB can neither add nor change CHECK constraints of existing tables,
+	// nor change the type of columns with CHECK constraints
+	hasCheck := func(schema *FieldSchema) bool {
+		return checkSQL(dialect, "", schema) != ""
+	}
+
+	rebuild = rebuild || len(collection.checkChangedFields(dialect)) > 0 ||
+		lo.SomeBy(createFields, func(field *Field) bool { return hasCheck(field.Schema) }) ||
+		lo.SomeBy(retypeFields, func(field *Field) bool { return hasCheck(field.original.Schema) })
+
+	if !rebuild {
+		for _, field := range removeFields {
+			sql := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", dialect.Quote(collection.Name), dialect.Quote(field.Name))
+			if _, err := s.tx.Exec(sql); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, field := range renameFields {
+		sql := fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", dialect.Quote(collection.Name), dialect.Quote(field.original.Name), dialect.Quote(field.Name))
+		if _, err := s.tx.Exec(sql); err != nil {
+			return err
+		}
+	}
+
+	if err := s.checkRemovedEnumValues(collection.Name, retypeFields); err != nil {
+		return err
+	}
+
+	if err := s.createSequences(collection.Name, createSequences); err != nil {
+		return err
+	}
+
+	if rebuild {
+		// new fields are created by the rebuild as well
+		fields := lo.Filter(collection.Schema.Fields, func(field *Field, i int) bool {
+			return !isMultiRelation(field.Schema.Type)
+		})
+
+		// foreign keys keep referencing the temporary table after renaming it
+		// and DuckDB cannot copy rows referencing rows of the same statement
+		selfReference := lo.SomeBy(fields, func(field *Field) bool {
+			target, ok := relationTarget(field.Schema.Type)
+			return ok && target == collection.Name
 		})
+
+		if selfReference {
+			return fmt.Errorf("configuration error, DuckDB cannot rebuild table %q since it references itself", collection.Name)
+		}
+
+		if err := rebuildTable(s.tx, collection.Name, fields, collection.Schema, backfills); err != nil {
+			return err
+		}
+
+		// the indexes of the old table have been dropped along with it
+		_, createIndexes = indexStatements(dialect, collection, true)
+
+		createFields = nil
+	} else {
+		// values are converted using DuckDB's implicit casts; incompatible
+		// values result in a conversion error
+		for _, field := range retypeFields {
+			columnType := dialect.ColumnType(field.Schema.Type)
+			if columnType == "" {
+				return fmt.Errorf("field %q: configuration error, unsupported field type %T", field.Name, field.Schema.Type)
+			}
+
+			sql := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", dialect.Quote(collection.Name), dialect.Quote(field.Name), columnType)
+			if _, err := s.tx.Exec(sql); err != nil {
+				return err
+			}
+		}
+
+		for _, field := range nullChangedFields {
+			sql := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", dialect.Quote(collection.Name), dialect.Quote(field.Name))
+			if nullableColumn(field.Schema.Type) {
+				sql = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", dialect.Quote(collection.Name), dialect.Quote(field.Name))
+			}
+
+			if _, err := s.tx.Exec(sql); err != nil {
+				return err
+			}
+		}
+
+		for _, field := range collection.defaultChangedFields() {
+			sql := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", dialect.Quote(collection.Name), dialect.Quote(field.Name))
+			if defaultSQL := defaultSQL(field.Schema); defaultSQL != "" {
+				sql = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET%s", dialect.Quote(collection.Name), dialect.Quote(field.Name), defaultSQL)
+			}
+
+			if _, err := s.tx.Exec(sql); err != nil {
+				return err
+			}
+		}
 	}
 
-	for _, field := range removeFields {
-		sql := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", collection.Name, field.Name)
+	for _, field := range createFields {
+		sql, err := addColumnSQL(dialect, collection, field)
+		if err != nil {
+			return err
+		}
+
 		if _, err := s.tx.Exec(sql); err != nil {
 			return err
 		}
 	}
 
-	for _, field := range renameFields {
-		sql := fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", collection.Name, field.original.Name, field.Name)
+	for _, sequence := range dropSequences {
+		if _, err := s.tx.Exec("DROP SEQUENCE IF EXISTS " + dialect.Quote(sequence)); err != nil {
+			return err
+		}
+	}
+
+	junctions, err := junctionStatements(dialect, collection, s.junctionTableSQL)
+	if err != nil {
+		return err
+	}
+
+	return s.execAll(append(junctions, createIndexes...))
+}
+
+// creates the given sequences of serial fields of the given table. Sequences
+// of existing columns start after the largest value of the column and after
+// the values drawn from the previous sequence, so that values are not reused.
+// Expects the table and its columns to be renamed already.
+func (s DuckDBTransaction) createSequences(table string, sequences []serialSequence) error {
+	dialect := s.tx.dialect
+
+	for _, sequence := range sequences {
+		start := int64(1)
+
+		if sequence.field.original != nil {
+			sql := fmt.Sprintf("SELECT COALESCE(MAX(TRY_CAST(%s AS BIGINT)), 0) + 1 FROM %s", dialect.Quote(sequence.field.Name), dialect.Quote(table))
+			if err := s.tx.QueryRow(sql).Scan(&start); err != nil {
+				return err
+			}
+		}
+
+		if sequence.previous != "" {
+			var next int64
+			if err := s.tx.QueryRow("SELECT nextval(" + quoteLiteral(sequence.previous) + ")").Scan(&next); err != nil {
+				return err
+			}
+
+			start = max(start, next)
+		}
+
+		sql := fmt.Sprintf("CREATE SEQUENCE %s START WITH %d", dialect.Quote(sequence.name), start)
 		if _, err := s.tx.Exec(sql); err != nil {
 			return err
 		}
 	}
 
-	for _, field := range createFields {
-		sql := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", collection.Name, columnSQL(field.Name, field.Schema.Type))
+	return nil
+}
+
+// loads the spatial extension required by point fields
+func (s DuckDBTransaction) loadSpatial(collection Collection) error {
+	hasPoint := lo.SomeBy(collection.Schema.Fields, func(field *Field) bool {
+		_, ok := field.Schema.Type.(FieldTypePoint)
+		return ok
+	})
+
+	if !hasPoint {
+		return nil
+	}
+
+	if _, err := s.tx.Exec("LOAD spatial"); err != nil {
+		return fmt.Errorf("configuration error, point fields require the DuckDB spatial extension, install it using INSTALL spatial: %w", err)
+	}
+
+	return nil
+}
+
+// ensures that no record holds a value removed from a native enum since
+// converting the column would fail with an obscure error otherwise
+func (s DuckDBTransaction) checkRemovedEnumValues(table string, fields []*Field) error {
+	for _, field := range fields {
+		origFt, ok := field.original.Schema.Type.(FieldTypeEnum)
+		if !ok || origFt.Storage != EnumStorageNative {
+			continue
+		}
+
+		ft, ok := field.Schema.Type.(FieldTypeEnum)
+		if !ok {
+			continue
+		}
+
+		removed, _ := lo.Difference(origFt.EnumValues, ft.EnumValues)
+		if len(removed) == 0 {
+			continue
+		}
+
+		placeholders := strings.Join(lo.Map(removed, func(string, int) string { return "?" }), ", ")
+		query := fmt.Sprintf("SELECT DISTINCT CAST(%s AS TEXT) FROM %s WHERE CAST(%s AS TEXT) IN (%s) ORDER BY 1", s.tx.dialect.Quote(field.Name), s.tx.dialect.Quote(table), s.tx.dialect.Quote(field.Name), placeholders)
+
+		rows, err := s.tx.Query(query, lo.ToAnySlice(removed)...)
+		if err != nil {
+			return err
+		}
+
+		inUse := []string{}
+		for rows.Next() {
+			var value string
+			if err := rows.Scan(&value); err != nil {
+				rows.Close()
+				return err
+			}
+
+			inUse = append(inUse, value)
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(inUse) > 0 {
+			return fmt.Errorf("field %q: cannot remove enum values [%s], still in use", field.Name, strings.Join(inUse, ", "))
+		}
+	}
+
+	return nil
+}
+
+// the source column has no foreign key since DuckDB cannot delete a record and
+// the rows referencing it within the same transaction; links are removed by
+// DeleteRecord instead
+func (s DuckDBTransaction) junctionTableSQL(table string, collection string, ft FieldTypeMultiRelation) (string, error) {
+	source, err := columnSQL(s.tx.dialect, "source", FieldTypeId{})
+	if err != nil {
+		return "", err
+	}
+
+	target, err := columnSQL(s.tx.dialect, "target", FieldTypeSingleRelation{Collection: ft.Collection, CascadeDelete: ft.CascadeDelete})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (%s, %s)", s.tx.dialect.Quote(table), source, target), nil
+}
+
+// DuckDB rejects foreign keys with ON DELETE or ON UPDATE actions, so relations
+// using them are reported as unsupported before any DDL is run
+func validateDuckDBReferentialActions(collection Collection) error {
+	for _, field := range collection.Schema.Fields {
+		// the junction tables of multi relations reference the target
+		if ft, ok := field.Schema.Type.(FieldTypeMultiRelation); ok && ft.CascadeDelete {
+			return fmt.Errorf("field %q: %w: DuckDB does not support ON DELETE CASCADE", field.Name, ErrUnsupported)
+		}
+
+		ft, ok := field.Schema.Type.(FieldTypeSingleRelation)
+		if !ok {
+			continue
+		}
+
+		switch ft.deleteAction() {
+		case DeleteCascade:
+			return fmt.Errorf("field %q: %w: DuckDB does not support ON DELETE CASCADE", field.Name, ErrUnsupported)
+		case DeleteSetNull:
+			return fmt.Errorf("field %q: %w: DuckDB does not support ON DELETE SET NULL", field.Name, ErrUnsupported)
+		}
+
+		if ft.CascadeUpdate {
+			return fmt.Errorf("field %q: %w: DuckDB does not support ON UPDATE CASCADE", field.Name, ErrUnsupported)
+		}
+	}
+
+	return nil
+}
+
+// executes the given statements in order
+func (s DuckDBTransaction) execAll(statements []string) error {
+	for _, sql := range statements {
 		if _, err := s.tx.Exec(sql); err != nil {
 			return err
 		}
@@ -122,77 +685,390 @@ func (s DuckDBTransaction) SaveCollection(collection Collection) error {
 
 // DropCollection implements DatabaseTransaction.
 func (s DuckDBTransaction) DropCollection(collection Collection) error {
-	panic("unimplemented")
+	// nothing to drop if the collection has never been migrated
+	if collection.original == nil {
+		return nil
+	}
+
+	if err := s.execAll(dropJunctionStatements(s.tx.dialect, collection)); err != nil {
+		return err
+	}
+
+	sql := fmt.Sprintf("DROP TABLE IF EXISTS %s", s.tx.dialect.Quote(collection.Name))
+	if _, err := s.tx.Exec(sql); err != nil {
+		return err
+	}
+
+	// sequences are dropped once no column depends on them anymore
+	for _, field := range collection.Schema.Fields {
+		if isSerial(field.Schema.Type) {
+			sql := "DROP SEQUENCE IF EXISTS " + s.tx.dialect.Quote(serialSequenceName(collection.Name, field.Name))
+			if _, err := s.tx.Exec(sql); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
 // SaveView implements DatabaseTransaction.
 func (s DuckDBTransaction) SaveView(view View) error {
-	panic("unimplemented")
+	if err := validateView(view); err != nil {
+		return err
+	}
+
+	statements := []string{}
+
+	// drop view if renamed
+	if view.originalName != "" && view.originalName != view.Name {
+		statements = append(statements, fmt.Sprintf("DROP VIEW IF EXISTS %s", s.tx.dialect.Quote(view.originalName)))
+	}
+
+	statements = append(statements, fmt.Sprintf("CREATE OR REPLACE VIEW %s AS %s", s.tx.dialect.Quote(view.Name), view.Schema.Query))
+	return s.execAll(statements)
 }
 
 // DropView implements DatabaseTransaction.
 func (s DuckDBTransaction) DropView(view View) error {
-	panic("unimplemented")
+	// nothing to drop if the view has never been migrated
+	if view.originalName == "" {
+		return nil
+	}
+
+	sql := fmt.Sprintf("DROP VIEW IF EXISTS %s", s.tx.dialect.Quote(view.Name))
+	_, err := s.tx.Exec(sql)
+	return err
+}
+
+// ListCollections implements DatabaseTransaction.
+func (s DuckDBTransaction) ListCollections() ([]string, error) {
+	return listTableNames(s.tx, `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name`)
+}
+
+// ListViews implements DatabaseTransaction.
+func (s DuckDBTransaction) ListViews() ([]string, error) {
+	return listTableNames(s.tx, `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_type = 'VIEW' ORDER BY table_name`)
 }
 
 // MigrationExists implements DatabaseTransaction.
 func (s DuckDBTransaction) MigrationExists(migrationName string) (bool, error) {
-	panic("unimplemented")
+	return migrationExists(s.tx, migrationName)
 }
 
 // FinishMigration implements DatabaseTransaction.
-func (s DuckDBTransaction) FinishMigration(migrationName string) error {
-	panic("unimplemented")
+func (s DuckDBTransaction) FinishMigration(migrationName string, checksum string) error {
+	return finishMigration(s.tx, migrationName, checksum)
+}
+
+// VerifyMigration implements DatabaseTransaction.
+func (s DuckDBTransaction) VerifyMigration(migrationName string, checksum string) error {
+	return verifyMigration(s.tx, migrationName, checksum)
+}
+
+// RevertMigration implements DatabaseTransaction.
+func (s DuckDBTransaction) RevertMigration(migrationName string) error {
+	return revertMigration(s.tx, migrationName)
+}
+
+// AppliedMigrations implements DatabaseTransaction.
+func (s DuckDBTransaction) AppliedMigrations() ([]string, error) {
+	return appliedMigrations(s.tx)
+}
+
+// MigrationHistory implements DatabaseTransaction.
+func (s DuckDBTransaction) MigrationHistory() ([]MigrationHistoryEntry, error) {
+	return migrationHistory(s.tx)
+}
+
+// CurrentSchemaVersion implements DatabaseTransaction.
+func (s DuckDBTransaction) CurrentSchemaVersion() (int, error) {
+	return currentSchemaVersion(s.tx)
+}
+
+// Savepoint implements DatabaseTransaction; DuckDB does not support savepoints.
+func (s DuckDBTransaction) Savepoint(name string) error {
+	return ErrUnsupported
+}
+
+// RollbackTo implements DatabaseTransaction; DuckDB does not support savepoints.
+func (s DuckDBTransaction) RollbackTo(name string) error {
+	return ErrUnsupported
+}
+
+// CreateRecord implements DatabaseTransaction.
+func (s DuckDBTransaction) CreateRecord(collection string, fields map[string]FieldType, data map[string]any) (string, error) {
+	return createRecord(s.tx, s, collection, fields, data)
+}
+
+// CreateRecords implements DatabaseTransaction.
+func (s DuckDBTransaction) CreateRecords(collection string, fields map[string]FieldType, rows []map[string]any) ([]string, error) {
+	return createRecords(s.tx, s, collection, fields, rows)
+}
+
+// GetRecord implements DatabaseTransaction.
+func (s DuckDBTransaction) GetRecord(collection string, fields map[string]FieldType, id string) (map[string]any, error) {
+	return getRecord(s.tx, s, collection, fields, id)
+}
+
+// UpsertRecord implements DatabaseTransaction.
+func (s DuckDBTransaction) UpsertRecord(collection string, fields map[string]FieldType, conflictColumns []string, data map[string]any) (string, error) {
+	return upsertRecord(s.tx, s, collection, fields, conflictColumns, data)
+}
+
+// UpdateRecord implements DatabaseTransaction.
+func (s DuckDBTransaction) UpdateRecord(collection string, fields map[string]FieldType, id string, data map[string]any) error {
+	return updateRecord(s.tx, s, collection, fields, id, data, nil)
+}
+
+// UpdateRecordVersion implements DatabaseTransaction.
+func (s DuckDBTransaction) UpdateRecordVersion(collection string, fields map[string]FieldType, id string, version int64, data map[string]any) error {
+	return updateRecord(s.tx, s, collection, fields, id, data, &version)
+}
+
+// ListRecords implements DatabaseTransaction.
+func (s DuckDBTransaction) ListRecords(collection string, fields map[string]FieldType, opts ListOptions) ([]map[string]any, int64, error) {
+	return listRecords(s.tx, s, collection, fields, opts)
+}
+
+// IterateRecords implements DatabaseTransaction.
+func (s DuckDBTransaction) IterateRecords(collection string, fields map[string]FieldType, opts ListOptions, fn func(record map[string]any) error) error {
+	return iterateRecords(s.tx, s, collection, fields, opts, fn)
+}
+
+// CountRecords implements DatabaseTransaction.
+func (s DuckDBTransaction) CountRecords(collection string, fields map[string]FieldType, opts ListOptions) (int64, error) {
+	return countRecords(s.tx, s, collection, fields, opts)
+}
+
+// Query implements DatabaseTransaction.
+func (s DuckDBTransaction) Query(collection string, fields map[string]FieldType, sql string, args ...any) ([]map[string]any, error) {
+	return queryRecords(s.tx, s, collection, fields, sql, args)
+}
+
+// Aggregate implements DatabaseTransaction.
+func (s DuckDBTransaction) Aggregate(collection string, fields map[string]FieldType, spec AggregateSpec) ([]map[string]any, error) {
+	return aggregateRecords(s.tx, s, collection, fields, spec, func(column string, fieldType FieldType) string {
+		return column
+	})
+}
+
+// DeleteRecord implements DatabaseTransaction.
+//
+// DuckDB does not support cascading foreign keys, so relations with
+// CascadeDelete cannot be saved and deleting a record that is still referenced
+// always fails with ErrRecordReferenced.
+func (s DuckDBTransaction) DeleteRecord(collection string, fields map[string]FieldType, id string) error {
+	return referencedError(deleteRecord(s.tx, collection, fields, id))
+}
+
+// TruncateCollection implements DatabaseTransaction.
+//
+// Foreign keys are checked immediately and against the state at the beginning
+// of the transaction, so truncating a collection fails with
+// ErrRecordReferenced as long as other collections referenced its records
+// then, even if the referencing records have been deleted since. Sequences
+// cannot be restarted while a column depends on them, so restarting the
+// sequences of serial fields returns ErrUnsupported.
+func (s DuckDBTransaction) TruncateCollection(collection string, fields map[string]FieldType, opts TruncateOptions) error {
+	if opts.RestartSequences && lo.SomeBy(lo.Values(fields), isSerial) {
+		return fmt.Errorf("%w: cannot restart sequences of collection %q", ErrUnsupported, collection)
+	}
+
+	return referencedError(truncateRecords(s.tx, collection, fields))
+}
+
+// wraps foreign key violations with ErrRecordReferenced
+func referencedError(err error) error {
+	var duckdbErr *duckdb.Error
+	if errors.As(err, &duckdbErr) && duckdbErr.Type == duckdb.ErrorTypeConstraint && strings.Contains(duckdbErr.Msg, "foreign key") {
+		return fmt.Errorf("%w: %w", ErrRecordReferenced, err)
+	}
+
+	return err
+}
+
+// DuckDB natively supports all values produced by FieldType.Encode except
+// arrays, which cannot be bound as a single parameter; durations and points of
+// filters, which are not encoded by their field type, are converted as well
+func (s DuckDBTransaction) encodeValue(fieldType FieldType, value any) any {
+	switch v := value.(type) {
+	case time.Duration:
+		return int64(v)
+
+	case Point:
+		return v.WKT()
+
+	case []any:
+		if ft, ok := baseFieldType(fieldType).(FieldTypeArray); ok {
+			list := sqlList{elementType: columnType(ft.Element), values: make([]any, len(v))}
+			for i, element := range v {
+				list.values[i] = s.encodeValue(ft.Element, element)
+			}
+
+			return list
+		}
+	}
+
+	return value
+}
+
+// points are read as well-known text since GEOMETRY values are scanned in an
+// internal format
+func (s DuckDBTransaction) selectSQL(fieldType FieldType, column string) string {
+	if _, ok := baseFieldType(fieldType).(FieldTypePoint); ok {
+		return "ST_AsText(" + column + ")"
+	}
+
+	return column
+}
+
+// DECIMAL columns are scanned as duckdb.Decimal, including list elements;
+// other values are decoded by the field type
+func (s DuckDBTransaction) decodeValue(fieldType FieldType, value any) (any, error) {
+	return fieldType.Decode(decodeDuckDBValue(value))
 }
 
-func withNullConstraint(sql string, nullable bool) string {
-	if nullable {
-		return sql + " NULL"
+// converts values scanned as driver types
+func decodeDuckDBValue(value any) any {
+	switch v := value.(type) {
+	case duckdb.Decimal:
+		r := new(big.Rat).SetFrac(v.Value, pow10(int(v.Scale)))
+		return r.FloatString(int(v.Scale))
+
+	case []any:
+		return lo.Map(v, func(element any, i int) any {
+			return decodeDuckDBValue(element)
+		})
 	}
 
-	return sql + " NOT NULL"
+	return value
 }
 
-func columnSQL(column string, fieldType FieldType) string {
+// returns the DuckDB column type of the given field type; returns an empty
+// string if the field type is not supported
+func columnType(fieldType FieldType) string {
 	switch ft := fieldType.(type) {
 	case FieldTypeBool:
-		return withNullConstraint(column+" BOOL", ft.Nullable)
+		return "BOOL"
 
 	case FieldTypeDateTime:
-		return withNullConstraint(column+" TIMESTAMP", ft.Nullable)
+		return "TIMESTAMP"
 
 	case FieldTypeEnum:
-		return withNullConstraint(column+" TEXT", ft.Nullable)
+		// DuckDB cannot add values to existing enum types; anonymous enum
+		// types are used instead so that changing the values converts the column
+		if ft.Storage == EnumStorageNative {
+			values := lo.Map(ft.EnumValues, func(value string, i int) string {
+				return quoteLiteral(value)
+			})
+
+			return "ENUM(" + strings.Join(values, ", ") + ")"
+		}
+
+		return "TEXT"
+
+	case FieldTypeEmail, FieldTypeId, FieldTypeIP, FieldTypeSingleRelation, FieldTypeText, FieldTypeURL:
+		return "TEXT"
 
 	case FieldTypeFloat:
-		return withNullConstraint(column+" REAL", ft.Nullable)
+		return "REAL"
+
+	case FieldTypeInt, FieldTypeDuration, FieldTypeSerial:
+		return "BIGINT"
+
+	case FieldTypeUUID:
+		return "UUID"
+
+	case FieldTypeDecimal:
+		return fmt.Sprintf("DECIMAL(%d, %d)", ft.Precision, ft.Scale)
+
+	case FieldTypeBytes:
+		return "BLOB"
 
-	case FieldTypeId:
-		sql := withNullConstraint(column+" TEXT", ft.Nullable || ft.PrimaryKey)
+	case FieldTypeJSON:
+		return "JSON"
 
-		if ft.PrimaryKey {
-			sql += " PRIMARY KEY"
+	case FieldTypePoint:
+		return "GEOMETRY"
+
+	case FieldTypeArray:
+		element := columnType(ft.Element)
+		if element == "" {
+			return ""
 		}
 
-		return sql
+		return element + "[]"
+
+	case FieldTypeComputed:
+		return columnType(ft.Base)
 
-	case FieldTypeInt:
-		return withNullConstraint(column+" BIGINT", ft.Nullable)
+	default:
+		return ""
+	}
+}
 
-	case FieldTypeSingleRelation:
-		sql := withNullConstraint(column+" TEXT", ft.Nullable)
-		sql += " REFERENCES " + ft.Collection + "(id)"
+// maps a DuckDB column type to the closest field type; inverse of columnType
+func duckdbFieldType(dataType string, precision int, scale int) (FieldType, error) {
+	if elementType, ok := strings.CutSuffix(dataType, "[]"); ok {
+		// the precision and scale of decimal elements are only part of the
+		// type name
+		if strings.HasPrefix(elementType, "DECIMAL") {
+			fmt.Sscanf(elementType, "DECIMAL(%d,%d)", &precision, &scale)
+		}
 
-		if ft.CascadeDelete {
-			sql += " ON DELETE CASCADE"
+		element, err := duckdbFieldType(elementType, precision, scale)
+		if err != nil {
+			return nil, err
 		}
 
-		return sql
+		return FieldTypeArray{Element: element}, nil
+	}
+
+	if strings.HasPrefix(dataType, "DECIMAL") {
+		return FieldTypeDecimal{Precision: precision, Scale: scale}, nil
+	}
+
+	if strings.HasPrefix(dataType, "ENUM(") {
+		values, err := parseEnumValues(dataType)
+		if err != nil {
+			return nil, err
+		}
+
+		return FieldTypeEnum{EnumValues: values, Storage: EnumStorageNative}, nil
+	}
+
+	switch dataType {
+	case "BOOLEAN":
+		return FieldTypeBool{}, nil
+
+	case "TIMESTAMP", "TIMESTAMP WITH TIME ZONE", "TIMESTAMP_S", "TIMESTAMP_MS", "TIMESTAMP_NS", "DATE":
+		return FieldTypeDateTime{}, nil
+
+	case "VARCHAR":
+		return FieldTypeText{}, nil
+
+	case "FLOAT", "DOUBLE":
+		return FieldTypeFloat{}, nil
+
+	case "TINYINT", "SMALLINT", "INTEGER", "BIGINT", "UTINYINT", "USMALLINT", "UINTEGER":
+		return FieldTypeInt{}, nil
+
+	case "UUID":
+		return FieldTypeUUID{}, nil
+
+	case "BLOB":
+		return FieldTypeBytes{}, nil
+
+	case "JSON":
+		return FieldTypeJSON{}, nil
 
-	case FieldTypeText:
-		return withNullConstraint(column+" TEXT", ft.Nullable)
+	case "GEOMETRY":
+		return FieldTypePoint{}, nil
 
 	default:
-		panic("SQLiteAdapter: unexpected fieldType")
+		return nil, fmt.Errorf("unsupported column type %q", dataType)
 	}
 }
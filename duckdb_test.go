@@ -0,0 +1,88 @@
+package ldb_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestDuckDBOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	adapter, err := ldb.OpenDuckDBAdapter(path, ldb.WithPragma("memory_limit", "256MB"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := beginTestRecords(t, adapter)
+	if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := adapter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// read-only databases can be opened multiple times at once
+	readers := []*ldb.DuckDBAdapter{}
+	for range 2 {
+		reader, err := ldb.OpenDuckDBAdapter(path, ldb.WithReadOnly(), ldb.WithMaxOpenConns(1), ldb.WithPragma("threads", "2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+
+		readers = append(readers, reader)
+	}
+
+	for _, reader := range readers {
+		tx, err := reader.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tx.GetRecord("authors", fieldTypes(testAuthors), testId0); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"name": "John"}); err == nil {
+			t.Fatal("expected write to a read-only database to fail")
+		}
+
+		tx.Rollback()
+	}
+
+	for _, pragma := range [][2]string{{"memory limit", "1GB"}, {"unknown_pragma", "1"}} {
+		if _, err := ldb.OpenDuckDBAdapter(path, ldb.WithReadOnly(), ldb.WithPragma(pragma[0], pragma[1])); err == nil {
+			t.Fatalf("expected error for pragma %q", pragma[0])
+		}
+	}
+}
+
+func TestDuckDBAdapterDB(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	tx := beginTestRecords(t, adapter)
+	if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var name string
+	if err := adapter.DB().QueryRow("SELECT name FROM authors WHERE id = ?", testId0).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+
+	if name != "Jane" {
+		t.Fatalf("expected Jane, got %q", name)
+	}
+}
@@ -0,0 +1,344 @@
+package ldb
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Migrations are generated by matching the collections of two schema snapshots
+// and saving or dropping each collection like a hand-written migration would.
+// Collections and fields are matched by name unless they have been forwarded
+// and renamed since, in which case they are matched by their name on the last
+// migration; renames not tracked this way result in a drop and a create. The
+// statements are independent of the database; the SQL they result in can be
+// inspected by applying them within a dry run.
+
+type StatementKind string
+
+const (
+	StatementCreateCollection StatementKind = "createCollection"
+	StatementAlterCollection  StatementKind = "alterCollection"
+	StatementDropCollection   StatementKind = "dropCollection"
+)
+
+// schema change of a generated migration
+type Statement struct {
+	Kind StatementKind
+	// collection to save or drop; altered collections are linked to their
+	// previous state, so that saving them applies the changes
+	Collection Collection
+	// changes of the collection; empty for dropped collections
+	Diff CollectionDiff
+}
+
+// applies the statement using the given transaction
+func (s Statement) Apply(tx DatabaseTransaction) error {
+	if s.Kind == StatementDropCollection {
+		return tx.DropCollection(s.Collection)
+	}
+
+	return tx.SaveCollection(s.Collection)
+}
+
+// describes the statement, e.g.
+// alter collection posts (add body, rename title to headline)
+func (s Statement) String() string {
+	switch s.Kind {
+	case StatementCreateCollection:
+		return "create collection " + s.Collection.Name
+
+	case StatementDropCollection:
+		return "drop collection " + s.Collection.Name
+	}
+
+	changes := []string{}
+	if s.Diff.Renamed {
+		changes = append(changes, "rename from "+s.Diff.OriginalName)
+	}
+
+	for _, name := range s.Diff.AddedFields {
+		changes = append(changes, "add "+name)
+	}
+
+	for _, name := range s.Diff.RemovedFields {
+		changes = append(changes, "remove "+name)
+	}
+
+	for _, rename := range s.Diff.RenamedFields {
+		changes = append(changes, "rename "+rename.From+" to "+rename.To)
+	}
+
+	for _, name := range s.Diff.RetypedFields {
+		changes = append(changes, "retype "+name)
+	}
+
+	if len(changes) == 0 {
+		changes = append(changes, "change options")
+	}
+
+	return "alter collection " + s.Collection.Name + " (" + strings.Join(changes, ", ") + ")"
+}
+
+// applies the given statements in order
+func ApplyStatements(tx DatabaseTransaction, statements []Statement) error {
+	for _, statement := range statements {
+		if err := statement.Apply(tx); err != nil {
+			return fmt.Errorf("%s: %w", statement, err)
+		}
+	}
+
+	return nil
+}
+
+// returns a migration applying up and reverting using down; see
+// GenerateMigration
+func StatementMigration(up, down []Statement) Migration {
+	return Migration{
+		Up: func(tx DatabaseTransaction) error {
+			return ApplyStatements(tx, up)
+		},
+		Down: func(tx DatabaseTransaction) error {
+			return ApplyStatements(tx, down)
+		},
+	}
+}
+
+// collection of the old schema matched with a collection of the new schema;
+// either may be nil for created and dropped collections
+type collectionMatch struct {
+	from, to *Collection
+	// names of the fields of from keyed by the name of the matching field of
+	// to
+	fields map[string]string
+}
+
+// returns the match in the opposite direction
+func (m collectionMatch) inverse() collectionMatch {
+	return collectionMatch{from: m.to, to: m.from, fields: lo.Invert(m.fields)}
+}
+
+// GenerateMigration computes the statements migrating from the old to the new
+// schema and the statements reverting them. Collections are saved in
+// dependency order of the new schema, removed collections are dropped in
+// reverse dependency order of the old schema. A nil schema is empty.
+func GenerateMigration(old, new *Schema) (up, down []Statement, err error) {
+	if old == nil {
+		old = NewSchema()
+	}
+
+	if new == nil {
+		new = NewSchema()
+	}
+
+	if err := old.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("old schema: %w", err)
+	}
+
+	if err := new.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("new schema: %w", err)
+	}
+
+	matches, err := matchCollections(old.collections, new.collections)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	up, err = migrationStatements(matches)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	down, err = migrationStatements(lo.Map(matches, func(match collectionMatch, i int) collectionMatch {
+		return match.inverse()
+	}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return up, down, nil
+}
+
+// matches the collections and fields of new with those of old by their name
+// on the last migration if known and by their current name otherwise; renamed
+// collections are matched first, so that a new collection may take the name of
+// a renamed one
+func matchCollections(old, new []Collection) ([]collectionMatch, error) {
+	matches := make([]collectionMatch, len(new))
+	matched := map[string]string{}
+
+	match := func(i int, name string) error {
+		to := &new[i]
+		matches[i] = collectionMatch{to: to}
+
+		from, ok := lo.Find(old, func(collection Collection) bool {
+			return collection.Name == name
+		})
+		if !ok {
+			return nil
+		}
+
+		if other, ok := matched[name]; ok {
+			// untracked collections only take over unmatched names
+			if to.original == nil {
+				return nil
+			}
+
+			return fmt.Errorf("configuration error, collections %q and %q both originate from collection %q", other, to.Name, name)
+		}
+
+		matched[name] = to.Name
+
+		fields := map[string]string{}
+		for _, field := range to.Schema.Fields {
+			name := field.Name
+			if field.original != nil {
+				name = field.original.Name
+			}
+
+			if from.Schema.field(name) == nil {
+				continue
+			}
+
+			if slices.Contains(lo.Values(fields), name) {
+				return fmt.Errorf("configuration error, fields of collection %q both originate from field %q", to.Name, name)
+			}
+
+			fields[field.Name] = name
+		}
+
+		matches[i] = collectionMatch{from: &from, to: to, fields: fields}
+		return nil
+	}
+
+	for i, collection := range new {
+		if collection.original != nil {
+			if err := match(i, collection.original.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, collection := range new {
+		if collection.original == nil {
+			if err := match(i, collection.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i := range old {
+		if _, ok := matched[old[i].Name]; !ok {
+			matches = append(matches, collectionMatch{from: &old[i]})
+		}
+	}
+
+	return matches, nil
+}
+
+// returns the statements applying the given matches; unchanged collections
+// are skipped. Removed collections are dropped before the other collections
+// are saved, since e.g. DuckDB cannot alter tables referenced by others, unless
+// the previous state of a kept collection still references them.
+func migrationStatements(matches []collectionMatch) ([]Statement, error) {
+	saved := map[string]Statement{}
+	saves := []Collection{}
+	drops := []Collection{}
+	// previous states of the collections that are not dropped
+	kept := []Collection{}
+
+	for _, match := range matches {
+		if match.from != nil && match.to != nil {
+			kept = append(kept, *match.from)
+		}
+
+		switch {
+		case match.from == nil:
+			collection := *match.to.Clone()
+			saved[collection.Name] = Statement{Kind: StatementCreateCollection, Collection: collection, Diff: DiffCollection(nil, &collection)}
+			saves = append(saves, collection)
+
+		case match.to == nil:
+			// dropping requires the state on last migration
+			collection := *match.from.Clone()
+			collection.Forward()
+			drops = append(drops, collection)
+
+		case !unchangedMatch(match):
+			collection := linkCollection(*match.from, *match.to, match.fields)
+			saved[collection.Name] = Statement{Kind: StatementAlterCollection, Collection: collection, Diff: DiffCollection(collection.original, &collection)}
+			saves = append(saves, collection)
+		}
+	}
+
+	orderedSaves, err := sortCollections(saves)
+	if err != nil {
+		return nil, err
+	}
+
+	orderedDrops, err := sortCollections(drops)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := func(collection Collection) bool {
+		return lo.SomeBy(kept, func(other Collection) bool {
+			return lo.SomeBy(other.Schema.Fields, func(field *Field) bool {
+				target, ok := relationTarget(field.Schema.Type)
+				return ok && target == collection.Name
+			})
+		})
+	}
+
+	dropStatement := func(collection Collection, i int) Statement {
+		return Statement{Kind: StatementDropCollection, Collection: collection, Diff: CollectionDiff{
+			OriginalName:  collection.Name,
+			Name:          collection.Name,
+			AddedFields:   []string{},
+			RemovedFields: []string{},
+			RenamedFields: []FieldRename{},
+			RetypedFields: []string{},
+		}}
+	}
+
+	lateDrops, earlyDrops := lo.FilterReject(lo.Reverse(orderedDrops), func(collection Collection, i int) bool {
+		return referenced(collection)
+	})
+
+	statements := lo.Map(earlyDrops, dropStatement)
+	for _, collection := range orderedSaves {
+		statements = append(statements, saved[collection.Name])
+	}
+
+	return append(statements, lo.Map(lateDrops, dropStatement)...), nil
+}
+
+// reports whether the matched collections are equal and all fields kept their
+// names
+func unchangedMatch(match collectionMatch) bool {
+	for to, from := range match.fields {
+		if to != from {
+			return false
+		}
+	}
+
+	return match.from.Equal(*match.to)
+}
+
+// returns a clone of to linked to from as its state on last migration, so that
+// saving it migrates from from to to; fields are linked by the given names of
+// the fields of from keyed by the names of the fields of to
+func linkCollection(from, to Collection, fields map[string]string) Collection {
+	linked := *to.Clone()
+	linked.original = from.Clone()
+
+	for _, field := range linked.Schema.Fields {
+		if name, ok := fields[field.Name]; ok {
+			field.original = linked.original.Schema.field(name).Clone()
+		}
+	}
+
+	return linked
+}
@@ -0,0 +1,123 @@
+package ldb_test
+
+import (
+	"slices"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+// returns the descriptions of the given statements
+func statementStrings(statements []ldb.Statement) []string {
+	descriptions := []string{}
+	for _, statement := range statements {
+		descriptions = append(descriptions, statement.String())
+	}
+
+	return descriptions
+}
+
+func TestGenerateMigration(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			authors, posts := *testAuthors.Clone(), *testPosts.Clone()
+			old := ldb.NewSchema(posts, authors)
+
+			// referenced collections are created first and dropped last
+			up, down, err := ldb.GenerateMigration(nil, old)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if descriptions := statementStrings(up); !slices.Equal(descriptions, []string{"create collection authors", "create collection posts"}) {
+				t.Fatalf("unexpected up statements %v", descriptions)
+			}
+
+			if descriptions := statementStrings(down); !slices.Equal(descriptions, []string{"drop collection posts", "drop collection authors"}) {
+				t.Fatalf("unexpected down statements %v", descriptions)
+			}
+
+			if err := ldb.ApplyStatements(tx, up); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("authors", fieldTypes(authors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// forwarded fields are matched by their name on last migration
+			posts.Forward()
+			renamed := *posts.Clone()
+			renamed.Forward()
+			renamed.Schema.Fields[1].Name = "headline"
+			renamed.Schema.Fields = append(renamed.Schema.Fields, &ldb.Field{Name: "body", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{Nullable: true}}})
+
+			tags := ldb.Collection{
+				Name: "tags",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "post", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Collection: "posts"}}},
+					},
+				},
+			}
+
+			up, down, err = ldb.GenerateMigration(old, ldb.NewSchema(authors, tags, renamed))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if descriptions := statementStrings(up); !slices.Equal(descriptions, []string{"alter collection posts (add body, rename title to headline)", "create collection tags"}) {
+				t.Fatalf("unexpected up statements %v", descriptions)
+			}
+
+			if descriptions := statementStrings(down); !slices.Equal(descriptions, []string{"drop collection tags", "alter collection posts (remove body, rename headline to title)"}) {
+				t.Fatalf("unexpected down statements %v", descriptions)
+			}
+
+			if err := ldb.ApplyStatements(tx, up); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("posts", fieldTypes(renamed), map[string]any{"id": testId1, "headline": "a", "body": "b", "author": testId0}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ldb.ApplyStatements(tx, down); err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := tx.GetRecord("posts", fieldTypes(posts), testId1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["title"] != "a" {
+				t.Fatalf("expected title to be restored, got %v", record)
+			}
+
+			// unchanged schemas result in no statements
+			up, down, err = ldb.GenerateMigration(old, old)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(up) != 0 || len(down) != 0 {
+				t.Fatalf("expected no statements, got %v and %v", statementStrings(up), statementStrings(down))
+			}
+		})
+	}
+}
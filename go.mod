@@ -4,6 +4,7 @@ go 1.22.7
 
 require (
 	github.com/marcboeker/go-duckdb v1.8.0
+	github.com/mattn/go-sqlite3 v1.14.24
 	github.com/samber/lo v1.47.0
 )
 
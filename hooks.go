@@ -0,0 +1,243 @@
+package ldb
+
+import (
+	"maps"
+)
+
+// Hooks observe the changes made through a transaction, e.g. for audit logging
+// or cache invalidation. Before hooks run synchronously before each change and
+// may veto it; after commit hooks run once the transaction committed, for each
+// successful change in the order the changes were performed. Changes undone by
+// a rollback, including a rollback to a savepoint, are never reported to after
+// commit hooks.
+
+type EventKind string
+
+const (
+	EventSaveCollection     EventKind = "saveCollection"
+	EventDropCollection     EventKind = "dropCollection"
+	EventSaveView           EventKind = "saveView"
+	EventDropView           EventKind = "dropView"
+	EventCreateRecord       EventKind = "createRecord"
+	EventUpsertRecord       EventKind = "upsertRecord"
+	EventUpdateRecord       EventKind = "updateRecord"
+	EventDeleteRecord       EventKind = "deleteRecord"
+	EventTruncateCollection EventKind = "truncateCollection"
+)
+
+// describes a schema change or record write
+type Event struct {
+	Kind EventKind
+	// name of the changed collection or view
+	Collection string
+	// id of the written record; empty for schema changes and for records
+	// created without id before the change was performed
+	Id string
+	// data of created, upserted and updated records
+	Data map[string]any
+}
+
+type Hooks struct {
+	// called before each change; an error aborts the change and is returned
+	// by the transaction method
+	OnBeforeSave func(event Event) error
+	// called after the transaction committed for each change it performed
+	OnAfterCommit func(event Event)
+}
+
+// forwards all calls to the underlying transaction and runs the hooks for the
+// changes made through it
+type hookTransaction struct {
+	DatabaseTransaction
+	hooks Hooks
+
+	// successful changes in order
+	events []Event
+	// number of events at the time the savepoint with the given name was
+	// created
+	savepoints map[string]int
+}
+
+// WithHooks returns a transaction running the given hooks for all changes made
+// through it; changes made through tx directly are not observed.
+func WithHooks(tx DatabaseTransaction, hooks Hooks) DatabaseTransaction {
+	return &hookTransaction{DatabaseTransaction: tx, hooks: hooks, savepoints: map[string]int{}}
+}
+
+// runs the before hook, performs the change and records the event if the
+// change succeeded; id returns the id of the written record
+func (t *hookTransaction) change(event Event, perform func() (string, error)) (string, error) {
+	if t.hooks.OnBeforeSave != nil {
+		if err := t.hooks.OnBeforeSave(event); err != nil {
+			return "", err
+		}
+	}
+
+	id, err := perform()
+	if err != nil {
+		return "", err
+	}
+
+	if id != "" {
+		event.Id = id
+	}
+
+	t.events = append(t.events, event)
+	return id, nil
+}
+
+// runs fn with a result of no id
+func noId(fn func() error) func() (string, error) {
+	return func() (string, error) {
+		return "", fn()
+	}
+}
+
+func (t *hookTransaction) Commit() error {
+	if err := t.DatabaseTransaction.Commit(); err != nil {
+		return err
+	}
+
+	events := t.events
+	t.events = nil
+
+	if t.hooks.OnAfterCommit != nil {
+		for _, event := range events {
+			t.hooks.OnAfterCommit(event)
+		}
+	}
+
+	return nil
+}
+
+func (t *hookTransaction) Rollback() error {
+	t.events = nil
+	return t.DatabaseTransaction.Rollback()
+}
+
+func (t *hookTransaction) Savepoint(name string) error {
+	if err := t.DatabaseTransaction.Savepoint(name); err != nil {
+		return err
+	}
+
+	t.savepoints[name] = len(t.events)
+	return nil
+}
+
+func (t *hookTransaction) RollbackTo(name string) error {
+	if err := t.DatabaseTransaction.RollbackTo(name); err != nil {
+		return err
+	}
+
+	if n, ok := t.savepoints[name]; ok && n < len(t.events) {
+		t.events = t.events[:n]
+	}
+
+	return nil
+}
+
+func (t *hookTransaction) SaveCollection(collection Collection) error {
+	_, err := t.change(Event{Kind: EventSaveCollection, Collection: collection.Name}, noId(func() error {
+		return t.DatabaseTransaction.SaveCollection(collection)
+	}))
+	return err
+}
+
+func (t *hookTransaction) DropCollection(collection Collection) error {
+	_, err := t.change(Event{Kind: EventDropCollection, Collection: collection.Name}, noId(func() error {
+		return t.DatabaseTransaction.DropCollection(collection)
+	}))
+	return err
+}
+
+func (t *hookTransaction) SaveView(view View) error {
+	_, err := t.change(Event{Kind: EventSaveView, Collection: view.Name}, noId(func() error {
+		return t.DatabaseTransaction.SaveView(view)
+	}))
+	return err
+}
+
+func (t *hookTransaction) DropView(view View) error {
+	_, err := t.change(Event{Kind: EventDropView, Collection: view.Name}, noId(func() error {
+		return t.DatabaseTransaction.DropView(view)
+	}))
+	return err
+}
+
+func (t *hookTransaction) CreateRecord(collection string, fields map[string]FieldType, data map[string]any) (string, error) {
+	event := Event{Kind: EventCreateRecord, Collection: collection, Id: recordId(fields, data), Data: maps.Clone(data)}
+	return t.change(event, func() (string, error) {
+		return t.DatabaseTransaction.CreateRecord(collection, fields, data)
+	})
+}
+
+// runs the before hook for each row before any row is inserted
+func (t *hookTransaction) CreateRecords(collection string, fields map[string]FieldType, rows []map[string]any) ([]string, error) {
+	events := make([]Event, len(rows))
+	for i, data := range rows {
+		events[i] = Event{Kind: EventCreateRecord, Collection: collection, Id: recordId(fields, data), Data: maps.Clone(data)}
+
+		if t.hooks.OnBeforeSave != nil {
+			if err := t.hooks.OnBeforeSave(events[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ids, err := t.DatabaseTransaction.CreateRecords(collection, fields, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, id := range ids {
+		events[i].Id = id
+	}
+
+	t.events = append(t.events, events...)
+	return ids, nil
+}
+
+func (t *hookTransaction) UpsertRecord(collection string, fields map[string]FieldType, conflictColumns []string, data map[string]any) (string, error) {
+	event := Event{Kind: EventUpsertRecord, Collection: collection, Id: recordId(fields, data), Data: maps.Clone(data)}
+	return t.change(event, func() (string, error) {
+		return t.DatabaseTransaction.UpsertRecord(collection, fields, conflictColumns, data)
+	})
+}
+
+func (t *hookTransaction) UpdateRecord(collection string, fields map[string]FieldType, id string, data map[string]any) error {
+	_, err := t.change(Event{Kind: EventUpdateRecord, Collection: collection, Id: id, Data: maps.Clone(data)}, noId(func() error {
+		return t.DatabaseTransaction.UpdateRecord(collection, fields, id, data)
+	}))
+	return err
+}
+
+func (t *hookTransaction) UpdateRecordVersion(collection string, fields map[string]FieldType, id string, version int64, data map[string]any) error {
+	_, err := t.change(Event{Kind: EventUpdateRecord, Collection: collection, Id: id, Data: maps.Clone(data)}, noId(func() error {
+		return t.DatabaseTransaction.UpdateRecordVersion(collection, fields, id, version, data)
+	}))
+	return err
+}
+
+func (t *hookTransaction) DeleteRecord(collection string, fields map[string]FieldType, id string) error {
+	_, err := t.change(Event{Kind: EventDeleteRecord, Collection: collection, Id: id}, noId(func() error {
+		return t.DatabaseTransaction.DeleteRecord(collection, fields, id)
+	}))
+	return err
+}
+
+func (t *hookTransaction) TruncateCollection(collection string, fields map[string]FieldType, opts TruncateOptions) error {
+	_, err := t.change(Event{Kind: EventTruncateCollection, Collection: collection}, noId(func() error {
+		return t.DatabaseTransaction.TruncateCollection(collection, fields, opts)
+	}))
+	return err
+}
+
+// returns the primary key value given in the data of a record, if any
+func recordId(fields map[string]FieldType, data map[string]any) string {
+	primaryKey, ok := primaryKeyField(fields)
+	if !ok {
+		return ""
+	}
+
+	return recordIdString(data[primaryKey])
+}
@@ -0,0 +1,137 @@
+package ldb_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestWithHooks(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			before := []string{}
+			after := []string{}
+			hooks := ldb.Hooks{
+				OnBeforeSave: func(event ldb.Event) error {
+					if event.Data["name"] == "Forbidden" {
+						return errors.New("forbidden")
+					}
+
+					before = append(before, string(event.Kind)+" "+event.Collection)
+					return nil
+				},
+				OnAfterCommit: func(event ldb.Event) {
+					after = append(after, string(event.Kind)+" "+event.Collection+" "+event.Id)
+				},
+			}
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx = ldb.WithHooks(tx, hooks)
+
+			if err := tx.SaveCollection(testAuthors); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(testAuthors)
+			if _, err := tx.CreateRecord("authors", fields, map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.UpdateRecord("authors", fields, testId0, map[string]any{"name": "John"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("authors", fields, map[string]any{"name": "Forbidden"}); err == nil || err.Error() != "forbidden" {
+				t.Fatalf("expected hook error, got %v", err)
+			}
+
+			if len(after) != 0 {
+				t.Fatalf("expected no events before commit, got %v", after)
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []string{"saveCollection authors ", "createRecord authors " + testId0, "updateRecord authors " + testId0}
+			if !slices.Equal(after, expected) {
+				t.Fatalf("expected %v, got %v", expected, after)
+			}
+
+			if !slices.Equal(before, []string{"saveCollection authors", "createRecord authors", "updateRecord authors"}) {
+				t.Fatalf("unexpected before events %v", before)
+			}
+
+			// changes of rolled back transactions are not reported
+			after = after[:0]
+
+			tx, err = adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx = ldb.WithHooks(tx, hooks)
+
+			if err := tx.DeleteRecord("authors", fields, testId0); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.Rollback(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(after) != 0 {
+				t.Fatalf("expected no events after rollback, got %v", after)
+			}
+		})
+	}
+}
+
+func TestWithHooksSavepoint(t *testing.T) {
+	adapter := openTestSQLiteAdapter(t)
+	defer adapter.Close()
+
+	after := []string{}
+	tx := ldb.WithHooks(beginTestRecords(t, adapter), ldb.Hooks{
+		OnAfterCommit: func(event ldb.Event) {
+			after = append(after, string(event.Kind)+" "+event.Id)
+		},
+	})
+
+	fields := fieldTypes(testAuthors)
+	if _, err := tx.CreateRecord("authors", fields, map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Savepoint("sp"); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := tx.CreateRecords("authors", fields, []map[string]any{{"id": testId1, "name": "John"}, {"id": testId2, "name": "Max"}})
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("expected 2 ids, got %v, %v", ids, err)
+	}
+
+	if err := tx.RollbackTo("sp"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(after, []string{"createRecord " + testId0}) {
+		t.Fatalf("expected only the first create, got %v", after)
+	}
+}
@@ -0,0 +1,418 @@
+package ldb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var _ HttpService = (*httpService)(nil)
+
+type httpService struct {
+	adapter     DatabaseAdapter
+	collections map[string]Collection
+	mux         *http.ServeMux
+}
+
+// NewHttpService returns a REST API exposing the records of the given collections:
+//
+//	GET    /api/collections/{name}/records       list records
+//	POST   /api/collections/{name}/records       create a record
+//	GET    /api/collections/{name}/records/{id}  get a record
+//	PATCH  /api/collections/{name}/records/{id}  update a record
+//	DELETE /api/collections/{name}/records/{id}  delete a record
+//
+// Records are listed using the query parameters limit, offset and sort (a
+// field name, prefixed by "-" for descending order). All other parameters are
+// filters: "field=value" compares for equality, "field[op]=value" uses one of
+// the operators eq, ne, lt, le, gt and ge. Values are decoded as JSON if they
+// are not valid for the field as plain strings, so "null" filters for null.
+//
+// Each request is handled in its own transaction bound to the request context.
+// The access control callbacks of the collections are applied.
+func NewHttpService(adapter DatabaseAdapter, collections []Collection) HttpService {
+	s := &httpService{
+		adapter:     adapter,
+		collections: map[string]Collection{},
+		mux:         http.NewServeMux(),
+	}
+
+	for _, collection := range collections {
+		s.collections[collection.Name] = collection
+	}
+
+	s.mux.HandleFunc("GET /api/collections/{name}/records", s.handle(s.listRecords))
+	s.mux.HandleFunc("POST /api/collections/{name}/records", s.handle(s.createRecord))
+	s.mux.HandleFunc("GET /api/collections/{name}/records/{id}", s.handle(s.getRecord))
+	s.mux.HandleFunc("PATCH /api/collections/{name}/records/{id}", s.handle(s.updateRecord))
+	s.mux.HandleFunc("DELETE /api/collections/{name}/records/{id}", s.handle(s.deleteRecord))
+
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *httpService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// error response; Fields holds validation errors keyed by field name
+type httpError struct {
+	Status  int               `json:"-"`
+	Message string            `json:"error"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// handles a request on a collection within a transaction; the handler returns
+// the status and the body of the response
+type collectionHandler func(r *http.Request, tx DatabaseTransaction, collection Collection) (int, any, error)
+
+func (s *httpService) handle(handler collectionHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collection, ok := s.collections[r.PathValue("name")]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, httpError{Message: "collection not found"})
+			return
+		}
+
+		tx, err := s.adapter.BeginTx(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		status, body, err := handler(r, tx, collection)
+		if err != nil {
+			tx.Rollback()
+			writeError(w, err)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, status, body)
+	}
+}
+
+func (s *httpService) listRecords(r *http.Request, tx DatabaseTransaction, collection Collection) (int, any, error) {
+	opts, err := parseListOptions(collection, r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	records, total, err := collection.ListRecords(tx, opts)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, map[string]any{"records": records, "total": total}, nil
+}
+
+func (s *httpService) getRecord(r *http.Request, tx DatabaseTransaction, collection Collection) (int, any, error) {
+	record, err := collection.GetRecord(tx, r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, record, nil
+}
+
+func (s *httpService) createRecord(r *http.Request, tx DatabaseTransaction, collection Collection) (int, any, error) {
+	data, err := decodeRecordData(collection, r, false)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	id, err := collection.CreateRecord(tx, data)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	record, err := tx.GetRecord(collection.Name, collection.Fields(), id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusCreated, record, nil
+}
+
+func (s *httpService) updateRecord(r *http.Request, tx DatabaseTransaction, collection Collection) (int, any, error) {
+	data, err := decodeRecordData(collection, r, true)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	id := r.PathValue("id")
+	if err := collection.UpdateRecord(tx, id, data); err != nil {
+		return 0, nil, err
+	}
+
+	record, err := tx.GetRecord(collection.Name, collection.Fields(), id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, record, nil
+}
+
+func (s *httpService) deleteRecord(r *http.Request, tx DatabaseTransaction, collection Collection) (int, any, error) {
+	if err := collection.DeleteRecord(tx, r.PathValue("id")); err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// decodes the JSON body of the request and validates each field so that all
+// invalid fields are reported at once; only the given fields are validated
+// if partial is set
+func decodeRecordData(collection Collection, r *http.Request, partial bool) (map[string]any, error) {
+	data := map[string]any{}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, httpError{Status: http.StatusBadRequest, Message: "invalid JSON body"}
+	}
+
+	fields := collection.Fields()
+	fieldErrors := map[string]string{}
+
+	for name, value := range data {
+		fieldType, ok := fields[name]
+		if !ok {
+			fieldErrors[name] = "unknown field"
+			continue
+		}
+
+		value, err := decodeJSONValue(fieldType, value)
+		if err != nil {
+			fieldErrors[name] = err.Error()
+			continue
+		}
+
+		data[name] = value
+	}
+
+	if !partial {
+		primaryKey, _ := primaryKeyField(fields)
+
+		for name, fieldType := range fields {
+			// missing primary keys are generated
+			if _, ok := data[name]; ok || name == primaryKey {
+				continue
+			}
+
+			if _, err := fieldType.ValidateValue(nil); err != nil {
+				fieldErrors[name] = err.Error()
+			}
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, httpError{Status: http.StatusBadRequest, Message: "invalid record", Fields: fieldErrors}
+	}
+
+	return data, nil
+}
+
+// converts decoded JSON values into the representation expected by the field
+// and validates them
+func decodeJSONValue(fieldType FieldType, value any) (any, error) {
+	value, err := convertJSONValue(fieldType, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return fieldType.ValidateValue(value)
+}
+
+// converts a decoded JSON value into the representation expected by the field
+func convertJSONValue(fieldType FieldType, value any) (any, error) {
+	switch ft := fieldType.(type) {
+	case FieldTypeJSON:
+		// strings are taken as JSON text by the field type
+		if value != nil {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+
+			value = json.RawMessage(data)
+		}
+
+	case FieldTypeDecimal:
+		if number, ok := value.(json.Number); ok {
+			value = number.String()
+		}
+
+	case FieldTypeInt, FieldTypeDuration, FieldTypeSerial:
+		if number, ok := value.(json.Number); ok {
+			if i, err := number.Int64(); err == nil {
+				value = i
+			} else if f, err := number.Float64(); err == nil {
+				value = f
+			}
+		}
+
+	case FieldTypeArray:
+		if values, ok := value.([]any); ok && ft.Element != nil {
+			elements := make([]any, len(values))
+			for i, element := range values {
+				converted, err := convertJSONValue(ft.Element, element)
+				if err != nil {
+					return nil, err
+				}
+
+				elements[i] = converted
+			}
+
+			value = elements
+		}
+
+	default:
+		if number, ok := value.(json.Number); ok {
+			if f, err := number.Float64(); err == nil {
+				value = f
+			}
+		}
+	}
+
+	return value, nil
+}
+
+// decodes a query parameter; the raw string is used if it is valid for the
+// field, otherwise the value is decoded as JSON
+func decodeQueryValue(fieldType FieldType, str string) (any, error) {
+	value, err := fieldType.ValidateValue(str)
+	if err == nil {
+		return value, nil
+	}
+
+	var decoded any
+	decoder := json.NewDecoder(strings.NewReader(str))
+	decoder.UseNumber()
+	if decoder.Decode(&decoded) != nil {
+		return nil, err
+	}
+
+	// null filters for null values regardless of nullability
+	if decoded == nil {
+		return nil, nil
+	}
+
+	return decodeJSONValue(fieldType, decoded)
+}
+
+var queryOperators = map[string]FilterOperator{
+	"eq": FilterEqual,
+	"ne": FilterNotEqual,
+	"lt": FilterLess,
+	"le": FilterLessOrEqual,
+	"gt": FilterGreater,
+	"ge": FilterGreaterOrEqual,
+}
+
+func parseListOptions(collection Collection, r *http.Request) (ListOptions, error) {
+	opts := ListOptions{}
+	fields := collection.Fields()
+	query := r.URL.Query()
+
+	badRequest := func(format string, args ...any) (ListOptions, error) {
+		return opts, httpError{Status: http.StatusBadRequest, Message: fmt.Sprintf(format, args...)}
+	}
+
+	for key, values := range query {
+		value := values[0]
+
+		switch key {
+		case "limit", "offset":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return badRequest("invalid %s, expected non-negative integer", key)
+			}
+
+			if key == "limit" {
+				opts.Limit = n
+			} else {
+				opts.Offset = n
+			}
+
+		case "sort":
+			opts.OrderBy = strings.TrimPrefix(value, "-")
+			opts.OrderDesc = strings.HasPrefix(value, "-")
+
+			if _, ok := fields[opts.OrderBy]; !ok {
+				return badRequest("invalid sort, unknown field %q", opts.OrderBy)
+			}
+
+		default:
+			name, operator := key, FilterEqual
+			if i := strings.IndexByte(key, '['); i > 0 && strings.HasSuffix(key, "]") {
+				op, ok := queryOperators[key[i+1:len(key)-1]]
+				if !ok {
+					return badRequest("invalid filter %q, unknown operator", key)
+				}
+
+				name, operator = key[:i], op
+			}
+
+			fieldType, ok := fields[name]
+			if !ok {
+				return badRequest("invalid filter %q, unknown field", key)
+			}
+
+			// computed fields are filtered by values of their base type
+			filterValue, err := decodeQueryValue(baseFieldType(fieldType), value)
+			if err != nil {
+				return opts, httpError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid filter %q", key), Fields: map[string]string{name: err.Error()}}
+			}
+
+			opts.Filters = append(opts.Filters, Filter{Field: name, Operator: operator, Value: filterValue})
+		}
+	}
+
+	return opts, nil
+}
+
+func (e httpError) Error() string {
+	return e.Message
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var httpErr httpError
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &httpErr):
+	case errors.As(err, &validationErr):
+		httpErr = httpError{Status: http.StatusBadRequest, Message: "invalid record", Fields: validationErr.Fields()}
+	case errors.Is(err, ErrRecordNotFound):
+		httpErr = httpError{Status: http.StatusNotFound, Message: err.Error()}
+	case errors.Is(err, ErrPermissionDenied):
+		httpErr = httpError{Status: http.StatusForbidden, Message: err.Error()}
+	case errors.Is(err, ErrRecordReferenced):
+		httpErr = httpError{Status: http.StatusConflict, Message: ErrRecordReferenced.Error()}
+	case errors.Is(err, ErrVersionConflict):
+		httpErr = httpError{Status: http.StatusConflict, Message: ErrVersionConflict.Error()}
+	default:
+		httpErr = httpError{Status: http.StatusInternalServerError, Message: "internal server error"}
+	}
+
+	writeJSON(w, httpErr.Status, httpErr)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,128 @@
+package ldb_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestHttpService(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, collection := range []ldb.Collection{testAuthors, testPosts} {
+				if err := tx.SaveCollection(collection); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			authors := *testAuthors.Clone()
+			authors.Schema.AllowDelete = func(record map[string]any) bool {
+				return record["name"] != "Locked"
+			}
+
+			server := httptest.NewServer(ldb.NewHttpService(adapter, []ldb.Collection{authors, testPosts}))
+			defer server.Close()
+
+			request := func(method, path, body string) (int, map[string]any) {
+				t.Helper()
+
+				req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				res, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer res.Body.Close()
+
+				result := map[string]any{}
+				if res.StatusCode != http.StatusNoContent {
+					if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				return res.StatusCode, result
+			}
+
+			status, author := request("POST", "/api/collections/authors/records", `{"id": "`+testId0+`", "name": "Locked"}`)
+			if status != http.StatusCreated || author["name"] != "Locked" {
+				t.Fatalf("unexpected response %v %v", status, author)
+			}
+
+			for i, title := range []string{"a", "b", "c"} {
+				body := fmt.Sprintf(`{"title": %q, "views": %d, "author": %q}`, title, i, testId0)
+				if status, post := request("POST", "/api/collections/posts/records", body); status != http.StatusCreated {
+					t.Fatalf("unexpected response %v %v", status, post)
+				}
+			}
+
+			// all invalid fields are reported
+			status, result := request("POST", "/api/collections/posts/records", `{"views": "many", "unknown": 1}`)
+			fields, _ := result["fields"].(map[string]any)
+			if status != http.StatusBadRequest || fields["title"] == nil || fields["views"] == nil || fields["unknown"] == nil {
+				t.Fatalf("expected field errors, got %v %v", status, result)
+			}
+
+			status, result = request("GET", "/api/collections/posts/records?views[ge]=1&sort=-views&limit=1", "")
+			records, _ := result["records"].([]any)
+			if status != http.StatusOK || result["total"] != 2.0 || len(records) != 1 || records[0].(map[string]any)["title"] != "c" {
+				t.Fatalf("unexpected list response %v %v", status, result)
+			}
+
+			post := records[0].(map[string]any)
+			status, result = request("PATCH", "/api/collections/posts/records/"+post["id"].(string), `{"views": 10}`)
+			if status != http.StatusOK || result["views"] != 10.0 || result["title"] != "c" {
+				t.Fatalf("unexpected update response %v %v", status, result)
+			}
+
+			status, result = request("GET", "/api/collections/posts/records?rating=null&title=c", "")
+			if status != http.StatusOK || result["total"] != 1.0 {
+				t.Fatalf("unexpected list response %v %v", status, result)
+			}
+
+			if status, result := request("GET", "/api/collections/posts/records?views[like]=1", ""); status != http.StatusBadRequest {
+				t.Fatalf("expected bad request, got %v %v", status, result)
+			}
+
+			if status, result := request("DELETE", "/api/collections/authors/records/"+testId0, ""); status != http.StatusForbidden {
+				t.Fatalf("expected forbidden, got %v %v", status, result)
+			}
+
+			if status, _ := request("DELETE", "/api/collections/posts/records/"+post["id"].(string), ""); status != http.StatusNoContent {
+				t.Fatalf("expected no content, got %v", status)
+			}
+
+			if status, result := request("GET", "/api/collections/posts/records/"+post["id"].(string), ""); status != http.StatusNotFound {
+				t.Fatalf("expected not found, got %v %v", status, result)
+			}
+
+			if status, result := request("GET", "/api/collections/unknown/records", ""); status != http.StatusNotFound {
+				t.Fatalf("expected not found, got %v %v", status, result)
+			}
+		})
+	}
+}
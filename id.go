@@ -2,37 +2,357 @@ package ldb
 
 import (
 	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
-func GenerateId() string {
-	// MYSQL: CONCAT(UNHEX(CONV(ROUND(UNIX_TIMESTAMP(CURTIME(4))*1000), 10, 16)), RANDOM_BYTES(10))
+// IdConfig describes the format of ids. Ids consist of Length characters of
+// Alphabet. If Timestamp is set, they start with a millisecond timestamp
+// encoded with a fixed width, followed by random characters; ids generated
+// within the same millisecond increment the random part of the previous id so
+// that ids are strictly increasing in lexicographic order, which requires the
+// alphabet to be given in ascending byte order.
+type IdConfig struct {
+	Length int
+	// characters ids consist of; ASCII only
+	Alphabet  string
+	Timestamp bool
+	// accept upper case variants of the lower case letters of the alphabet
+	CaseInsensitive bool
+}
+
+// ids consist of a 48 bit millisecond timestamp followed by 76 bits of
+// entropy, both hex encoded with a fixed width
+var DefaultIdConfig = IdConfig{Length: 31, Alphabet: "0123456789abcdef", Timestamp: true, CaseInsensitive: true}
+
+// largest timestamp embedded in ids
+const maxIdTimestamp = 1<<48 - 1
+
+// timestamp and random part of the last id generated per config
+type idState struct {
+	timestamp int64
+	entropy   []int
+}
+
+var idMutex sync.Mutex
+var idStates = map[IdConfig]*idState{}
 
-	timestamp := int64(time.Now().UnixMilli() * 1000)
+// panics if no randomness is available; see GenerateIdErr
+func GenerateId() string {
+	id, err := GenerateIdErr()
+	if err != nil {
+		panic(err)
+	}
 
-	entropy := make([]byte, 8)
-	rand.Read(entropy)
+	return id
+}
 
-	return fmt.Sprintf("%x%x", timestamp, entropy)
+// like GenerateId, but returns an error if no randomness is available
+func GenerateIdErr() (string, error) {
+	return DefaultIdConfig.Generate()
 }
 
 func ValidateId(value any) error {
+	return DefaultIdConfig.Validate(value)
+}
+
+// ParseIdTime returns the creation time embedded in the given id. The time has
+// millisecond precision and is returned in UTC; ids generated within the same
+// millisecond or while the clock moved backwards may carry a time slightly
+// after their actual creation time.
+func ParseIdTime(id string) (time.Time, error) {
+	return DefaultIdConfig.ParseTime(id)
+}
+
+// returns the config itself or DefaultIdConfig if it is the zero value
+func (c IdConfig) orDefault() IdConfig {
+	if c == (IdConfig{}) {
+		return DefaultIdConfig
+	}
+
+	return c
+}
+
+// returns the id config of id and relation field types
+func fieldIdConfig(fieldType FieldType) (IdConfig, bool) {
+	switch ft := fieldType.(type) {
+	case FieldTypeId:
+		return ft.IdConfig.orDefault(), true
+	case FieldTypeSingleRelation:
+		return ft.IdConfig.orDefault(), true
+	case FieldTypeMultiRelation:
+		return ft.IdConfig.orDefault(), true
+	default:
+		return IdConfig{}, false
+	}
+}
+
+// ensures that ids of the config can be generated
+func (c IdConfig) validateConfig() error {
+	if len(c.Alphabet) < 2 {
+		return fmt.Errorf("configuration error, expected id alphabet of at least 2 characters")
+	}
+
+	for i := 0; i < len(c.Alphabet); i++ {
+		if c.Alphabet[i] >= utf8.RuneSelf {
+			return fmt.Errorf("configuration error, expected ASCII id alphabet")
+		}
+
+		if strings.IndexByte(c.Alphabet[:i], c.Alphabet[i]) >= 0 {
+			return fmt.Errorf("configuration error, duplicate id alphabet character %q", c.Alphabet[i])
+		}
+
+		if c.Timestamp && i > 0 && c.Alphabet[i] < c.Alphabet[i-1] {
+			return fmt.Errorf("configuration error, expected id alphabet in ascending order for timestamps")
+		}
+	}
+
+	if c.Length <= c.timestampWidth() {
+		return fmt.Errorf("configuration error, expected id length greater than %d", c.timestampWidth())
+	}
+
+	return nil
+}
+
+// returns the number of characters encoding the timestamp; zero if ids do not
+// embed a timestamp
+func (c IdConfig) timestampWidth() int {
+	if !c.Timestamp || len(c.Alphabet) < 2 {
+		return 0
+	}
+
+	width := 0
+	for n := int64(maxIdTimestamp); n > 0; n /= int64(len(c.Alphabet)) {
+		width++
+	}
+
+	return width
+}
+
+// generates a new id; returns an error if the config is invalid or no
+// randomness is available
+func (c IdConfig) Generate() (string, error) {
+	if err := c.validateConfig(); err != nil {
+		return "", err
+	}
+
+	base := len(c.Alphabet)
+	width := c.timestampWidth()
+
+	if !c.Timestamp {
+		digits, err := randomIdDigits(c.Length, base)
+		if err != nil {
+			return "", err
+		}
+
+		return c.format(digits), nil
+	}
+
+	idMutex.Lock()
+	defer idMutex.Unlock()
+
+	state, ok := idStates[c]
+	if !ok {
+		state = &idState{}
+		idStates[c] = state
+	}
+
+	timestamp := time.Now().UnixMilli()
+	entropy := slices.Clone(state.entropy)
+
+	var err error
+	if timestamp <= state.timestamp {
+		// same millisecond or clock moved backwards
+		timestamp = state.timestamp
+		if !incrementIdEntropy(entropy, base) {
+			timestamp++
+			entropy, err = randomIdDigits(c.Length-width, base)
+		}
+	} else {
+		entropy, err = randomIdDigits(c.Length-width, base)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	state.timestamp = timestamp
+	state.entropy = entropy
+
+	digits := make([]int, width)
+	for i := width - 1; i >= 0; i-- {
+		digits[i] = int(timestamp % int64(base))
+		timestamp /= int64(base)
+	}
+
+	return c.format(append(digits, entropy...)), nil
+}
+
+// returns the characters of the alphabet at the given indexes
+func (c IdConfig) format(digits []int) string {
+	id := make([]byte, len(digits))
+	for i, digit := range digits {
+		id[i] = c.Alphabet[digit]
+	}
+
+	return string(id)
+}
+
+// returns n uniformly distributed random digits of the given base
+func randomIdDigits(n int, base int) ([]int, error) {
+	// bytes above the largest multiple of the base are rejected since they
+	// would favor small digits
+	limit := 256 - 256%base
+
+	digits := make([]int, 0, n)
+	buffer := make([]byte, n)
+	for len(digits) < n {
+		if _, err := rand.Read(buffer); err != nil {
+			return nil, fmt.Errorf("failed to generate id: %w", err)
+		}
+
+		for _, b := range buffer {
+			if int(b) < limit && len(digits) < n {
+				digits = append(digits, int(b)%base)
+			}
+		}
+	}
+
+	return digits, nil
+}
+
+// increments the given big-endian number of the given base; returns false on
+// overflow
+func incrementIdEntropy(entropy []int, base int) bool {
+	for i := len(entropy) - 1; i >= 0; i-- {
+		entropy[i]++
+		if entropy[i] < base {
+			return true
+		}
+
+		entropy[i] = 0
+	}
+
+	return false
+}
+
+// validates that the given value is an id of the config
+func (c IdConfig) Validate(value any) error {
 	str, ok := value.(string)
 	if !ok {
 		return fmt.Errorf("invalid id, expected string value")
 	}
 
-	const requiredLen = 31
-	if len(str) != requiredLen {
-		return fmt.Errorf("invalid id, expected string of length %v", requiredLen)
+	if len(str) != c.Length {
+		return fmt.Errorf("invalid id, expected string of length %v", c.Length)
 	}
 
-	str = strings.ToLower(str)
-	if len(strings.Trim(str, "0123456789abcdef")) != 0 {
-		return fmt.Errorf("invalid id, expcted hex string")
+	if c.CaseInsensitive {
+		str = strings.ToLower(str)
+	}
+
+	for i := 0; i < len(str); i++ {
+		if strings.IndexByte(c.Alphabet, str[i]) < 0 {
+			return fmt.Errorf("invalid id, expected characters of %q", c.Alphabet)
+		}
 	}
 
 	return nil
 }
+
+// returns a regular expression matching the ids of the config
+func (c IdConfig) pattern() string {
+	characters := c.Alphabet
+	if c.CaseInsensitive {
+		characters += strings.ToUpper(c.Alphabet)
+	}
+
+	var class strings.Builder
+	for i := 0; i < len(characters); i++ {
+		if i > 0 && strings.IndexByte(characters[:i], characters[i]) >= 0 {
+			continue
+		}
+
+		// characters with a special meaning in character classes
+		if strings.IndexByte(`\]^-[`, characters[i]) >= 0 {
+			class.WriteByte('\\')
+		}
+
+		class.WriteByte(characters[i])
+	}
+
+	return fmt.Sprintf("^[%s]{%d}$", class.String(), c.Length)
+}
+
+// returns the creation time embedded in the given id; see ParseIdTime
+func (c IdConfig) ParseTime(id string) (time.Time, error) {
+	if !c.Timestamp {
+		return time.Time{}, fmt.Errorf("invalid id, expected embedded timestamp")
+	}
+
+	if err := c.Validate(id); err != nil {
+		return time.Time{}, err
+	}
+
+	if c.CaseInsensitive {
+		id = strings.ToLower(id)
+	}
+
+	var timestamp int64
+	for i := 0; i < c.timestampWidth(); i++ {
+		timestamp = timestamp*int64(len(c.Alphabet)) + int64(strings.IndexByte(c.Alphabet, id[i]))
+	}
+
+	if timestamp > maxIdTimestamp {
+		return time.Time{}, fmt.Errorf("invalid id, expected 48 bit timestamp")
+	}
+
+	return time.UnixMilli(timestamp).UTC(), nil
+}
+
+// generates a random (version 4) UUID in canonical form
+func GenerateUUID() string {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		panic(fmt.Errorf("failed to generate uuid: %w", err))
+	}
+
+	uuid[6] = (uuid[6] & 0x0f) | 0x40 // version 4
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // variant RFC 4122
+
+	return formatUUID(uuid)
+}
+
+// formats the given UUID in canonical lowercase hyphenated form
+func formatUUID(uuid [16]byte) string {
+	str := hex.EncodeToString(uuid[:])
+	return str[0:8] + "-" + str[8:12] + "-" + str[12:16] + "-" + str[16:20] + "-" + str[20:32]
+}
+
+// parses a UUID in hyphenated or plain hex form
+func parseUUID(str string) ([16]byte, error) {
+	var uuid [16]byte
+
+	if len(str) == 36 {
+		if str[8] != '-' || str[13] != '-' || str[18] != '-' || str[23] != '-' {
+			return uuid, fmt.Errorf("invalid uuid, expected hyphenated form")
+		}
+
+		str = strings.ReplaceAll(str, "-", "")
+	}
+
+	if len(str) != 32 {
+		return uuid, fmt.Errorf("invalid uuid, expected 32 hex digits")
+	}
+
+	if _, err := hex.Decode(uuid[:], []byte(str)); err != nil {
+		return uuid, fmt.Errorf("invalid uuid, expected hex string")
+	}
+
+	return uuid, nil
+}
@@ -0,0 +1,163 @@
+package ldb_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"lehnert.dev/ldb"
+)
+
+func TestGenerateId(t *testing.T) {
+	const count = 100_000
+
+	seen := make(map[string]bool, count)
+	previous := ""
+
+	for range count {
+		id := ldb.GenerateId()
+
+		if len(id) != 31 {
+			t.Fatalf("expected id of length 31, got %q", id)
+		}
+
+		if err := ldb.ValidateId(id); err != nil {
+			t.Fatal(err)
+		}
+
+		if seen[id] {
+			t.Fatalf("duplicate id %q", id)
+		}
+
+		if id <= previous {
+			t.Fatalf("expected id %q to sort after %q", id, previous)
+		}
+
+		seen[id] = true
+		previous = id
+	}
+}
+
+func TestGenerateIdErr(t *testing.T) {
+	id, err := ldb.GenerateIdErr()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ldb.ValidateId(id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseIdTime(t *testing.T) {
+	before := time.Now().Truncate(time.Millisecond)
+	id := ldb.GenerateId()
+	after := time.Now()
+
+	created, err := ldb.ParseIdTime(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created.Before(before) || created.After(after) {
+		t.Fatalf("expected time between %v and %v, got %v", before, after, created)
+	}
+
+	if _, err := ldb.ParseIdTime("invalid"); err == nil {
+		t.Fatal("expected error for malformed id")
+	}
+}
+
+func TestIdConfig(t *testing.T) {
+	config := ldb.IdConfig{Length: 22, Alphabet: "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz", Timestamp: true}
+
+	before := time.Now().Truncate(time.Millisecond)
+	previous := ""
+	for range 1000 {
+		id, err := config.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := config.Validate(id); err != nil {
+			t.Fatal(err)
+		}
+
+		if id <= previous {
+			t.Fatalf("expected id %q to sort after %q", id, previous)
+		}
+
+		previous = id
+	}
+
+	created, err := config.ParseTime(previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created.Before(before) || created.After(time.Now().Add(time.Second)) {
+		t.Fatalf("unexpected creation time %v", created)
+	}
+
+	if err := config.Validate(ldb.GenerateId()); err == nil {
+		t.Fatal("expected default id to be rejected")
+	}
+
+	random := ldb.IdConfig{Length: 8, Alphabet: "xyz"}
+	id, err := random.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(id) != 8 || strings.Trim(id, "xyz") != "" {
+		t.Fatalf("unexpected id %q", id)
+	}
+
+	if _, err := random.ParseTime(id); err == nil {
+		t.Fatal("expected error for id without timestamp")
+	}
+
+	invalid := []ldb.IdConfig{
+		{Length: 8, Alphabet: "x"},
+		{Length: 8, Alphabet: "xyx"},
+		{Length: 8, Alphabet: "fedcba9876543210", Timestamp: true},
+		{Length: 12, Alphabet: "0123456789abcdef", Timestamp: true},
+	}
+
+	for _, config := range invalid {
+		if _, err := config.Generate(); err == nil {
+			t.Errorf("expected configuration error for %+v", config)
+		}
+	}
+}
+
+func TestFieldTypeIdConfig(t *testing.T) {
+	config := ldb.IdConfig{Length: 10, Alphabet: "abcdefghijklmnopqrstuvwxyz"}
+	fieldType := ldb.FieldTypeId{PrimaryKey: true, IdConfig: config}
+
+	id, err := fieldType.ValidateValue(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.Validate(id); err != nil {
+		t.Fatalf("expected generated id to match the config, got %v", err)
+	}
+
+	if _, err := fieldType.ValidateValue(ldb.GenerateId()); err == nil {
+		t.Fatal("expected default id to be rejected")
+	}
+
+	relation := ldb.FieldTypeSingleRelation{Collection: "users", IdConfig: config}
+	if _, err := relation.ValidateValue(id); err != nil {
+		t.Fatal(err)
+	}
+
+	if fieldType.Equal(ldb.FieldTypeId{PrimaryKey: true}) {
+		t.Fatal("expected field types with different id configs to differ")
+	}
+
+	if !(ldb.FieldTypeId{IdConfig: ldb.DefaultIdConfig}).Equal(ldb.FieldTypeId{}) {
+		t.Fatal("expected the zero id config to equal the default")
+	}
+}
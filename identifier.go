@@ -0,0 +1,75 @@
+package ldb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maximum length of collection and field names
+const maxIdentifierLength = 63
+
+// ValidateIdentifier checks if the given name may be used as collection or
+// field name. Valid identifiers consist of ASCII letters, digits and
+// underscores, do not start with a digit and are at most 63 characters long.
+func ValidateIdentifier(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf("invalid identifier, expected non-empty name")
+	}
+
+	if len(name) > maxIdentifierLength {
+		return fmt.Errorf("invalid identifier %q, max length is %v", name, maxIdentifierLength)
+	}
+
+	for i, r := range name {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
+		isDigit := r >= '0' && r <= '9'
+
+		if i == 0 && isDigit {
+			return fmt.Errorf("invalid identifier %q, must not start with a digit", name)
+		}
+
+		if !isLetter && !isDigit {
+			return fmt.Errorf("invalid identifier %q, expected letters, digits and underscores only", name)
+		}
+	}
+
+	return nil
+}
+
+// validates the collection name and all of its field names; field names must
+// be unique within the collection regardless of their case since database
+// identifiers are usually case-insensitive
+func validateCollectionIdentifiers(collection Collection) error {
+	if err := ValidateIdentifier(collection.Name); err != nil {
+		return err
+	}
+
+	seen := map[string]string{}
+	for _, field := range collection.Schema.Fields {
+		if err := ValidateIdentifier(field.Name); err != nil {
+			return err
+		}
+
+		folded := strings.ToLower(field.Name)
+		if other, ok := seen[folded]; ok {
+			return fmt.Errorf("invalid identifier %q, collides with field %q in collection %q", field.Name, other, collection.Name)
+		}
+
+		seen[folded] = field.Name
+	}
+
+	return nil
+}
+
+// validates the view name and ensures that the view has a definition
+func validateView(view View) error {
+	if err := ValidateIdentifier(view.Name); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(view.Schema.Query) == "" {
+		return fmt.Errorf("invalid view %q, expected non-empty query", view.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package ldb_test
+
+import (
+	"strings"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestValidateIdentifier(t *testing.T) {
+	valid := []string{"a", "users", "_private", "Mixed_Case_1", strings.Repeat("a", 63)}
+	for _, name := range valid {
+		if err := ldb.ValidateIdentifier(name); err != nil {
+			t.Errorf("expected %q to be valid, got %v", name, err)
+		}
+	}
+
+	invalid := []string{"", "1abc", "with space", "semi;colon", `quo"te`, "umlaut_ä", strings.Repeat("a", 64)}
+	for _, name := range invalid {
+		if err := ldb.ValidateIdentifier(name); err == nil {
+			t.Errorf("expected %q to be invalid", name)
+		}
+	}
+}
+
+func TestSaveCollectionInvalidIdentifiers(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	collections := []ldb.Collection{
+		{
+			Name: "bad name",
+			Schema: &ldb.CollectionSchema{
+				Fields: []*ldb.Field{
+					{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				},
+			},
+		},
+		{
+			Name: "test",
+			Schema: &ldb.CollectionSchema{
+				Fields: []*ldb.Field{
+					{Name: "", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+				},
+			},
+		},
+		{
+			Name: "test",
+			Schema: &ldb.CollectionSchema{
+				Fields: []*ldb.Field{
+					{Name: "title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+					{Name: "Title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+				},
+			},
+		},
+	}
+
+	for _, collection := range collections {
+		if err := tx.SaveCollection(collection); err == nil {
+			t.Errorf("expected invalid collection %q to be rejected", collection.Name)
+		}
+	}
+}
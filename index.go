@@ -0,0 +1,155 @@
+package ldb
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Unique fields are implemented using unique indexes rather than UNIQUE
+// constraints since neither DuckDB nor SQLite support adding or dropping
+// constraints of existing tables.
+
+// secondary index of a collection spanning one or more columns
+type IndexSchema struct {
+	// unique within the collection
+	Name string
+	// names of the indexed fields in order
+	Columns []string
+	Unique  bool
+}
+
+func (s IndexSchema) Clone() IndexSchema {
+	cloned := s
+	cloned.Columns = slices.Clone(s.Columns)
+	return cloned
+}
+
+// index managed by SaveCollection
+type indexDefinition struct {
+	name    string
+	columns []string
+	unique  bool
+}
+
+func (i indexDefinition) equal(other indexDefinition) bool {
+	return i.name == other.name && i.unique == other.unique && slices.Equal(i.columns, other.columns)
+}
+
+func (i indexDefinition) createSQL(dialect Dialect, table string) string {
+	columns := lo.Map(i.columns, func(column string, _ int) string {
+		return dialect.Quote(column)
+	})
+
+	unique := ""
+	if i.unique {
+		unique = "UNIQUE "
+	}
+
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, dialect.Quote(i.name), dialect.Quote(table), strings.Join(columns, ", "))
+}
+
+func (i indexDefinition) dropSQL(dialect Dialect) string {
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s", dialect.Quote(i.name))
+}
+
+// returns the indexes required by the collection schema
+func (c Collection) indexes() []indexDefinition {
+	indexes := []indexDefinition{}
+
+	for _, field := range c.Schema.Fields {
+		if field.Schema.Unique {
+			indexes = append(indexes, indexDefinition{
+				name:    fmt.Sprintf("_ldb_unique_%s_%s", c.Name, field.Name),
+				columns: []string{field.Name},
+				unique:  true,
+			})
+		}
+	}
+
+	for _, index := range c.Schema.Indexes {
+		indexes = append(indexes, indexDefinition{
+			name:    fmt.Sprintf("_ldb_index_%s_%s", c.Name, index.Name),
+			columns: index.Columns,
+			unique:  index.Unique,
+		})
+	}
+
+	return indexes
+}
+
+// validates the index names and that all indexed columns exist
+func validateIndexes(collection Collection) error {
+	seen := map[string]bool{}
+	for _, index := range collection.Schema.Indexes {
+		if err := ValidateIdentifier(index.Name); err != nil {
+			return err
+		}
+
+		folded := strings.ToLower(index.Name)
+		if seen[folded] {
+			return fmt.Errorf("invalid index %q, duplicate name in collection %q", index.Name, collection.Name)
+		}
+
+		seen[folded] = true
+
+		if len(index.Columns) == 0 {
+			return fmt.Errorf("invalid index %q, expected at least one column", index.Name)
+		}
+
+		for _, column := range index.Columns {
+			field, found := lo.Find(collection.Schema.Fields, func(field *Field) bool {
+				return field.Name == column
+			})
+
+			if !found {
+				return fmt.Errorf("invalid index %q, unknown field %q", index.Name, column)
+			}
+
+			if isMultiRelation(field.Schema.Type) {
+				return fmt.Errorf("invalid index %q, multi relation field %q cannot be indexed", index.Name, column)
+			}
+		}
+	}
+
+	return nil
+}
+
+// computes the statements required to keep the indexes in sync with the
+// collection; dropStatements have to be executed before and createStatements
+// after altering the table since DuckDB does not allow to alter tables with
+// indexes depending on the altered columns. If the table is rebuilt, all
+// indexes are created again since they are dropped together with the old
+// table.
+func indexStatements(dialect Dialect, collection Collection, rebuild bool) (dropStatements []string, createStatements []string) {
+	indexes := collection.indexes()
+
+	if collection.original == nil {
+		return nil, lo.Map(indexes, func(index indexDefinition, _ int) string {
+			return index.createSQL(dialect, collection.Name)
+		})
+	}
+
+	origIndexes := collection.original.indexes()
+
+	// recreate all indexes if the table is restructured
+	_, renameFields, removeFields := collection.fieldChanges()
+	retypeFields := collection.retypedFields(dialect)
+	restructure := rebuild || collection.original.Name != collection.Name || len(renameFields) > 0 || len(removeFields) > 0 || len(retypeFields) > 0
+
+	for _, origIndex := range origIndexes {
+		if restructure || !lo.ContainsBy(indexes, origIndex.equal) {
+			dropStatements = append(dropStatements, origIndex.dropSQL(dialect))
+		}
+	}
+
+	for _, index := range indexes {
+		if restructure || !lo.ContainsBy(origIndexes, index.equal) {
+			createStatements = append(createStatements, index.createSQL(dialect, collection.Name))
+		}
+	}
+
+	return dropStatements, createStatements
+}
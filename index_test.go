@@ -0,0 +1,353 @@
+package ldb_test
+
+import (
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestUniqueField(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			users := ldb.Collection{
+				Name: "users",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "email", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}, Unique: true}},
+						{Name: "name", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+					},
+				},
+			}
+
+			migrate := func() {
+				t.Helper()
+
+				tx, err := adapter.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := tx.SaveCollection(users); err != nil {
+					tx.Rollback()
+					t.Fatal(err)
+				}
+
+				if err := tx.Commit(); err != nil {
+					t.Fatal(err)
+				}
+
+				users.Forward()
+			}
+
+			// inserts a record in a separate transaction since a failed
+			// statement aborts the whole transaction in DuckDB
+			create := func(data map[string]any) error {
+				t.Helper()
+
+				tx, err := adapter.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := tx.CreateRecord("users", fieldTypes(users), data); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				return tx.Commit()
+			}
+
+			migrate()
+
+			if err := create(map[string]any{"id": testId0, "email": "jane@example.com", "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := create(map[string]any{"id": testId1, "email": "jane@example.com", "name": "Jane"}); err == nil {
+				t.Fatal("expected uniqueness error")
+			}
+
+			// uniqueness is kept when renaming the field
+			users.Schema.Fields[1].Name = "mail"
+			migrate()
+
+			if err := create(map[string]any{"id": testId1, "mail": "jane@example.com", "name": "Jane"}); err == nil {
+				t.Fatal("expected uniqueness error after rename")
+			}
+
+			// uniqueness can be moved to another field
+			users.Schema.Fields[1].Schema.Unique = false
+			users.Schema.Fields[2].Schema.Unique = true
+			migrate()
+
+			if err := create(map[string]any{"id": testId1, "mail": "jane@example.com", "name": "John"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := create(map[string]any{"id": testId2, "mail": "john@example.com", "name": "John"}); err == nil {
+				t.Fatal("expected uniqueness error after adding uniqueness")
+			}
+		})
+	}
+}
+
+func TestCollectionIndexes(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			events := ldb.Collection{
+				Name: "events",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "room", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+						{Name: "slot", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{}}},
+					},
+					Indexes: []ldb.IndexSchema{
+						{Name: "by_room", Columns: []string{"room"}},
+						{Name: "by_room_slot", Columns: []string{"room", "slot"}, Unique: true},
+					},
+				},
+			}
+
+			migrate := func() error {
+				t.Helper()
+
+				tx, err := adapter.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := tx.SaveCollection(events); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if err := tx.Commit(); err != nil {
+					t.Fatal(err)
+				}
+
+				events.Forward()
+				return nil
+			}
+
+			create := func(id string, room string, slot int64) error {
+				t.Helper()
+
+				tx, err := adapter.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := tx.CreateRecord("events", fieldTypes(events), map[string]any{"id": id, "room": room, "slot": slot}); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				return tx.Commit()
+			}
+
+			if err := migrate(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := create(testId0, "a", 1); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := create(testId1, "a", 2); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := create(testId2, "a", 1); err == nil {
+				t.Fatal("expected uniqueness error")
+			}
+
+			// indexed columns must exist
+			events.Schema.Indexes = append(events.Schema.Indexes, ldb.IndexSchema{Name: "by_title", Columns: []string{"title"}})
+			if err := migrate(); err == nil {
+				t.Fatal("expected error for unknown indexed column")
+			}
+
+			// removed indexes are dropped
+			events.Schema.Indexes = events.Schema.Indexes[:1]
+			if err := migrate(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := create(testId2, "a", 1); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestIndexesSurviveRebuild(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			users := ldb.Collection{
+				Name: "users",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "email", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}, Unique: true}},
+						{Name: "score", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{Nullable: true}}},
+					},
+				},
+			}
+
+			migrate := func() {
+				t.Helper()
+
+				tx, err := adapter.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := tx.SaveCollection(users); err != nil {
+					tx.Rollback()
+					t.Fatal(err)
+				}
+
+				if err := tx.Commit(); err != nil {
+					t.Fatal(err)
+				}
+
+				users.Forward()
+			}
+
+			// inserts a record in a separate transaction since a failed
+			// statement aborts the whole transaction in DuckDB
+			create := func(data map[string]any) error {
+				t.Helper()
+
+				tx, err := adapter.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := tx.CreateRecord("users", fieldTypes(users), data); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				return tx.Commit()
+			}
+
+			migrate()
+
+			if err := create(map[string]any{"id": testId0, "email": "jane@example.com", "score": 1}); err != nil {
+				t.Fatal(err)
+			}
+
+			type change struct {
+				name  string
+				apply func()
+			}
+
+			// each change rebuilds the table, which must keep its indexes
+			changes := []change{
+				{"check", func() {
+					users.Schema.Fields[2].Schema.DatabaseCheck = true
+					users.Schema.Fields[2].Schema.Type = ldb.FieldTypeInt{Nullable: true, CreateMinValue: func() int64 { return 0 }}
+				}},
+				{"default", func() {
+					users.Schema.Fields[2].Schema.DatabaseDefault = true
+					users.Schema.Fields[2].Schema.Type = ldb.FieldTypeInt{
+						Nullable:           true,
+						CreateMinValue:     func() int64 { return 0 },
+						CreateDefaultValue: func() int64 { return 0 },
+					}
+				}},
+				{"not null", func() {
+					users.Schema.Fields[2].Schema.Type = ldb.FieldTypeInt{
+						CreateMinValue:     func() int64 { return 0 },
+						CreateDefaultValue: func() int64 { return 0 },
+					}
+				}},
+				{"primary key", func() {
+					users.Schema.PrimaryKey = []string{"id", "score"}
+				}},
+				{"computed field", func() {
+					users.Schema.Fields = append(users.Schema.Fields, &ldb.Field{
+						Name:   "double",
+						Schema: &ldb.FieldSchema{Type: ldb.FieldTypeComputed{Expression: "score * 2", Base: ldb.FieldTypeInt{}}},
+					})
+				}},
+				{"unique constraint", func() {
+					users.Schema.UniqueConstraints = [][]string{{"email", "score"}}
+				}},
+			}
+
+			// SQLite supports no serial fields besides primary keys
+			if name == "duckdb" {
+				changes = append(changes, change{"serial field", func() {
+					users.Schema.Fields = append(users.Schema.Fields, &ldb.Field{
+						Name:   "number",
+						Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSerial{}},
+					})
+				}})
+			}
+
+			// DuckDB supports no referential actions
+			if name == "sqlite" {
+				changes = append(changes, change{"referential action", func() {
+					tx, err := adapter.Begin()
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					teams := ldb.Collection{Name: "teams", Schema: &ldb.CollectionSchema{Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+					}}}
+
+					if err := tx.SaveCollection(teams); err != nil {
+						t.Fatal(err)
+					}
+
+					if err := tx.Commit(); err != nil {
+						t.Fatal(err)
+					}
+
+					users.Schema.Fields = append(users.Schema.Fields, &ldb.Field{
+						Name:   "team",
+						Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Collection: "teams", Nullable: true}},
+					})
+					migrate()
+
+					users.Schema.Fields[len(users.Schema.Fields)-1].Schema.Type = ldb.FieldTypeSingleRelation{Collection: "teams", Nullable: true, OnDelete: ldb.DeleteSetNull}
+				}})
+			}
+
+			for _, change := range changes {
+				change.apply()
+				migrate()
+
+				if err := create(map[string]any{"id": testId1, "email": "jane@example.com", "score": 1}); err == nil {
+					t.Fatalf("expected uniqueness error after %s change", change.name)
+				}
+			}
+		})
+	}
+}
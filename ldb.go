@@ -1,22 +1,41 @@
 package ldb
 
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
 type App struct {
 	Migrations      map[string]*Migration
-	DatabaseService *DatabaseService
-	HttpService     *HttpService
+	DatabaseAdapter DatabaseAdapter
+	DatabaseService DatabaseService
+	HttpService     HttpService
+
+	// rolls back migrations and rollbacks not finished within the given
+	// duration; see BeginWithTimeout. No timeout applies if zero.
+	MigrationTimeout time.Duration
 }
 
+// migration functions operate on the transaction the runner opened;
+// returning an error rolls back every migration of the current run
 type Migration struct {
-	Up   func() error
-	Down func() error
+	Up   func(tx DatabaseTransaction) error
+	Down func(tx DatabaseTransaction) error
+
+	// replace null values of fields made non-nullable by the migration with
+	// their default value instead of failing; see WithNullBackfill
+	BackfillNulls bool
 }
 
 type DatabaseService interface {
-	CreateCollection(schema CollectionSchema) error
+	CreateCollection(name string, schema CollectionSchema) error
 	DropCollection(name string) error
 }
 
+// serves the records of collections over HTTP; see NewHttpService
 type HttpService interface {
+	http.Handler
 }
 
 func (app *App) RegisterMigration(name string, migration Migration) {
@@ -27,6 +46,29 @@ func (app *App) RegisterMigration(name string, migration Migration) {
 	app.Migrations[name] = &migration
 }
 
-func (app *App) Start() {
+func (app *App) Start() error {
+	if app.DatabaseAdapter == nil {
+		return fmt.Errorf("no database adapter configured")
+	}
+
+	return app.migrate()
+}
+
+// returns the statements Start would execute to apply the pending migrations
+// without persisting any changes
+func (app *App) DryRun() ([]string, error) {
+	if app.DatabaseAdapter == nil {
+		return nil, fmt.Errorf("no database adapter configured")
+	}
+
+	return app.dryRun()
+}
+
+// reverts the last n applied migrations in reverse order
+func (app *App) Rollback(n int) error {
+	if app.DatabaseAdapter == nil {
+		return fmt.Errorf("no database adapter configured")
+	}
 
+	return app.rollback(n)
 }
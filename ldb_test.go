@@ -1,17 +1,33 @@
 package ldb_test
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/samber/lo"
 	"lehnert.dev/ldb"
 )
 
-func TestSQLite(t *testing.T) {
-	adapter, err := ldb.OpenDuckDBAdapter("/tmp/test.db")
+func openTestAdapter(t *testing.T) *ldb.DuckDBAdapter {
+	t.Helper()
+
+	adapter, err := ldb.OpenDuckDBAdapter(filepath.Join(t.TempDir(), "test.db"))
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	return adapter
+}
+
+func TestSQLite(t *testing.T) {
+	adapter := openTestAdapter(t)
+
 	tx, err := adapter.Begin()
 	if err != nil {
 		t.Fatal(err)
@@ -61,3 +77,1910 @@ func TestSQLite(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestDropCollection(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	parent := ldb.Collection{
+		Name: "parent",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+			},
+		},
+	}
+
+	child := ldb.Collection{
+		Name: "child",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "parent", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Collection: "parent"}}},
+			},
+		},
+	}
+
+	// never migrated, nothing to drop
+	if err := tx.DropCollection(parent); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, collection := range []*ldb.Collection{&parent, &child} {
+		if err := tx.SaveCollection(*collection); err != nil {
+			t.Fatal(err)
+		}
+
+		collection.Forward()
+	}
+
+	// parent is still referenced by child
+	if err := tx.DropCollection(parent); err == nil {
+		t.Fatal("expected foreign key error when dropping referenced collection")
+	}
+
+	if err := tx.DropCollection(child); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.DropCollection(parent); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrationHistory(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, err := tx.MigrationExists("0001_init"); err != nil {
+		t.Fatal(err)
+	} else if exists {
+		t.Fatal("expected migration to not exist")
+	}
+
+	if err := tx.FinishMigration("0001_init", "checksum"); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, err := tx.MigrationExists("0001_init"); err != nil {
+		t.Fatal(err)
+	} else if !exists {
+		t.Fatal("expected migration to exist")
+	}
+
+	if err := tx.VerifyMigration("0001_init", "checksum"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.VerifyMigration("0001_init", "other"); !errors.Is(err, ldb.ErrMigrationChanged) {
+		t.Fatalf("expected changed migration, got %v", err)
+	}
+
+	// a rolled back transaction must not leave a history row behind
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err = adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if exists, err := tx.MigrationExists("0001_init"); err != nil {
+		t.Fatal(err)
+	} else if exists {
+		t.Fatal("expected migration to not exist after rollback")
+	}
+}
+
+func TestAppStartMigrations(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	applied := []string{}
+	app := ldb.App{DatabaseAdapter: adapter}
+
+	for _, name := range []string{"0002_second", "0001_first", "0003_third"} {
+		app.RegisterMigration(name, ldb.Migration{
+			Up: func(tx ldb.DatabaseTransaction) error {
+				applied = append(applied, name)
+				return nil
+			},
+		})
+	}
+
+	if err := app.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"0001_first", "0002_second", "0003_third"}
+	if !slices.Equal(applied, expected) {
+		t.Fatalf("expected migrations %v, got %v", expected, applied)
+	}
+
+	// already applied migrations are only run again to verify their checksum
+	if err := app.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(applied, append(expected, expected...)) {
+		t.Fatalf("expected migrations to be verified in order, got %v", applied)
+	}
+}
+
+func TestMigrationTableWithoutChecksum(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	// history table as created before checksums were stored
+	if _, err := adapter.DB().Exec(`CREATE TABLE _ldb_migrations (name TEXT NOT NULL PRIMARY KEY, applied_at TIMESTAMP NOT NULL);
+		INSERT INTO _ldb_migrations VALUES ('0001_init', now())`); err != nil {
+		t.Fatal(err)
+	}
+
+	app := ldb.App{DatabaseAdapter: adapter}
+	app.RegisterMigration("0001_init", ldb.Migration{})
+	app.RegisterMigration("0002_next", ldb.Migration{})
+
+	if err := app.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	// existing entries are numbered when the table is upgraded
+	history, err := tx.MigrationHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := lo.Map(history, func(entry ldb.MigrationHistoryEntry, i int) string {
+		return fmt.Sprintf("%d %s %s", entry.Version, entry.Direction, entry.Name)
+	})
+
+	expected := []string{"1 up 0001_init", "2 up 0002_next"}
+	if !slices.Equal(names, expected) {
+		t.Fatalf("expected history %v, got %v", expected, names)
+	}
+}
+
+func TestMigrationHistoryVersions(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			app := ldb.App{DatabaseAdapter: adapter}
+			for _, name := range []string{"0001_first", "0002_second"} {
+				app.RegisterMigration(name, ldb.Migration{
+					Up:   func(tx ldb.DatabaseTransaction) error { return nil },
+					Down: func(tx ldb.DatabaseTransaction) error { return nil },
+				})
+			}
+
+			if err := app.Start(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := app.Rollback(1); err != nil {
+				t.Fatal(err)
+			}
+
+			// the reverted migration is applied again
+			if err := app.Start(); err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			history, err := tx.MigrationHistory()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			entries := lo.Map(history, func(entry ldb.MigrationHistoryEntry, i int) string {
+				return fmt.Sprintf("%d %s %s", entry.Version, entry.Direction, entry.Name)
+			})
+
+			expected := []string{"1 up 0001_first", "2 up 0002_second", "3 down 0002_second", "4 up 0002_second"}
+			if !slices.Equal(entries, expected) {
+				t.Fatalf("expected history %v, got %v", expected, entries)
+			}
+
+			if history[0].Checksum == "" || history[2].Checksum != "" || history[0].AppliedAt.IsZero() {
+				t.Fatalf("unexpected history entries %+v", history)
+			}
+
+			if version, err := tx.CurrentSchemaVersion(); err != nil || version != 4 {
+				t.Fatalf("expected schema version 4, got %d (%v)", version, err)
+			}
+
+			applied, err := tx.AppliedMigrations()
+			if err != nil || !slices.Equal(applied, []string{"0001_first", "0002_second"}) {
+				t.Fatalf("expected both migrations to be applied, got %v (%v)", applied, err)
+			}
+		})
+	}
+}
+
+func TestAppStartMigrationChecksum(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	authors := *testAuthors.Clone()
+	migration := ldb.Migration{
+		Up: func(tx ldb.DatabaseTransaction) error {
+			if err := tx.SaveCollection(authors); err != nil {
+				return err
+			}
+
+			_, err := tx.CreateRecord("authors", fieldTypes(authors), map[string]any{"name": "Jane"})
+			return err
+		},
+	}
+
+	app := ldb.App{DatabaseAdapter: adapter}
+	app.RegisterMigration("0001_authors", migration)
+
+	if err := app.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// verifying neither saves the collection again nor inserts records
+	if err := app.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := tx.CountRecords("authors", fieldTypes(authors), ldb.ListOptions{}); err != nil || count != 1 {
+		t.Fatalf("expected a single author, got %d (%v)", count, err)
+	}
+
+	tx.Rollback()
+
+	// editing the applied migration is detected
+	authors.Schema.Fields = append(authors.Schema.Fields, &ldb.Field{
+		Name:   "email",
+		Schema: &ldb.FieldSchema{Type: ldb.FieldTypeEmail{Nullable: true}},
+	})
+
+	if err := app.Start(); !errors.Is(err, ldb.ErrMigrationChanged) || !strings.Contains(err.Error(), "0001_authors") {
+		t.Fatalf("expected changed migration error, got %v", err)
+	}
+}
+
+func TestAppStartDataMigration(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	authors := *testAuthors.Clone()
+	migration := ldb.Migration{
+		Up: func(tx ldb.DatabaseTransaction) error {
+			if err := tx.SaveCollection(authors); err != nil {
+				return err
+			}
+
+			id, err := tx.CreateRecord("authors", fieldTypes(authors), map[string]any{"name": "Jane"})
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.GetRecord("authors", fieldTypes(authors), id)
+			return err
+		},
+	}
+
+	app := ldb.App{DatabaseAdapter: adapter}
+	app.RegisterMigration("0001_authors", migration)
+
+	if err := app.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the record is not created while verifying, so the migration cannot be
+	// verified, which must not prevent the app from starting
+	if err := app.Start(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppStartMigrationsRollback(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	app := ldb.App{DatabaseAdapter: adapter}
+
+	app.RegisterMigration("0001_ok", ldb.Migration{
+		Up: func(tx ldb.DatabaseTransaction) error { return nil },
+	})
+
+	app.RegisterMigration("0002_fail", ldb.Migration{
+		Up: func(tx ldb.DatabaseTransaction) error { return errors.New("failure") },
+	})
+
+	if err := app.Start(); err == nil {
+		t.Fatal("expected failing migration to return an error")
+	}
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if exists, err := tx.MigrationExists("0001_ok"); err != nil {
+		t.Fatal(err)
+	} else if exists {
+		t.Fatal("expected migration history to be rolled back")
+	}
+}
+
+func TestAppRollback(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	reverted := []string{}
+	app := ldb.App{DatabaseAdapter: adapter}
+
+	for _, name := range []string{"0001_first", "0002_second", "0003_third"} {
+		app.RegisterMigration(name, ldb.Migration{
+			Up: func(tx ldb.DatabaseTransaction) error { return nil },
+			Down: func(tx ldb.DatabaseTransaction) error {
+				reverted = append(reverted, name)
+				return nil
+			},
+		})
+	}
+
+	app.RegisterMigration("0000_irreversible", ldb.Migration{
+		Up: func(tx ldb.DatabaseTransaction) error { return nil },
+	})
+
+	if err := app.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Rollback(2); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"0003_third", "0002_second"}
+	if !slices.Equal(reverted, expected) {
+		t.Fatalf("expected reverted migrations %v, got %v", expected, reverted)
+	}
+
+	// 0000_irreversible has no down function
+	if err := app.Rollback(2); err == nil {
+		t.Fatal("expected error when reverting migration without down function")
+	}
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	applied, err := tx.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []string{"0000_irreversible", "0001_first"}
+	if !slices.Equal(applied, expected) {
+		t.Fatalf("expected applied migrations %v, got %v", expected, applied)
+	}
+}
+
+func TestQuotedIdentifiers(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	user := ldb.Collection{
+		Name: "User",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "DisplayName", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+			},
+		},
+	}
+
+	order := ldb.Collection{
+		Name: "order",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "select", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+				{Name: "Group", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{}}},
+				{Name: "from", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Collection: "User"}}},
+			},
+		},
+	}
+
+	for _, collection := range []*ldb.Collection{&user, &order} {
+		if err := tx.SaveCollection(*collection); err != nil {
+			t.Fatal(err)
+		}
+
+		collection.Forward()
+	}
+
+	for _, collection := range []ldb.Collection{order, user} {
+		if err := tx.DropCollection(collection); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// lists the column names of the given table in the given database file
+func tableColumns(t *testing.T, driver string, path string, table string) []string {
+	t.Helper()
+
+	db, err := sql.Open(driver, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	columns := []string{}
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			t.Fatal(err)
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns
+}
+
+func TestRenameField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	adapter, err := ldb.OpenDuckDBAdapter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection := ldb.Collection{
+		Name: "test",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+				{Name: "count", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{}}},
+			},
+		},
+	}
+
+	migrate := func() {
+		tx, err := adapter.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tx.SaveCollection(collection); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		collection.Forward()
+	}
+
+	migrate()
+
+	collection.Schema.Fields[1].Name = "headline"
+	migrate()
+
+	if err := adapter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	columns := tableColumns(t, "duckdb", path, "test")
+	expected := []string{"id", "headline", "count"}
+	if !slices.Equal(columns, expected) {
+		t.Fatalf("expected columns %v, got %v", expected, columns)
+	}
+}
+
+func TestBeginTxCancel(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			tx, err := adapter.BeginTx(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			cancel()
+
+			if err := tx.SaveCollection(*testAuthors.Clone()); !errors.Is(err, context.Canceled) && !errors.Is(err, sql.ErrTxDone) {
+				t.Fatalf("expected cancellation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSaveView(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			for _, data := range []map[string]any{{"id": testId0, "name": "Jane"}, {"id": testId1, "name": "Hidden"}} {
+				if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), data); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			view := ldb.View{
+				Name:   "visible_authors",
+				Schema: ldb.ViewSchema{Query: `SELECT id, name FROM authors WHERE name <> 'Hidden'`},
+			}
+
+			if err := tx.SaveView(view); err != nil {
+				t.Fatal(err)
+			}
+
+			view.Forward()
+
+			// the definition may be replaced
+			view.Schema.Query = `SELECT id, name FROM authors WHERE name = 'Hidden'`
+			if err := tx.SaveView(view); err != nil {
+				t.Fatal(err)
+			}
+
+			view.Forward()
+
+			view.Name = "hidden_authors"
+			if err := tx.SaveView(view); err != nil {
+				t.Fatal(err)
+			}
+
+			records, _, err := tx.ListRecords("hidden_authors", fieldTypes(testAuthors), ldb.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != 1 || records[0]["name"] != "Hidden" {
+				t.Fatalf("unexpected records %v", records)
+			}
+
+			if err := tx.SaveView(ldb.View{Name: "invalid name", Schema: view.Schema}); err == nil {
+				t.Fatal("expected error for invalid view name")
+			}
+		})
+	}
+}
+
+func TestDropView(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			view := ldb.View{
+				Name:   "author_names",
+				Schema: ldb.ViewSchema{Query: `SELECT id, name FROM authors`},
+			}
+
+			// never migrated views are not dropped
+			if err := tx.DropView(view); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.SaveView(view); err != nil {
+				t.Fatal(err)
+			}
+
+			view.Forward()
+
+			if _, _, err := tx.ListRecords("author_names", fieldTypes(testAuthors), ldb.ListOptions{}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.DropView(view); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, _, err := tx.ListRecords("author_names", fieldTypes(testAuthors), ldb.ListOptions{}); err == nil {
+				t.Fatal("expected error when listing dropped view")
+			}
+		})
+	}
+}
+
+func TestListCollections(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			// junction tables and the migration history are internal
+			teams := ldb.Collection{
+				Name: "teams",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "members", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeMultiRelation{Collection: "authors"}}},
+					},
+				},
+			}
+
+			if err := tx.SaveCollection(teams); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.FinishMigration("0001_teams", ""); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.SaveView(ldb.View{Name: "author_names", Schema: ldb.ViewSchema{Query: `SELECT name FROM authors`}}); err != nil {
+				t.Fatal(err)
+			}
+
+			collections, err := tx.ListCollections()
+			if err != nil || !slices.Equal(collections, []string{"authors", "posts", "teams"}) {
+				t.Fatalf("expected authors, posts and teams, got %v (%v)", collections, err)
+			}
+
+			views, err := tx.ListViews()
+			if err != nil || !slices.Equal(views, []string{"author_names"}) {
+				t.Fatalf("expected author_names, got %v (%v)", views, err)
+			}
+		})
+	}
+}
+
+func TestChangeFieldType(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			collection := ldb.Collection{
+				Name: "scores",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "score", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{}}},
+						// indexed columns require a table rebuild in DuckDB
+						{Name: "label", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}, Unique: true}},
+					},
+				},
+			}
+
+			migrate := func() error {
+				tx, err := adapter.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := tx.SaveCollection(collection); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if err := tx.Commit(); err != nil {
+					t.Fatal(err)
+				}
+
+				collection.Forward()
+				return nil
+			}
+
+			if err := migrate(); err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("scores", fieldTypes(collection), map[string]any{"id": testId0, "score": int64(3), "label": "a"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			collection.Schema.Fields[1].Schema.Type = ldb.FieldTypeFloat{}
+			collection.Schema.Fields[2].Schema.Type = ldb.FieldTypeEnum{EnumValues: []string{"a", "b"}}
+			if err := migrate(); err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err = adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			record, err := tx.GetRecord("scores", fieldTypes(collection), testId0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["score"] != 3.0 || record["label"] != "a" {
+				t.Fatalf("unexpected record %v", record)
+			}
+
+			if _, err := tx.CreateRecord("scores", fieldTypes(collection), map[string]any{"id": testId1, "score": 1.5, "label": "c"}); err == nil {
+				t.Fatal("expected error for invalid enum value")
+			}
+		})
+	}
+}
+
+func TestChangeFieldTypeIncompatible(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	collection := ldb.Collection{
+		Name: "values",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "value", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+			},
+		},
+	}
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.SaveCollection(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	collection.Forward()
+
+	if _, err := tx.CreateRecord("values", fieldTypes(collection), map[string]any{"id": testId0, "value": "abc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	collection.Schema.Fields[1].Schema.Type = ldb.FieldTypeInt{}
+	if err := tx.SaveCollection(collection); err == nil {
+		t.Fatal("expected conversion error")
+	}
+}
+
+func TestAppDryRun(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			app := ldb.App{DatabaseAdapter: adapter}
+			app.RegisterMigration("0001_authors", ldb.Migration{
+				Up: func(tx ldb.DatabaseTransaction) error {
+					authors := *testAuthors.Clone()
+					if err := tx.SaveCollection(authors); err != nil {
+						return err
+					}
+
+					authors.Forward()
+					authors.Schema.Fields[1].Name = "full_name"
+					if err := tx.SaveCollection(authors); err != nil {
+						return err
+					}
+
+					return tx.SaveView(ldb.View{Name: "author_names", Schema: ldb.ViewSchema{Query: `SELECT full_name FROM authors`}})
+				},
+			})
+
+			statements, err := app.DryRun()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, expected := range []string{`CREATE TABLE "authors"`, `RENAME COLUMN "name" TO "full_name"`, `VIEW "author_names"`} {
+				if !slices.ContainsFunc(statements, func(statement string) bool { return strings.Contains(statement, expected) }) {
+					t.Fatalf("expected statement containing %q, got %v", expected, statements)
+				}
+			}
+
+			// nothing has been persisted
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if exists, err := tx.MigrationExists("0001_authors"); err != nil {
+				t.Fatal(err)
+			} else if exists {
+				t.Fatal("expected dry run not to persist the migration history")
+			}
+		})
+	}
+}
+
+func TestNativeEnum(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	collection := ldb.Collection{
+		Name: "tickets",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "state", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeEnum{EnumValues: []string{"open", "closed"}, Storage: ldb.EnumStorageNative}}},
+			},
+		},
+	}
+
+	setValues := func(values ...string) {
+		ft := collection.Schema.Fields[1].Schema.Type.(ldb.FieldTypeEnum)
+		ft.EnumValues = values
+		collection.Schema.Fields[1].Schema.Type = ft
+	}
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.SaveCollection(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	collection.Forward()
+
+	if _, err := tx.CreateRecord("tickets", fieldTypes(collection), map[string]any{"id": testId0, "state": "open"}); err != nil {
+		t.Fatal(err)
+	}
+
+	setValues("open", "closed", "archived")
+	if err := tx.SaveCollection(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	collection.Forward()
+
+	if _, err := tx.CreateRecord("tickets", fieldTypes(collection), map[string]any{"id": testId1, "state": "archived"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// values in use cannot be removed
+	setValues("closed", "archived")
+	if err := tx.SaveCollection(collection); err == nil || !strings.Contains(err.Error(), "[open], still in use") {
+		t.Fatalf("expected error for removed enum value in use, got %v", err)
+	}
+
+	setValues("open", "archived")
+	if err := tx.SaveCollection(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	collection.Forward()
+
+	record, err := tx.GetRecord("tickets", fieldTypes(collection), testId1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record["state"] != "archived" {
+		t.Fatalf("unexpected record %v", record)
+	}
+}
+
+func TestDatabaseDefaults(t *testing.T) {
+	open := map[string]func(path string) (ldb.DatabaseAdapter, error){
+		"duckdb":  func(path string) (ldb.DatabaseAdapter, error) { return ldb.OpenDuckDBAdapter(path) },
+		"sqlite3": func(path string) (ldb.DatabaseAdapter, error) { return ldb.OpenSQLiteAdapter(path) },
+	}
+
+	for driver, open := range open {
+		t.Run(driver, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.db")
+
+			adapter, err := open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			collection := ldb.Collection{
+				Name: "pages",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "views", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{CreateDefaultValue: func() int64 { return 5 }}, DatabaseDefault: true}},
+						{Name: "title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{CreateDefaultValue: func() string { return "it's new" }}, DatabaseDefault: true}},
+						{Name: "visible", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeBool{CreateDefaultValue: func() bool { return true }}, DatabaseDefault: true}},
+					},
+				},
+			}
+
+			migrate := func() {
+				tx, err := adapter.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := tx.SaveCollection(collection); err != nil {
+					tx.Rollback()
+					t.Fatal(err)
+				}
+
+				if err := tx.Commit(); err != nil {
+					t.Fatal(err)
+				}
+
+				collection.Forward()
+			}
+
+			migrate()
+
+			collection.Schema.Fields[1].Schema.Type = ldb.FieldTypeInt{CreateDefaultValue: func() int64 { return 7 }}
+			migrate()
+
+			if err := adapter.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			db, err := sql.Open(driver, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			if _, err := db.Exec(`INSERT INTO pages (id) VALUES (?)`, testId0); err != nil {
+				t.Fatal(err)
+			}
+
+			var title string
+			var count int64
+			var visible bool
+			if err := db.QueryRow(`SELECT views, title, visible FROM pages`).Scan(&count, &title, &visible); err != nil {
+				t.Fatal(err)
+			}
+
+			if count != 7 || title != "it's new" || !visible {
+				t.Fatalf("unexpected defaults %v, %q, %v", count, title, visible)
+			}
+		})
+	}
+}
+
+func TestSavepoint(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			err := tx.Savepoint("before_john")
+			if name == "duckdb" {
+				if !errors.Is(err, ldb.ErrUnsupported) {
+					t.Fatalf("expected ErrUnsupported, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId1, "name": "John"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.RollbackTo("before_john"); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.GetRecord("authors", fieldTypes(testAuthors), testId1); !errors.Is(err, ldb.ErrRecordNotFound) {
+				t.Fatalf("expected rolled back record to be gone, got %v", err)
+			}
+
+			if _, err := tx.GetRecord("authors", fieldTypes(testAuthors), testId0); err != nil {
+				t.Fatalf("expected record created before the savepoint, got %v", err)
+			}
+
+			if err := tx.RollbackTo("unknown"); err == nil {
+				t.Fatal("expected error for unknown savepoint")
+			}
+		})
+	}
+}
+
+func TestPing(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			if err := adapter.Ping(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := adapter.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := adapter.Ping(context.Background()); err == nil {
+				t.Fatal("expected ping on closed adapter to fail")
+			}
+		})
+	}
+}
+
+func TestDatabaseChecks(t *testing.T) {
+	open := map[string]func(path string) (ldb.DatabaseAdapter, error){
+		"duckdb":  func(path string) (ldb.DatabaseAdapter, error) { return ldb.OpenDuckDBAdapter(path) },
+		"sqlite3": func(path string) (ldb.DatabaseAdapter, error) { return ldb.OpenSQLiteAdapter(path) },
+	}
+
+	for driver, open := range open {
+		t.Run(driver, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.db")
+
+			adapter, err := open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			collection := ldb.Collection{
+				Name: "people",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "age", Schema: &ldb.FieldSchema{DatabaseCheck: true, Type: ldb.FieldTypeInt{
+							CreateMinValue: func() int64 { return 0 },
+							CreateMaxValue: func() int64 { return 120 },
+						}}},
+						{Name: "name", Schema: &ldb.FieldSchema{DatabaseCheck: true, Type: ldb.FieldTypeText{
+							CreateMinLength: func() int { return 1 },
+							CreateMaxLength: func() int { return 4 },
+						}}},
+						{Name: "score", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeFloat{CreateMaxValue: func() float64 { return 1.5 }}}},
+					},
+				},
+			}
+
+			dryRun, err := adapter.BeginDryRun()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := dryRun.SaveCollection(collection); err != nil {
+				t.Fatal(err)
+			}
+
+			ddl := strings.Join(dryRun.Statements(), "\n")
+			dryRun.Rollback()
+
+			if !strings.Contains(ddl, `CHECK ("age" >= 0 AND "age" <= 120)`) || !strings.Contains(ddl, "BETWEEN 1 AND 4)") || strings.Count(ddl, "CHECK") != 2 {
+				t.Fatalf("unexpected DDL %s", ddl)
+			}
+
+			migrate := func() {
+				tx, err := adapter.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := tx.SaveCollection(collection); err != nil {
+					tx.Rollback()
+					t.Fatal(err)
+				}
+
+				if _, err := tx.CreateRecord("people", collection.Fields(), map[string]any{"age": 30, "name": "Jo", "score": 1.0}); err != nil {
+					tx.Rollback()
+					t.Fatal(err)
+				}
+
+				if err := tx.Commit(); err != nil {
+					t.Fatal(err)
+				}
+
+				collection.Forward()
+			}
+
+			migrate()
+
+			// changed and added checks are applied to existing tables
+			collection.Schema.Fields[1].Schema.Type = ldb.FieldTypeInt{
+				CreateMinValue: func() int64 { return 0 },
+				CreateMaxValue: func() int64 { return 100 },
+			}
+			collection.Schema.Fields = append(collection.Schema.Fields, &ldb.Field{
+				Name:   "level",
+				Schema: &ldb.FieldSchema{DatabaseCheck: true, Type: ldb.FieldTypeInt{Nullable: true, CreateMaxValue: func() int64 { return 3 }}},
+			})
+			migrate()
+
+			if err := adapter.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			db, err := sql.Open(driver, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			var count int64
+			if err := db.QueryRow(`SELECT COUNT(*) FROM people`).Scan(&count); err != nil || count != 2 {
+				t.Fatalf("expected records to be kept, got %v (%v)", count, err)
+			}
+
+			insert := `INSERT INTO people (id, age, name, score, level) VALUES (?, ?, ?, ?, ?)`
+			if _, err := db.Exec(insert, testId0, 100, "abcd", 2.0, 3); err != nil {
+				t.Fatal(err)
+			}
+
+			// lengths are checked in bytes like ValidateValue does
+			for _, args := range [][]any{{101, "a", nil}, {-1, "a", nil}, {1, "", nil}, {1, "abcé", nil}, {1, "a", 4}} {
+				if _, err := db.Exec(insert, testId1, args[0], args[1], 0.0, args[2]); err == nil {
+					t.Fatalf("expected check violation for %v", args)
+				}
+			}
+		})
+	}
+}
+
+func TestNotNullBackfill(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			collection := ldb.Collection{
+				Name: "notes",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{Nullable: true}}},
+						// indexed columns require a table rebuild in DuckDB
+						{Name: "label", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{Nullable: true}, Unique: true}},
+					},
+				},
+			}
+
+			migrate := func(backfill bool) error {
+				tx, err := adapter.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if backfill {
+					tx = ldb.WithNullBackfill(tx)
+				}
+
+				if err := tx.SaveCollection(collection); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if err := tx.Commit(); err != nil {
+					t.Fatal(err)
+				}
+
+				collection.Forward()
+				return nil
+			}
+
+			if err := migrate(false); err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows := []map[string]any{{"id": testId0}, {"id": testId1, "title": "a", "label": "x"}}
+			if _, err := tx.CreateRecords("notes", fieldTypes(collection), rows); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			collection.Schema.Fields[1].Schema.Type = ldb.FieldTypeText{}
+			collection.Schema.Fields[2].Schema.Type = ldb.FieldTypeText{}
+
+			if err := migrate(false); err == nil || !strings.Contains(err.Error(), "NOT NULL") {
+				t.Fatalf("expected NOT NULL conflict, got %v", err)
+			}
+
+			if err := migrate(true); err == nil || !strings.Contains(err.Error(), "default value") {
+				t.Fatalf("expected missing default value error, got %v", err)
+			}
+
+			collection.Schema.Fields[1].Schema.Type = ldb.FieldTypeText{CreateDefaultValue: func() string { return "untitled" }}
+			collection.Schema.Fields[2].Schema.Type = ldb.FieldTypeText{CreateDefaultValue: func() string { return "none" }}
+
+			if err := migrate(true); err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err = adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			record, err := tx.GetRecord("notes", fieldTypes(collection), testId0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["title"] != "untitled" || record["label"] != "none" {
+				t.Fatalf("expected backfilled record, got %v", record)
+			}
+
+			// the constraint is enforced by the database
+			if _, err := tx.Query("notes", fieldTypes(collection), `INSERT INTO notes (id, title, label) VALUES (?, NULL, 'y')`, testId2); err == nil {
+				t.Fatal("expected NOT NULL violation")
+			}
+		})
+	}
+}
+
+// field type unknown to the adapters
+type unsupportedFieldType struct {
+	ldb.FieldTypeText
+}
+
+func TestSaveCollectionUnsupportedFieldType(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			collection := ldb.Collection{
+				Name: "notes",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "body", Schema: &ldb.FieldSchema{Type: unsupportedFieldType{}}},
+					},
+				},
+			}
+
+			if err := tx.SaveCollection(collection); err == nil || !strings.Contains(err.Error(), "unsupported field type") {
+				t.Fatalf("expected unsupported field type error, got %v", err)
+			}
+
+			// adding a column of the type fails as well
+			collection.Schema.Fields = collection.Schema.Fields[:1]
+			if err := tx.SaveCollection(collection); err != nil {
+				t.Fatal(err)
+			}
+
+			collection.Forward()
+			collection.Schema.Fields = append(collection.Schema.Fields, &ldb.Field{Name: "body", Schema: &ldb.FieldSchema{Type: unsupportedFieldType{}}})
+
+			if err := tx.SaveCollection(collection); err == nil || !strings.Contains(err.Error(), "unsupported field type") {
+				t.Fatalf("expected unsupported field type error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCompositePrimaryKey(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			collection := ldb.Collection{
+				Name: "memberships",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						// the per-column primary key is replaced by the composite one
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "user", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+						{Name: "team", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+						{Name: "role", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{Nullable: true}}},
+					},
+					PrimaryKey: []string{"user", "role"},
+				},
+			}
+
+			if err := tx.SaveCollection(collection); err == nil || !strings.Contains(err.Error(), "nullable field") {
+				t.Fatalf("expected nullable field error, got %v", err)
+			}
+
+			collection.Schema.PrimaryKey = []string{"user", "unknown"}
+			if err := tx.SaveCollection(collection); err == nil || !strings.Contains(err.Error(), "unknown field") {
+				t.Fatalf("expected unknown field error, got %v", err)
+			}
+
+			collection.Schema.PrimaryKey = []string{"user", "team"}
+			if err := tx.SaveCollection(collection); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(collection)
+			rows := []map[string]any{
+				{"id": testId0, "user": "alice", "team": "red"},
+				{"id": testId0, "user": "alice", "team": "blue"},
+				{"id": testId1, "user": "bob", "team": "red"},
+			}
+
+			if _, err := tx.CreateRecords("memberships", fields, rows); err != nil {
+				t.Fatal(err)
+			}
+
+			if count, err := tx.CountRecords("memberships", fields, ldb.ListOptions{}); err != nil || count != 3 {
+				t.Fatalf("expected 3 records, got %d (%v)", count, err)
+			}
+
+			// the composite primary key identifies records to upsert
+			if _, err := collection.UpsertRecord(tx, []string{"team", "user"}, map[string]any{"id": testId2, "user": "carol", "team": "red", "role": "owner"}); err != nil {
+				t.Fatal(err)
+			}
+
+			id, err := collection.UpsertRecord(tx, []string{"user", "team"}, map[string]any{"user": "carol", "team": "red", "role": "member"})
+			if err != nil || id != testId2 {
+				t.Fatalf("expected id %q of the existing record, got %q (%v)", testId2, id, err)
+			}
+
+			if record, err := tx.GetRecord("memberships", fields, testId2); err != nil || record["role"] != "member" {
+				t.Fatalf("expected updated membership, got %v (%v)", record, err)
+			}
+
+			// DuckDB aborts the transaction on constraint errors
+			if _, err := tx.CreateRecord("memberships", fields, map[string]any{"id": testId2, "user": "bob", "team": "red"}); err == nil {
+				t.Fatal("expected primary key violation")
+			}
+		})
+	}
+}
+
+func TestPrimaryKeyConfiguration(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			for _, fieldType := range []ldb.FieldType{
+				ldb.FieldTypeId{PrimaryKey: true, Nullable: true},
+				ldb.FieldTypeUUID{PrimaryKey: true, Nullable: true},
+			} {
+				collection := ldb.Collection{
+					Name: "things",
+					Schema: &ldb.CollectionSchema{
+						Fields: []*ldb.Field{{Name: "id", Schema: &ldb.FieldSchema{Type: fieldType}}},
+					},
+				}
+
+				if err := tx.SaveCollection(collection); err == nil || !strings.Contains(err.Error(), "primary key cannot be nullable") {
+					t.Fatalf("expected nullable primary key error for %s, got %v", fieldType, err)
+				}
+			}
+
+			profiles := ldb.Collection{
+				Name: "profiles",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "author", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Collection: "authors"}}},
+						{Name: "bio", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+					},
+					PrimaryKey: []string{"author"},
+				},
+			}
+
+			if err := tx.SaveCollection(profiles); err == nil || !strings.Contains(err.Error(), "requires RelationPrimaryKey") {
+				t.Fatalf("expected relation primary key error, got %v", err)
+			}
+
+			profiles.Schema.RelationPrimaryKey = true
+			if err := tx.SaveCollection(profiles); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestComputedField(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			collection := ldb.Collection{
+				Name: "people",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "first", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+						{Name: "last", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+					},
+				},
+			}
+
+			if err := tx.SaveCollection(collection); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("people", fieldTypes(collection), map[string]any{"id": testId0, "first": "Jane", "last": "Doe"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// computed fields are added to existing tables
+			collection.Forward()
+			collection.Schema.Fields = append(collection.Schema.Fields, &ldb.Field{Name: "full_name", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeComputed{
+				Expression: `"first" || ' ' || "last"`,
+				Base:       ldb.FieldTypeText{},
+			}}})
+
+			if err := tx.SaveCollection(collection); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(collection)
+			if _, err := tx.CreateRecord("people", fields, map[string]any{"id": testId1, "first": "John", "last": "Roe"}); err != nil {
+				t.Fatal(err)
+			}
+
+			records, _, err := tx.ListRecords("people", fields, ldb.ListOptions{
+				Filters: []ldb.Filter{{Field: "full_name", Operator: ldb.FilterEqual, Value: "Jane Doe"}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != 1 || records[0]["id"] != testId0 {
+				t.Fatalf("expected Jane Doe, got %v", records)
+			}
+
+			// changing the expression recomputes the values
+			collection.Forward()
+			collection.Schema.Fields[3].Schema.Type = ldb.FieldTypeComputed{Expression: `"last" || ', ' || "first"`, Base: ldb.FieldTypeText{}}
+			if err := tx.SaveCollection(collection); err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := tx.GetRecord("people", fields, testId1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["full_name"] != "Roe, John" {
+				t.Fatalf("expected Roe, John, got %v", record["full_name"])
+			}
+
+			if err := tx.UpdateRecord("people", fields, testId1, map[string]any{"full_name": "John"}); err == nil {
+				t.Fatal("expected computed field to be read-only")
+			}
+
+			// removing the computed field keeps the other columns
+			collection.Forward()
+			collection.Schema.Fields = collection.Schema.Fields[:3]
+			if err := tx.SaveCollection(collection); err != nil {
+				t.Fatal(err)
+			}
+
+			if record, err := tx.GetRecord("people", fieldTypes(collection), testId1); err != nil || record["last"] != "Roe" {
+				t.Fatalf("expected John Roe, got %v (%v)", record, err)
+			}
+		})
+	}
+}
+
+func TestColumnPosition(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			notes := ldb.Collection{
+				Name: "notes",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+						{Name: "body", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{Nullable: true}}},
+					},
+				},
+			}
+
+			if err := tx.SaveCollection(notes); err != nil {
+				t.Fatal(err)
+			}
+
+			notes.Forward()
+			subtitle := &ldb.Field{Name: "subtitle", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{Nullable: true}, After: "headline"}}
+			notes.Schema.Fields = slices.Insert(notes.Schema.Fields, 2, subtitle)
+
+			if err := tx.SaveCollection(notes); err == nil || !strings.Contains(err.Error(), `unknown column "headline"`) {
+				t.Fatalf("expected unknown column error, got %v", err)
+			}
+
+			// columns are appended where positions are not supported
+			subtitle.Schema.After = "title"
+			if err := tx.SaveCollection(notes); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := notes.Fields()
+			id, err := tx.CreateRecord("notes", fields, map[string]any{"title": "a", "subtitle": "b"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := tx.GetRecord("notes", fields, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["subtitle"] != "b" {
+				t.Fatalf("expected subtitle b, got %v", record["subtitle"])
+			}
+		})
+	}
+}
+
+func TestUniqueConstraints(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			visits := ldb.Collection{
+				Name: "visits",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "user", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+						{Name: "day", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+						{Name: "note", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{Nullable: true}}},
+					},
+				},
+			}
+
+			invalid := map[string][][]string{
+				"unknown field":        {{"user", "date"}},
+				"duplicate field":      {{"user", "day", "user"}},
+				"duplicate constraint": {{"user", "day"}, {"user", "day"}},
+				"no columns":           {{}},
+			}
+
+			for expected, constraints := range invalid {
+				visits.Schema.UniqueConstraints = constraints
+				if err := tx.SaveCollection(visits); err == nil || !strings.Contains(err.Error(), expected) {
+					t.Fatalf("expected %s error, got %v", expected, err)
+				}
+			}
+
+			visits.Schema.UniqueConstraints = [][]string{{"user", "day"}}
+			if err := tx.SaveCollection(visits); err != nil {
+				t.Fatal(err)
+			}
+
+			create := func(user, day, note string) error {
+				_, err := tx.CreateRecord("visits", visits.Fields(), map[string]any{"user": user, "day": day, "note": note})
+				return err
+			}
+
+			if err := create("alice", "mon", "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			// constraint columns identify records to upsert in any order
+			for _, note := range []string{"z", "a"} {
+				if _, err := visits.UpsertRecord(tx, []string{"day", "user"}, map[string]any{"user": "alice", "day": "mon", "note": note}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			records, total, err := tx.ListRecords("visits", visits.Fields(), ldb.ListOptions{})
+			if err != nil || total != 1 || records[0]["note"] != "a" {
+				t.Fatalf("expected a single upserted visit, got %v (%v)", records, err)
+			}
+
+			// constraints follow renamed columns without rebuilding the table
+			visits.Forward()
+			visits.Schema.Fields[2].Name = "date"
+			visits.Schema.UniqueConstraints = [][]string{{"user", "date"}}
+			if err := tx.SaveCollection(visits); err != nil {
+				t.Fatal(err)
+			}
+
+			create = func(user, date, note string) error {
+				_, err := tx.CreateRecord("visits", visits.Fields(), map[string]any{"user": user, "date": date, "note": note})
+				return err
+			}
+
+			if err := create("alice", "tue", "b"); err != nil {
+				t.Fatal(err)
+			}
+
+			// removed constraints are dropped
+			visits.Forward()
+			visits.Schema.UniqueConstraints = nil
+			if err := tx.SaveCollection(visits); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := create("alice", "mon", "c"); err != nil {
+				t.Fatalf("expected dropped constraint to allow duplicates, got %v", err)
+			}
+
+			// added constraints are enforced for existing and new rows
+			visits.Forward()
+			visits.Schema.UniqueConstraints = [][]string{{"user", "date", "note"}}
+			if err := tx.SaveCollection(visits); err != nil {
+				t.Fatal(err)
+			}
+
+			if count, err := tx.CountRecords("visits", visits.Fields(), ldb.ListOptions{}); err != nil || count != 3 {
+				t.Fatalf("expected 3 records, got %d (%v)", count, err)
+			}
+
+			if err := create("alice", "mon", "a"); err == nil {
+				t.Fatal("expected unique constraint violation")
+			}
+		})
+	}
+}
+
+func TestSerialField(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			orders := ldb.Collection{
+				Name: "orders",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSerial{PrimaryKey: true}}},
+						{Name: "label", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+					},
+				},
+			}
+
+			if err := tx.SaveCollection(orders); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := orders.Fields()
+			for _, expected := range []string{"1", "2"} {
+				if id, err := tx.CreateRecord("orders", fields, map[string]any{"label": "a"}); err != nil || id != expected {
+					t.Fatalf("expected id %s, got %q (%v)", expected, id, err)
+				}
+			}
+
+			ids, err := tx.CreateRecords("orders", fields, []map[string]any{{"label": "b"}, {"label": "d"}, {"id": 10, "label": "c"}})
+			if err != nil || !slices.Equal(ids, []string{"3", "4", "10"}) {
+				t.Fatalf("expected ids 3, 4 and 10, got %v (%v)", ids, err)
+			}
+
+			record, err := tx.GetRecord("orders", fields, "10")
+			if err != nil || record["id"] != int64(10) || record["label"] != "c" {
+				t.Fatalf("unexpected record %v (%v)", record, err)
+			}
+
+			if err := tx.UpdateRecord("orders", fields, "4", map[string]any{"label": "e"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.GetRecord("orders", fields, "four"); err == nil || !strings.Contains(err.Error(), "cannot parse") {
+				t.Fatalf("expected parse error, got %v", err)
+			}
+
+			// the sequence of renamed tables continues after existing values
+			orders.Forward()
+			orders.Name = "purchases"
+			if err := tx.SaveCollection(orders); err != nil {
+				t.Fatal(err)
+			}
+
+			if id, err := tx.CreateRecord("purchases", fields, map[string]any{"label": "f"}); err != nil || id != "11" {
+				t.Fatalf("expected id 11, got %q (%v)", id, err)
+			}
+
+			// sequences are dropped along with their table
+			orders.Forward()
+			if err := tx.DropCollection(orders); err != nil {
+				t.Fatal(err)
+			}
+
+			purchases := *orders.Clone()
+			if err := tx.SaveCollection(purchases); err != nil {
+				t.Fatal(err)
+			}
+
+			if id, err := tx.CreateRecord("purchases", fields, map[string]any{"label": "a"}); err != nil || id != "1" {
+				t.Fatalf("expected id 1, got %q (%v)", id, err)
+			}
+
+			// SQLite only assigns values to INTEGER PRIMARY KEY columns
+			purchases.Forward()
+			purchases.Schema.Fields = append(purchases.Schema.Fields, &ldb.Field{Name: "number", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSerial{}}})
+
+			err = tx.SaveCollection(purchases)
+			if name == "sqlite" {
+				if err == nil || !strings.Contains(err.Error(), "unsupported field type") {
+					t.Fatalf("expected unsupported field type error, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// existing rows are numbered by the rebuild
+			record, err = tx.GetRecord("purchases", purchases.Fields(), "1")
+			if err != nil || record["number"] != int64(1) {
+				t.Fatalf("expected number 1, got %v (%v)", record, err)
+			}
+		})
+	}
+}
@@ -1 +1,182 @@
 package ldb
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// name of the table holding the migration history
+const migrationTableName = "_ldb_migrations"
+
+type MigrationDirection string
+
+const (
+	MigrationUp   MigrationDirection = "up"
+	MigrationDown MigrationDirection = "down"
+)
+
+// entry of the migration history; each application and each revert of a
+// migration appends an entry
+type MigrationHistoryEntry struct {
+	// increases by one with each entry, starting at 1; the version of the last
+	// entry is the current schema version
+	Version   int
+	Name      string
+	Direction MigrationDirection
+	AppliedAt time.Time
+	// checksum of the schema changes of applied migrations; empty for reverts
+	// and for migrations applied before checksums were stored
+	Checksum string
+}
+
+// applies all pending migrations within a single transaction;
+// migrations are applied in lexical order of their names
+func (app *App) migrate() error {
+	tx, err := app.beginMigration()
+	if err != nil {
+		return err
+	}
+
+	if err := app.applyMigrations(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// begins the transaction migrations are applied or reverted in, taking the
+// migration timeout into account
+func (app *App) beginMigration() (DatabaseTransaction, error) {
+	if app.MigrationTimeout > 0 {
+		return BeginWithTimeout(app.DatabaseAdapter, app.MigrationTimeout)
+	}
+
+	return app.DatabaseAdapter.Begin()
+}
+
+// applies all pending migrations within a dry run transaction and returns the
+// executed statements; nothing is persisted
+func (app *App) dryRun() ([]string, error) {
+	tx, err := app.DatabaseAdapter.BeginDryRun()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := app.applyMigrations(tx); err != nil {
+		return nil, err
+	}
+
+	return tx.Statements(), nil
+}
+
+// applies all pending migrations using the given transaction
+func (app *App) applyMigrations(tx DatabaseTransaction) error {
+	names := lo.Keys(app.Migrations)
+	slices.Sort(names)
+
+	for _, name := range names {
+		exists, err := tx.MigrationExists(name)
+		if err != nil {
+			return err
+		}
+
+		// applied migrations are verified against their stored checksum;
+		// migrations whose up function fails without its writes cannot be
+		// verified
+		if exists {
+			checksum, err := migrationChecksum(tx, app.Migrations[name], false)
+			if err != nil {
+				continue
+			}
+
+			if err := tx.VerifyMigration(name, checksum); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		checksum, err := migrationChecksum(migrationTransaction(tx, app.Migrations[name]), app.Migrations[name], true)
+		if err != nil {
+			return fmt.Errorf("migration %s failed: %w", name, err)
+		}
+
+		if err := tx.FinishMigration(name, checksum); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reverts the last n applied migrations within a single transaction
+func (app *App) rollback(n int) error {
+	tx, err := app.beginMigration()
+	if err != nil {
+		return err
+	}
+
+	names, err := tx.AppliedMigrations()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	names = lo.Reverse(names)
+	if n < len(names) {
+		names = names[:max(n, 0)]
+	}
+
+	for _, name := range names {
+		migration, ok := app.Migrations[name]
+		if !ok || migration.Down == nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %s cannot be reverted, no down function defined", name)
+		}
+
+		if err := migration.Down(migrationTransaction(tx, migration)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("reverting migration %s failed: %w", name, err)
+		}
+
+		if err := tx.RevertMigration(name); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// returns the transaction the functions of the given migration run on
+func migrationTransaction(tx DatabaseTransaction, migration *Migration) DatabaseTransaction {
+	if migration.BackfillNulls {
+		return WithNullBackfill(tx)
+	}
+
+	return tx
+}
+
+// forwards all calls to the underlying transaction and saves collections with
+// null backfill
+type backfillTransaction struct {
+	DatabaseTransaction
+}
+
+// WithNullBackfill returns a transaction saving collections such that null
+// values of fields becoming non-nullable are replaced by the default value of
+// the field, i.e. the value validating nil results in. Without backfill, such
+// saves fail if null values exist.
+func WithNullBackfill(tx DatabaseTransaction) DatabaseTransaction {
+	return backfillTransaction{tx}
+}
+
+func (t backfillTransaction) SaveCollection(collection Collection) error {
+	collection.backfillNulls = true
+	return t.DatabaseTransaction.SaveCollection(collection)
+}
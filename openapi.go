@@ -0,0 +1,403 @@
+package ldb
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"time"
+)
+
+// OpenAPI 3.0 generation from collection schemas; constraints given by
+// functions such as CreateMaxLength are evaluated once during generation
+
+// GenerateOpenAPI returns an OpenAPI 3.0 document describing the given
+// collections as component schemas and the routes served by NewHttpService.
+func GenerateOpenAPI(collections []Collection) ([]byte, error) {
+	schemas := map[string]any{
+		"Error": map[string]any{
+			"type":     "object",
+			"required": []string{"error"},
+			"properties": map[string]any{
+				"error":  map[string]any{"type": "string"},
+				"fields": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+			},
+		},
+	}
+	paths := map[string]any{}
+
+	for _, collection := range collections {
+		schema, err := collectionJSONSchema(collection)
+		if err != nil {
+			return nil, fmt.Errorf("collection %q: %w", collection.Name, err)
+		}
+
+		schemas[collection.Name] = schema
+
+		// updates are partial
+		update := map[string]any{}
+		for key, value := range schema {
+			if key != "required" {
+				update[key] = value
+			}
+		}
+
+		schemas[collection.Name+"_update"] = update
+
+		for path, item := range collectionPaths(collection) {
+			paths[path] = item
+		}
+	}
+
+	return json.Marshal(map[string]any{
+		"openapi":    "3.0.3",
+		"info":       map[string]any{"title": "ldb", "version": "1.0.0"},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	})
+}
+
+// returns the JSON schema of the records of the given collection; fields that
+// are neither nullable nor have a default value are required
+func collectionJSONSchema(collection Collection) (map[string]any, error) {
+	if err := validateFieldTypes(collection); err != nil {
+		return nil, err
+	}
+
+	properties := map[string]any{}
+	required := []string{}
+
+	for _, field := range collection.Schema.Fields {
+		schema, err := fieldJSONSchema(field.Schema.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+
+		// descriptions of fields take precedence over those of their types
+		if field.Schema.Description != "" {
+			schema["description"] = field.Schema.Description
+		}
+
+		if len(field.Schema.Meta) > 0 {
+			schema["x-meta"] = maps.Clone(field.Schema.Meta)
+		}
+
+		properties[field.Name] = schema
+
+		if requiredField(field.Schema.Type) {
+			required = append(required, field.Name)
+		}
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema, nil
+}
+
+// maps a field type to its JSON schema using the representation of its values
+// in JSON request and response bodies
+func fieldJSONSchema(fieldType FieldType) (map[string]any, error) {
+	schema := map[string]any{}
+	nullable := false
+
+	switch ft := fieldType.(type) {
+	case FieldTypeId:
+		schema["type"] = "string"
+		schema["pattern"] = ft.IdConfig.orDefault().pattern()
+		nullable = ft.Nullable && !ft.PrimaryKey
+
+	case FieldTypeText:
+		schema["type"] = "string"
+		nullable = ft.Nullable
+
+		if ft.CreateMinLength != nil {
+			schema["minLength"] = ft.CreateMinLength()
+		}
+
+		if ft.CreateMaxLength != nil {
+			schema["maxLength"] = ft.CreateMaxLength()
+		}
+
+		if ft.CreatePattern != nil {
+			pattern := ft.CreatePattern()
+			if _, err := compilePattern(pattern); err != nil {
+				return nil, fmt.Errorf("configuration error, invalid pattern")
+			}
+
+			schema["pattern"] = pattern
+		}
+
+	case FieldTypeEmail:
+		schema["type"] = "string"
+		schema["format"] = "email"
+		nullable = ft.Nullable
+
+	case FieldTypeURL:
+		schema["type"] = "string"
+		schema["format"] = "uri"
+		nullable = ft.Nullable
+
+		if ft.CreateAllowedSchemes != nil {
+			schema["x-schemes"] = ft.CreateAllowedSchemes()
+		}
+
+	case FieldTypeDuration:
+		schema["type"] = "integer"
+		schema["format"] = "int64"
+		schema["description"] = "duration in nanoseconds"
+		nullable = ft.Nullable
+
+		if ft.CreateMinValue != nil {
+			schema["minimum"] = int64(ft.CreateMinValue())
+		}
+
+		if ft.CreateMaxValue != nil {
+			schema["maximum"] = int64(ft.CreateMaxValue())
+		}
+
+	case FieldTypeArray:
+		items, err := fieldJSONSchema(ft.Element)
+		if err != nil {
+			return nil, err
+		}
+
+		schema["type"] = "array"
+		schema["items"] = items
+		nullable = ft.Nullable
+
+	case FieldTypePoint:
+		schema["type"] = "object"
+		schema["properties"] = map[string]any{
+			"lat": map[string]any{"type": "number", "minimum": -90, "maximum": 90},
+			"lng": map[string]any{"type": "number", "minimum": -180, "maximum": 180},
+		}
+		schema["required"] = []string{"lat", "lng"}
+		nullable = ft.Nullable
+
+		if ft.SRID != 0 {
+			schema["x-srid"] = ft.SRID
+		}
+
+	case FieldTypeIP:
+		schema["type"] = "string"
+		nullable = ft.Nullable
+
+		if ft.AllowCIDR {
+			schema["description"] = "IP address or CIDR range"
+		} else {
+			schema["description"] = "IP address"
+		}
+
+	case FieldTypeInt:
+		schema["type"] = "integer"
+		schema["format"] = "int64"
+		nullable = ft.Nullable
+
+		if ft.CreateMinValue != nil {
+			schema["minimum"] = ft.CreateMinValue()
+		}
+
+		if ft.CreateMaxValue != nil {
+			schema["maximum"] = ft.CreateMaxValue()
+		}
+
+	case FieldTypeSerial:
+		schema["type"] = "integer"
+		schema["format"] = "int64"
+		schema["description"] = "assigned by the database if omitted"
+
+	case FieldTypeFloat:
+		schema["type"] = "number"
+		schema["format"] = "double"
+		nullable = ft.Nullable
+
+		if ft.CreateMinValue != nil {
+			if minValue := ft.CreateMinValue(); !math.IsInf(minValue, 0) && !math.IsNaN(minValue) {
+				schema["minimum"] = minValue
+			}
+		}
+
+		if ft.CreateMaxValue != nil {
+			if maxValue := ft.CreateMaxValue(); !math.IsInf(maxValue, 0) && !math.IsNaN(maxValue) {
+				schema["maximum"] = maxValue
+			}
+		}
+
+	case FieldTypeBool:
+		schema["type"] = "boolean"
+		nullable = ft.Nullable
+
+	case FieldTypeDateTime:
+		// JSON schema has no bounds for date-time strings
+		schema["type"] = "string"
+		schema["format"] = "date-time"
+		nullable = ft.Nullable
+
+		if ft.CreateMinValue != nil {
+			schema["x-minimum"] = ft.CreateMinValue().Format(time.RFC3339)
+		}
+
+		if ft.CreateMaxValue != nil {
+			schema["x-maximum"] = ft.CreateMaxValue().Format(time.RFC3339)
+		}
+
+	case FieldTypeEnum:
+		values := []any{}
+		for _, value := range ft.EnumValues {
+			values = append(values, value)
+		}
+
+		// OpenAPI requires null to be listed in nullable enums
+		if ft.Nullable {
+			values = append(values, nil)
+		}
+
+		schema["type"] = "string"
+		schema["enum"] = values
+		nullable = ft.Nullable
+
+	case FieldTypeSingleRelation:
+		schema["type"] = "string"
+		schema["description"] = fmt.Sprintf("id of a record of collection %q", ft.Collection)
+		nullable = ft.Nullable
+
+	case FieldTypeJSON:
+		// any JSON value
+		nullable = ft.Nullable
+
+	case FieldTypeUUID:
+		schema["type"] = "string"
+		schema["format"] = "uuid"
+		nullable = ft.Nullable && !ft.PrimaryKey
+
+	case FieldTypeDecimal:
+		if ft.Precision < 1 || ft.Precision > 38 || ft.Scale < 0 || ft.Scale > ft.Precision {
+			return nil, fmt.Errorf("configuration error, invalid precision or scale")
+		}
+
+		schema["type"] = "string"
+		schema["format"] = "decimal"
+		schema["pattern"] = `^-?[0-9]+(\.[0-9]+)?$`
+		nullable = ft.Nullable
+
+	case FieldTypeBytes:
+		schema["type"] = "string"
+		schema["format"] = "byte"
+		nullable = ft.Nullable
+
+		// length of the base64 encoding
+		if ft.CreateMaxLength != nil {
+			schema["maxLength"] = (ft.CreateMaxLength() + 2) / 3 * 4
+		}
+
+	case FieldTypeMultiRelation:
+		schema["type"] = "array"
+		schema["items"] = map[string]any{"type": "string"}
+		schema["uniqueItems"] = true
+		schema["description"] = fmt.Sprintf("ids of records of collection %q", ft.Collection)
+
+	case FieldTypeComputed:
+		base, err := fieldJSONSchema(ft.Base)
+		if err != nil {
+			return nil, err
+		}
+
+		// computed values are only returned in responses
+		schema = base
+		schema["readOnly"] = true
+		nullable = true
+
+	default:
+		return nil, fmt.Errorf("unexpected field type %T", fieldType)
+	}
+
+	if nullable {
+		schema["nullable"] = true
+	}
+
+	return schema, nil
+}
+
+// returns the path items of the routes served by NewHttpService
+func collectionPaths(collection Collection) map[string]any {
+	ref := map[string]any{"$ref": "#/components/schemas/" + collection.Name}
+	errorResponse := map[string]any{
+		"description": "error",
+		"content": map[string]any{
+			"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Error"}},
+		},
+	}
+
+	response := func(description string, schema any) map[string]any {
+		return map[string]any{
+			"description": description,
+			"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+		}
+	}
+
+	body := func(schema any) map[string]any {
+		return map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": schema}},
+		}
+	}
+
+	intParam := func(name string) map[string]any {
+		return map[string]any{"name": name, "in": "query", "schema": map[string]any{"type": "integer", "minimum": 0}}
+	}
+
+	idParam := map[string]any{"name": "id", "in": "path", "required": true, "schema": map[string]any{"type": "string"}}
+
+	return map[string]any{
+		"/api/collections/" + collection.Name + "/records": map[string]any{
+			"get": map[string]any{
+				"operationId": "list_" + collection.Name,
+				"parameters": []any{
+					intParam("limit"),
+					intParam("offset"),
+					map[string]any{"name": "sort", "in": "query", "schema": map[string]any{"type": "string"}},
+				},
+				"responses": map[string]any{
+					"200": response("records", map[string]any{
+						"type":     "object",
+						"required": []string{"records", "total"},
+						"properties": map[string]any{
+							"records": map[string]any{"type": "array", "items": ref},
+							"total":   map[string]any{"type": "integer", "format": "int64"},
+						},
+					}),
+					"default": errorResponse,
+				},
+			},
+			"post": map[string]any{
+				"operationId": "create_" + collection.Name,
+				"requestBody": body(ref),
+				"responses":   map[string]any{"201": response("created record", ref), "default": errorResponse},
+			},
+		},
+		"/api/collections/" + collection.Name + "/records/{id}": map[string]any{
+			"parameters": []any{idParam},
+			"get": map[string]any{
+				"operationId": "get_" + collection.Name,
+				"responses":   map[string]any{"200": response("record", ref), "default": errorResponse},
+			},
+			"patch": map[string]any{
+				"operationId": "update_" + collection.Name,
+				"requestBody": body(map[string]any{"$ref": "#/components/schemas/" + collection.Name + "_update"}),
+				"responses":   map[string]any{"200": response("updated record", ref), "default": errorResponse},
+			},
+			"delete": map[string]any{
+				"operationId": "delete_" + collection.Name,
+				"responses":   map[string]any{"204": map[string]any{"description": "deleted"}, "default": errorResponse},
+			},
+		},
+	}
+}
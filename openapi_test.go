@@ -0,0 +1,97 @@
+package ldb_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestGenerateOpenAPI(t *testing.T) {
+	tags := ldb.Collection{
+		Name: "tags",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeUUID{PrimaryKey: true}}},
+				{Name: "label", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{
+					CreateMaxLength: func() int { return 16 },
+					CreatePattern:   func() string { return "^[a-z]+$" },
+				}}},
+				{Name: "weight", Schema: &ldb.FieldSchema{Description: "higher weights are listed first", Meta: map[string]string{"widget": "slider"}, Type: ldb.FieldTypeInt{
+					Nullable:       true,
+					CreateMinValue: func() int64 { return 1 },
+					CreateMaxValue: func() int64 { return 10 },
+				}}},
+			},
+		},
+	}
+
+	data, err := ldb.GenerateOpenAPI([]ldb.Collection{testAuthors, testPosts, tags})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string                  `json:"required"`
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Fatalf("unexpected openapi version %q", doc.OpenAPI)
+	}
+
+	posts := doc.Components.Schemas["posts"]
+	if !reflect.DeepEqual(posts.Required, []string{"title"}) {
+		t.Fatalf("expected only title to be required, got %v", posts.Required)
+	}
+
+	expected := map[string]any{"type": "string", "enum": []any{"draft", "live", nil}, "nullable": true}
+	if !reflect.DeepEqual(posts.Properties["state"], expected) {
+		t.Fatalf("unexpected enum schema %v", posts.Properties["state"])
+	}
+
+	if posts.Properties["published_at"]["format"] != "date-time" || posts.Properties["views"]["type"] != "integer" {
+		t.Fatalf("unexpected field schemas %v", posts.Properties)
+	}
+
+	expected = map[string]any{"type": "string", "maxLength": 16.0, "pattern": "^[a-z]+$"}
+	if label := doc.Components.Schemas["tags"].Properties["label"]; !reflect.DeepEqual(label, expected) {
+		t.Fatalf("unexpected text schema %v", label)
+	}
+
+	expected = map[string]any{
+		"type": "integer", "format": "int64", "minimum": 1.0, "maximum": 10.0, "nullable": true,
+		"description": "higher weights are listed first", "x-meta": map[string]any{"widget": "slider"},
+	}
+	if weight := doc.Components.Schemas["tags"].Properties["weight"]; !reflect.DeepEqual(weight, expected) {
+		t.Fatalf("unexpected int schema %v", weight)
+	}
+
+	if update := doc.Components.Schemas["posts_update"]; len(update.Required) != 0 {
+		t.Fatalf("expected no required fields for updates, got %v", update.Required)
+	}
+
+	for _, path := range []string{"/api/collections/posts/records", "/api/collections/posts/records/{id}"} {
+		if doc.Paths[path] == nil {
+			t.Fatalf("missing path %q", path)
+		}
+	}
+
+	invalid := *tags.Clone()
+	invalid.Schema.Fields[1].Schema.Type = ldb.FieldTypeText{CreatePattern: func() string { return "(" }}
+	if _, err := ldb.GenerateOpenAPI([]ldb.Collection{invalid}); err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Fatalf("expected invalid pattern error, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package ldb
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+type FilterOperator string
+
+const (
+	FilterEqual          FilterOperator = "="
+	FilterNotEqual       FilterOperator = "!="
+	FilterLess           FilterOperator = "<"
+	FilterLessOrEqual    FilterOperator = "<="
+	FilterGreater        FilterOperator = ">"
+	FilterGreaterOrEqual FilterOperator = ">="
+)
+
+// compares the value of a field to the given value; a nil value combined with
+// FilterEqual or FilterNotEqual checks for (non-)null values
+type Filter struct {
+	Field    string
+	Operator FilterOperator
+	Value    any
+}
+
+type ListOptions struct {
+	// filters are combined using AND
+	Filters []Filter
+	// maximum number of records to return; zero means no limit
+	Limit  int
+	Offset int
+	// name of the field to order by; empty means unordered
+	OrderBy   string
+	OrderDesc bool
+}
+
+// ensure interface implementation
+var _ Clonable[*Filter] = Filter{}
+var _ Clonable[*ListOptions] = ListOptions{}
+
+// copies the filter including slice and map values, so that the copy can be
+// modified without affecting the original
+func (f Filter) Clone() *Filter {
+	cloned := Filter{}
+	cloned.Field = f.Field
+	cloned.Operator = f.Operator
+	cloned.Value = cloneValue(f.Value)
+	return &cloned
+}
+
+// copies the options including all filters, e.g. to specialize a base query
+func (o ListOptions) Clone() *ListOptions {
+	cloned := o
+	cloned.Filters = nil
+	for _, filter := range o.Filters {
+		cloned.Filters = append(cloned.Filters, *filter.Clone())
+	}
+
+	return &cloned
+}
+
+// deep-copies slices and maps of values as used by filters on array, bytes
+// and JSON fields; other values are returned as is
+func cloneValue(value any) any {
+	switch value := value.(type) {
+	case []any:
+		cloned := make([]any, len(value))
+		for i, element := range value {
+			cloned[i] = cloneValue(element)
+		}
+		return cloned
+
+	case map[string]any:
+		cloned := make(map[string]any, len(value))
+		for key, element := range value {
+			cloned[key] = cloneValue(element)
+		}
+		return cloned
+
+	case []byte:
+		return slices.Clone(value)
+
+	case []string:
+		return slices.Clone(value)
+
+	case []int64:
+		return slices.Clone(value)
+
+	case []float64:
+		return slices.Clone(value)
+
+	default:
+		return value
+	}
+}
+
+// builds a parameterized WHERE clause from the given filters;
+// returns an empty clause if there are no filters
+func whereSQL(dialect Dialect, codec valueCodec, fields map[string]FieldType, filters []Filter) (string, []any, error) {
+	if len(filters) == 0 {
+		return "", nil, nil
+	}
+
+	conditions := []string{}
+	args := []any{}
+
+	for _, filter := range filters {
+		fieldType, ok := fields[filter.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("invalid filter, unknown field %q", filter.Field)
+		}
+
+		if isMultiRelation(fieldType) {
+			return "", nil, fmt.Errorf("invalid filter, cannot filter by multi relation field %q", filter.Field)
+		}
+
+		column := dialect.Quote(filter.Field)
+
+		switch filter.Operator {
+		case FilterEqual, FilterNotEqual:
+			if filter.Value == nil {
+				if filter.Operator == FilterEqual {
+					conditions = append(conditions, column+" IS NULL")
+				} else {
+					conditions = append(conditions, column+" IS NOT NULL")
+				}
+
+				continue
+			}
+
+		case FilterLess, FilterLessOrEqual, FilterGreater, FilterGreaterOrEqual:
+			if filter.Value == nil {
+				return "", nil, fmt.Errorf("invalid filter on field %q, expected non-null value", filter.Field)
+			}
+
+		default:
+			return "", nil, fmt.Errorf("invalid filter on field %q, unknown operator %q", filter.Field, filter.Operator)
+		}
+
+		sql, valueArgs := bindSQL(codec.encodeValue(fieldType, filter.Value))
+		if _, ok := filter.Value.(string); ok && isCaseInsensitive(fieldType) {
+			column, sql = "LOWER("+column+")", "LOWER("+sql+")"
+		}
+
+		conditions = append(conditions, fmt.Sprintf("%s %s %s", column, filter.Operator, sql))
+		args = append(args, valueArgs...)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+// text and enum fields may compare values ignoring case
+func isCaseInsensitive(fieldType FieldType) bool {
+	switch ft := fieldType.(type) {
+	case FieldTypeText:
+		return ft.CaseInsensitive
+	case FieldTypeEnum:
+		return ft.CaseInsensitive
+	}
+
+	return false
+}
+
+// builds the ORDER BY, LIMIT and OFFSET clauses
+func pageSQL(dialect Dialect, fields map[string]FieldType, opts ListOptions) (string, error) {
+	sql := ""
+
+	if opts.OrderBy != "" {
+		fieldType, ok := fields[opts.OrderBy]
+		if !ok {
+			return "", fmt.Errorf("invalid order, unknown field %q", opts.OrderBy)
+		}
+
+		if isMultiRelation(fieldType) {
+			return "", fmt.Errorf("invalid order, cannot order by multi relation field %q", opts.OrderBy)
+		}
+
+		sql += " ORDER BY " + dialect.Quote(opts.OrderBy)
+		if opts.OrderDesc {
+			sql += " DESC"
+		}
+	}
+
+	if opts.Limit < 0 || opts.Offset < 0 {
+		return "", fmt.Errorf("invalid pagination, expected non-negative limit and offset")
+	}
+
+	if opts.Limit > 0 {
+		sql += fmt.Sprintf(" LIMIT %d", opts.Limit)
+	}
+
+	if opts.Offset > 0 {
+		// SQLite does not support OFFSET without LIMIT
+		if opts.Limit == 0 {
+			sql += fmt.Sprintf(" LIMIT %d", math.MaxInt64)
+		}
+
+		sql += fmt.Sprintf(" OFFSET %d", opts.Offset)
+	}
+
+	return sql, nil
+}
@@ -0,0 +1,70 @@
+package ldb
+
+import (
+	"errors"
+)
+
+// Read-only transactions reject all writes modeled by DatabaseTransaction
+// before they reach the database, so a rejected write does not abort the
+// transaction. Adapters additionally start the transaction in a read-only mode
+// of the database if there is one, which covers writes issued by Query.
+
+var ErrReadOnly = errors.New("transaction is read-only")
+
+// rejects all writes with ErrReadOnly; forwards reads to the underlying
+// transaction
+type readOnlyTransaction struct {
+	DatabaseTransaction
+}
+
+func (t readOnlyTransaction) SaveCollection(collection Collection) error {
+	return ErrReadOnly
+}
+
+func (t readOnlyTransaction) DropCollection(collection Collection) error {
+	return ErrReadOnly
+}
+
+func (t readOnlyTransaction) SaveView(view View) error {
+	return ErrReadOnly
+}
+
+func (t readOnlyTransaction) DropView(view View) error {
+	return ErrReadOnly
+}
+
+func (t readOnlyTransaction) FinishMigration(migrationName string, checksum string) error {
+	return ErrReadOnly
+}
+
+func (t readOnlyTransaction) RevertMigration(migrationName string) error {
+	return ErrReadOnly
+}
+
+func (t readOnlyTransaction) CreateRecord(collection string, fields map[string]FieldType, data map[string]any) (string, error) {
+	return "", ErrReadOnly
+}
+
+func (t readOnlyTransaction) CreateRecords(collection string, fields map[string]FieldType, rows []map[string]any) ([]string, error) {
+	return nil, ErrReadOnly
+}
+
+func (t readOnlyTransaction) UpsertRecord(collection string, fields map[string]FieldType, conflictColumns []string, data map[string]any) (string, error) {
+	return "", ErrReadOnly
+}
+
+func (t readOnlyTransaction) UpdateRecord(collection string, fields map[string]FieldType, id string, data map[string]any) error {
+	return ErrReadOnly
+}
+
+func (t readOnlyTransaction) UpdateRecordVersion(collection string, fields map[string]FieldType, id string, version int64, data map[string]any) error {
+	return ErrReadOnly
+}
+
+func (t readOnlyTransaction) DeleteRecord(collection string, fields map[string]FieldType, id string) error {
+	return ErrReadOnly
+}
+
+func (t readOnlyTransaction) TruncateCollection(collection string, fields map[string]FieldType, opts TruncateOptions) error {
+	return ErrReadOnly
+}
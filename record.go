@@ -0,0 +1,820 @@
+package ldb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// record operations shared by adapters built on top of database/sql
+
+var ErrRecordNotFound = errors.New("record not found")
+var ErrRecordReferenced = errors.New("record is still referenced")
+
+// converts values between their go representation and the representation
+// stored by a specific database
+type valueCodec interface {
+	// converts a validated value into its database representation
+	encodeValue(fieldType FieldType, value any) any
+	// converts a scanned database value into its go representation
+	decodeValue(fieldType FieldType, value any) (any, error)
+	// returns the select expression reading the given quoted column
+	selectSQL(fieldType FieldType, column string) string
+}
+
+// encoded array for databases unable to bind lists as parameters; bound as
+// list of parameters cast to the element type
+type sqlList struct {
+	elementType string
+	values      []any
+}
+
+// returns the SQL expression binding the given encoded value together with its
+// arguments
+func bindSQL(value any) (string, []any) {
+	list, ok := value.(sqlList)
+	if !ok {
+		return "?", []any{value}
+	}
+
+	if len(list.values) == 0 {
+		return "CAST([] AS " + list.elementType + "[])", nil
+	}
+
+	expressions := []string{}
+	args := []any{}
+	for _, element := range list.values {
+		if nested, ok := element.(sqlList); ok {
+			sql, nestedArgs := bindSQL(nested)
+			expressions = append(expressions, sql)
+			args = append(args, nestedArgs...)
+			continue
+		}
+
+		expressions = append(expressions, "CAST(? AS "+list.elementType+")")
+		args = append(args, element)
+	}
+
+	return "list_value(" + strings.Join(expressions, ", ") + ")", args
+}
+
+// returns the name of the primary key field if there is one
+func primaryKeyField(fields map[string]FieldType) (string, bool) {
+	for name, fieldType := range fields {
+		switch ft := fieldType.(type) {
+		case FieldTypeId:
+			if ft.PrimaryKey {
+				return name, true
+			}
+
+		case FieldTypeUUID:
+			if ft.PrimaryKey {
+				return name, true
+			}
+
+		case FieldTypeSerial:
+			if ft.PrimaryKey {
+				return name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// returns the given primary key value as string; serial keys are formatted
+// in decimal
+func recordIdString(value any) string {
+	if id, ok := value.(string); ok {
+		return id
+	}
+
+	if id, err := toInt64(value); err == nil {
+		return strconv.FormatInt(id, 10)
+	}
+
+	return ""
+}
+
+// generates a new primary key value suitable for the given field type
+func generatePrimaryKey(fieldType FieldType) (string, error) {
+	if _, ok := fieldType.(FieldTypeUUID); ok {
+		return GenerateUUID(), nil
+	}
+
+	if config, ok := fieldIdConfig(fieldType); ok {
+		return config.Generate()
+	}
+
+	return GenerateIdErr()
+}
+
+// validates the given primary key value and returns it in normalized form
+func validatePrimaryKey(fields map[string]FieldType, primaryKey string, id string) (string, error) {
+	value, err := fields[primaryKey].ValidateValue(id)
+	if err != nil {
+		return "", err
+	}
+
+	return recordIdString(value), nil
+}
+
+// returns the field names in a deterministic order
+func sortedFieldNames(fields map[string]FieldType) []string {
+	names := lo.Keys(fields)
+	slices.Sort(names)
+	return names
+}
+
+// ensures that all keys of data refer to known fields
+func validateFieldNames(fields map[string]FieldType, data map[string]any) error {
+	for name := range data {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("unknown field %q", name)
+		}
+	}
+
+	return nil
+}
+
+// record validated for insertion
+type preparedRecord struct {
+	// empty if the primary key is assigned by the database
+	id      string
+	columns []string
+	// SQL expressions binding the values of the columns
+	values    []string
+	args      []any
+	relations map[string][]string
+}
+
+// validates the given data and converts it into column values; generates a
+// primary key value if none is given, except for serial fields, which draw
+// their value from their sequence
+func prepareRecord(dialect Dialect, codec valueCodec, collection string, fields map[string]FieldType, data map[string]any) (preparedRecord, error) {
+	record := preparedRecord{relations: map[string][]string{}}
+
+	if err := validateFieldNames(fields, data); err != nil {
+		return record, err
+	}
+
+	primaryKey, hasPrimaryKey := primaryKeyField(fields)
+	fieldErrors := map[string]string{}
+
+	for _, name := range sortedFieldNames(fields) {
+		fieldType := fields[name]
+
+		value, present := data[name]
+		value, err := fieldType.Encode(value)
+		if err != nil {
+			fieldErrors[name] = fieldErrorMessage(err, present)
+			continue
+		}
+
+		if isMultiRelation(fieldType) {
+			record.relations[name] = value.([]string)
+			continue
+		}
+
+		// generated columns cannot be written
+		if isComputed(fieldType) {
+			continue
+		}
+
+		if isSerial(fieldType) && value == nil {
+			// rows of a single statement are inserted using the same columns
+			sql := dialect.SerialValue(serialSequenceName(collection, name))
+			if sql == "" {
+				sql = "NULL"
+			}
+
+			record.columns = append(record.columns, dialect.Quote(name))
+			record.values = append(record.values, sql)
+			continue
+		}
+
+		if hasPrimaryKey && name == primaryKey {
+			if value == nil {
+				if value, err = generatePrimaryKey(fieldType); err != nil {
+					return record, err
+				}
+			}
+
+			record.id = recordIdString(value)
+		}
+
+		sql, args := bindSQL(codec.encodeValue(fieldType, value))
+		record.columns = append(record.columns, dialect.Quote(name))
+		record.values = append(record.values, sql)
+		record.args = append(record.args, args...)
+	}
+
+	if len(fieldErrors) > 0 {
+		return record, &ValidationError{fields: fieldErrors}
+	}
+
+	if len(record.relations) > 0 && !hasPrimaryKey {
+		return record, fmt.Errorf("collection %q has no primary key", collection)
+	}
+
+	return record, nil
+}
+
+func createRecord(tx contextTx, codec valueCodec, collection string, fields map[string]FieldType, data map[string]any) (string, error) {
+	ids, err := createRecords(tx, codec, collection, fields, []map[string]any{data})
+	if err != nil {
+		return "", err
+	}
+
+	return ids[0], nil
+}
+
+// validates all rows before inserting them using a single statement
+func createRecords(tx contextTx, codec valueCodec, collection string, fields map[string]FieldType, rows []map[string]any) ([]string, error) {
+	if len(rows) == 0 {
+		return []string{}, nil
+	}
+
+	records := make([]preparedRecord, len(rows))
+	for i, data := range rows {
+		record, err := prepareRecord(tx.dialect, codec, collection, fields, data)
+		if err != nil {
+			if len(rows) == 1 {
+				return nil, err
+			}
+
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+
+		records[i] = record
+	}
+
+	values := []string{}
+	args := []any{}
+	for _, record := range records {
+		values = append(values, "("+strings.Join(record.values, ", ")+")")
+		args = append(args, record.args...)
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", tx.dialect.Quote(collection), strings.Join(records[0].columns, ", "), strings.Join(values, ", "))
+	if primaryKey, ok := primaryKeyField(fields); ok && isSerial(fields[primaryKey]) {
+		// serial keys are read back in insertion order
+		ids, err := insertReturningIds(tx, sql+" RETURNING "+tx.dialect.Quote(primaryKey), args)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ids) != len(records) {
+			return nil, fmt.Errorf("expected %d inserted records, got %d", len(records), len(ids))
+		}
+
+		for i := range records {
+			records[i].id = ids[i]
+		}
+	} else if _, err := tx.Exec(sql, args...); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(records))
+	for i, record := range records {
+		for name, targets := range record.relations {
+			if err := saveRelations(tx, collection, name, fields[name].(FieldTypeMultiRelation), record.id, targets); err != nil {
+				return nil, err
+			}
+		}
+
+		ids[i] = record.id
+	}
+
+	return ids, nil
+}
+
+// executes the given INSERT statement returning the primary key of the inserted
+// rows and returns the keys as strings; the statement is recorded like
+// statements executed using Exec
+func insertReturningIds(tx contextTx, sql string, args []any) ([]string, error) {
+	if tx.statements != nil {
+		*tx.statements = append(*tx.statements, rebindPlaceholders(tx.dialect, sql))
+	}
+
+	rows, err := tx.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id any
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, recordIdString(id))
+	}
+
+	return ids, rows.Err()
+}
+
+// validates the given conflict columns and returns their values from data
+func conflictValues(fields map[string]FieldType, conflictColumns []string, data map[string]any) ([]any, error) {
+	if len(conflictColumns) == 0 {
+		return nil, fmt.Errorf("invalid conflict columns, expected at least one column")
+	}
+
+	values := []any{}
+	for i, name := range conflictColumns {
+		fieldType, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid conflict columns, unknown field %q", name)
+		}
+
+		if isMultiRelation(fieldType) {
+			return nil, fmt.Errorf("invalid conflict columns, multi relation field %q", name)
+		}
+
+		if slices.Contains(conflictColumns[:i], name) {
+			return nil, fmt.Errorf("invalid conflict columns, duplicate field %q", name)
+		}
+
+		value, err := fieldType.Encode(data[name])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", name, err)
+		}
+
+		if value == nil {
+			return nil, fmt.Errorf("invalid conflict columns, expected value for field %q", name)
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// Records are upserted by inserting them using ON CONFLICT DO NOTHING and
+// updating the conflicting record if nothing was inserted. DO UPDATE is not
+// used since DuckDB refuses to assign columns that are part of an index. The
+// database rejects conflict columns that are not covered by the primary key or
+// a unique index.
+func upsertRecord(tx contextTx, codec valueCodec, collection string, fields map[string]FieldType, conflictColumns []string, data map[string]any) (string, error) {
+	primaryKey, ok := primaryKeyField(fields)
+	if !ok {
+		return "", fmt.Errorf("collection %q has no primary key", collection)
+	}
+
+	record, err := prepareRecord(tx.dialect, codec, collection, fields, data)
+	if err != nil {
+		return "", err
+	}
+
+	values, err := conflictValues(fields, conflictColumns, data)
+	if err != nil {
+		return "", err
+	}
+
+	conflict := lo.Map(conflictColumns, func(name string, i int) string { return tx.dialect.Quote(name) })
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO NOTHING", tx.dialect.Quote(collection),
+		strings.Join(record.columns, ", "), strings.Join(record.values, ", "), strings.Join(conflict, ", "))
+
+	var inserted int64
+	if isSerial(fields[primaryKey]) {
+		ids, err := insertReturningIds(tx, sql+" RETURNING "+tx.dialect.Quote(primaryKey), record.args)
+		if err != nil {
+			return "", err
+		}
+
+		if inserted = int64(len(ids)); inserted > 0 {
+			record.id = ids[0]
+		}
+	} else {
+		result, err := tx.Exec(sql, record.args...)
+		if err != nil {
+			return "", err
+		}
+
+		if inserted, err = result.RowsAffected(); err != nil {
+			return "", err
+		}
+	}
+
+	if inserted > 0 {
+		for name, targets := range record.relations {
+			if err := saveRelations(tx, collection, name, fields[name].(FieldTypeMultiRelation), record.id, targets); err != nil {
+				return "", err
+			}
+		}
+
+		return record.id, nil
+	}
+
+	filters := lo.Map(conflictColumns, func(name string, i int) Filter {
+		return Filter{Field: name, Operator: FilterEqual, Value: values[i]}
+	})
+
+	where, args, err := whereSQL(tx.dialect, codec, fields, filters)
+	if err != nil {
+		return "", err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s", tx.dialect.Quote(primaryKey), tx.dialect.Quote(collection), where)
+	existing, err := scanRecord(tx.QueryRow(query, args...).Scan, codec, fields, []string{primaryKey})
+	if err != nil {
+		return "", err
+	}
+
+	id := recordIdString(existing[primaryKey])
+
+	// the primary key of the existing record is kept
+	update := lo.OmitByKeys(data, append(slices.Clone(conflictColumns), primaryKey))
+	if err := updateRecord(tx, codec, collection, fields, id, update, nil); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func getRecord(tx contextTx, codec valueCodec, collection string, fields map[string]FieldType, id string) (map[string]any, error) {
+	primaryKey, ok := primaryKeyField(fields)
+	if !ok {
+		return nil, fmt.Errorf("collection %q has no primary key", collection)
+	}
+
+	id, err := validatePrimaryKey(fields, primaryKey, id)
+	if err != nil {
+		return nil, err
+	}
+
+	names := columnFieldNames(fields)
+	columns := lo.Map(names, func(name string, i int) string {
+		return codec.selectSQL(fields[name], tx.dialect.Quote(name))
+	})
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", strings.Join(columns, ", "), tx.dialect.Quote(collection), tx.dialect.Quote(primaryKey))
+
+	record, err := scanRecord(tx.QueryRow(query, id).Scan, codec, fields, names)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := loadRelations(tx, collection, fields, id, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func listRecords(tx contextTx, codec valueCodec, collection string, fields map[string]FieldType, opts ListOptions) ([]map[string]any, int64, error) {
+	where, args, err := whereSQL(tx.dialect, codec, fields, opts.Filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := countWhere(tx, collection, where, args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	records := []map[string]any{}
+	err = iterateRecords(tx, codec, collection, fields, opts, func(record map[string]any) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, nil
+}
+
+// scans the records matching the given options one at a time and calls fn for
+// each of them; stops and returns the error of fn if it fails
+func iterateRecords(tx contextTx, codec valueCodec, collection string, fields map[string]FieldType, opts ListOptions, fn func(record map[string]any) error) error {
+	where, args, err := whereSQL(tx.dialect, codec, fields, opts.Filters)
+	if err != nil {
+		return err
+	}
+
+	page, err := pageSQL(tx.dialect, fields, opts)
+	if err != nil {
+		return err
+	}
+
+	relations := multiRelationFieldNames(fields)
+	primaryKey, ok := primaryKeyField(fields)
+	if len(relations) > 0 && !ok {
+		return fmt.Errorf("collection %q has no primary key", collection)
+	}
+
+	names := columnFieldNames(fields)
+	columns := lo.Map(names, func(name string, i int) string {
+		return codec.selectSQL(fields[name], tx.dialect.Quote(name))
+	})
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s%s", strings.Join(columns, ", "), tx.dialect.Quote(collection), where, page)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scanRecord(rows.Scan, codec, fields, names)
+		if err != nil {
+			return err
+		}
+
+		if len(relations) > 0 {
+			if err := loadRelations(tx, collection, fields, recordIdString(record[primaryKey]), record); err != nil {
+				return err
+			}
+		}
+
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// runs the given query and decodes the result columns named like fields of the
+// collection according to their field types; other columns are returned as
+// scanned
+func queryRecords(tx contextTx, codec valueCodec, collection string, fields map[string]FieldType, query string, args []any) ([]map[string]any, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	records := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(names))
+		dest := lo.Map(values, func(value any, i int) any { return &values[i] })
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		record := map[string]any{}
+		for i, name := range names {
+			fieldType, ok := fields[name]
+			if !ok || values[i] == nil {
+				record[name] = values[i]
+				continue
+			}
+
+			value, err := codec.decodeValue(fieldType, values[i])
+			if err != nil {
+				return nil, fmt.Errorf("collection %q: field %q: %w", collection, name, err)
+			}
+
+			record[name] = value
+		}
+
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+func countRecords(tx contextTx, codec valueCodec, collection string, fields map[string]FieldType, opts ListOptions) (int64, error) {
+	where, args, err := whereSQL(tx.dialect, codec, fields, opts.Filters)
+	if err != nil {
+		return 0, err
+	}
+
+	return countWhere(tx, collection, where, args)
+}
+
+// counts the rows of the given table matching the given WHERE clause
+func countWhere(tx contextTx, collection string, where string, args []any) (int64, error) {
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s%s", tx.dialect.Quote(collection), where)
+	if err := tx.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// scans a single row consisting of the given fields into a record
+func scanRecord(scan func(dest ...any) error, codec valueCodec, fields map[string]FieldType, names []string) (map[string]any, error) {
+	values := make([]any, len(names))
+	dest := make([]any, len(names))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	if err := scan(dest...); err != nil {
+		return nil, err
+	}
+
+	record := map[string]any{}
+	for i, name := range names {
+		if values[i] == nil {
+			record[name] = nil
+			continue
+		}
+
+		value, err := codec.decodeValue(fields[name], values[i])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", name, err)
+		}
+
+		record[name] = value
+	}
+
+	return record, nil
+}
+
+// updates the given fields of a record; if a version is given, the record is
+// only updated if its version field matches and the version is incremented
+func updateRecord(tx contextTx, codec valueCodec, collection string, fields map[string]FieldType, id string, data map[string]any, version *int64) error {
+	if err := validateFieldNames(fields, data); err != nil {
+		return err
+	}
+
+	primaryKey, ok := primaryKeyField(fields)
+	if !ok {
+		return fmt.Errorf("collection %q has no primary key", collection)
+	}
+
+	id, err := validatePrimaryKey(fields, primaryKey, id)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := data[primaryKey]; ok {
+		return fmt.Errorf("field %q: primary key must not be updated", primaryKey)
+	}
+
+	assignments := []string{}
+	args := []any{}
+	relations := map[string][]string{}
+	fieldErrors := map[string]string{}
+
+	for _, name := range sortedFieldNames(fields) {
+		value, ok := data[name]
+		if !ok {
+			continue
+		}
+
+		fieldType := fields[name]
+
+		value, err := fieldType.Encode(value)
+		if err != nil {
+			fieldErrors[name] = err.Error()
+			continue
+		}
+
+		if isMultiRelation(fieldType) {
+			relations[name] = value.([]string)
+			continue
+		}
+
+		if isComputed(fieldType) {
+			continue
+		}
+
+		sql, valueArgs := bindSQL(codec.encodeValue(fieldType, value))
+		assignments = append(assignments, tx.dialect.Quote(name)+" = "+sql)
+		args = append(args, valueArgs...)
+	}
+
+	if len(fieldErrors) > 0 {
+		return &ValidationError{fields: fieldErrors}
+	}
+
+	where := tx.dialect.Quote(primaryKey) + " = ?"
+	args = append(args, id)
+
+	if version != nil {
+		if _, ok := fields[VersionField]; !ok {
+			return fmt.Errorf("collection %q has no version field", collection)
+		}
+
+		if _, ok := data[VersionField]; ok {
+			return fmt.Errorf("field %q: version must not be updated", VersionField)
+		}
+
+		column := tx.dialect.Quote(VersionField)
+		assignments = append(assignments, column+" = "+column+" + 1")
+		where += " AND " + column + " = ?"
+		args = append(args, *version)
+	}
+
+	if len(assignments) > 0 {
+		query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", tx.dialect.Quote(collection), strings.Join(assignments, ", "), where)
+
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+
+		if err := requireAffectedRows(result); errors.Is(err, ErrRecordNotFound) && version != nil {
+			return versionConflict(tx, collection, primaryKey, id)
+		} else if err != nil {
+			return err
+		}
+	} else {
+		// no columns to update, only check for existence
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?", tx.dialect.Quote(collection), tx.dialect.Quote(primaryKey))
+
+		var count int64
+		if err := tx.QueryRow(query, id).Scan(&count); err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return ErrRecordNotFound
+		}
+	}
+
+	for name, targets := range relations {
+		if err := saveRelations(tx, collection, name, fields[name].(FieldTypeMultiRelation), id, targets); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteRecord(tx contextTx, collection string, fields map[string]FieldType, id string) error {
+	primaryKey, ok := primaryKeyField(fields)
+	if !ok {
+		return fmt.Errorf("collection %q has no primary key", collection)
+	}
+
+	id, err := validatePrimaryKey(fields, primaryKey, id)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", tx.dialect.Quote(collection), tx.dialect.Quote(primaryKey))
+
+	result, err := tx.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	if err := requireAffectedRows(result); err != nil {
+		return err
+	}
+
+	// links are removed after the record since the deletion may still fail
+	return deleteRelations(tx, collection, fields, id)
+}
+
+type TruncateOptions struct {
+	// restarts the sequences of serial fields, so that the next record
+	// created gets the first value again
+	RestartSequences bool
+}
+
+// deletes all records of the collection and their links; sequences are not
+// restarted
+func truncateRecords(tx contextTx, collection string, fields map[string]FieldType) error {
+	// links are removed first since DuckDB junction tables lack a foreign key
+	// on the source
+	for _, name := range multiRelationFieldNames(fields) {
+		ft := fields[name].(FieldTypeMultiRelation)
+		query := "DELETE FROM " + tx.dialect.Quote(junctionTableName(collection, name, ft.Collection))
+
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec("DELETE FROM " + tx.dialect.Quote(collection))
+	return err
+}
+
+// returns ErrRecordNotFound if the statement did not affect any rows
+func requireAffectedRows(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
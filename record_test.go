@@ -0,0 +1,1449 @@
+package ldb_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"lehnert.dev/ldb"
+)
+
+// valid ids for tests
+const (
+	testId0 = "0000000000000000000000000000000"
+	testId1 = "0000000000000000000000000000001"
+	testId2 = "0000000000000000000000000000002"
+)
+
+var testAuthors = ldb.Collection{
+	Name: "authors",
+	Schema: &ldb.CollectionSchema{
+		Fields: []*ldb.Field{
+			{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+			{Name: "name", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+		},
+	},
+}
+
+var testPosts = ldb.Collection{
+	Name: "posts",
+	Schema: &ldb.CollectionSchema{
+		Fields: []*ldb.Field{
+			{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+			{Name: "title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+			{Name: "views", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{CreateDefaultValue: func() int64 { return 0 }}}},
+			{Name: "rating", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeFloat{Nullable: true}}},
+			{Name: "published", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeBool{Nullable: true}}},
+			{Name: "published_at", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeDateTime{Nullable: true}}},
+			{Name: "state", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeEnum{Nullable: true, EnumValues: []string{"draft", "live"}}}},
+			{Name: "author", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Nullable: true, Collection: "authors"}}},
+		},
+	},
+}
+
+// returns the field types of the given collection keyed by field name
+func fieldTypes(collection ldb.Collection) map[string]ldb.FieldType {
+	fields := map[string]ldb.FieldType{}
+	for _, field := range collection.Schema.Fields {
+		fields[field.Name] = field.Schema.Type
+	}
+
+	return fields
+}
+
+// opens a transaction on a fresh database containing the test collections
+func beginTestRecords(t *testing.T, adapter ldb.DatabaseAdapter) ldb.DatabaseTransaction {
+	t.Helper()
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { tx.Rollback() })
+
+	for _, collection := range []ldb.Collection{testAuthors, testPosts} {
+		if err := tx.SaveCollection(collection); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return tx
+}
+
+func TestCreateRecord(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			authorId, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{
+				"id":   testId0,
+				"name": "Jane",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if authorId != testId0 {
+				t.Fatalf("expected id %q, got %q", testId0, authorId)
+			}
+
+			postId, err := tx.CreateRecord("posts", fieldTypes(testPosts), map[string]any{
+				"title":        "Hello",
+				"rating":       4.5,
+				"published":    true,
+				"published_at": time.Now(),
+				"state":        "live",
+				"author":       authorId,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if postId == "" {
+				t.Fatal("expected generated id")
+			}
+
+			// title is required
+			_, err = tx.CreateRecord("posts", fieldTypes(testPosts), map[string]any{})
+			if err == nil || !strings.Contains(err.Error(), `"title"`) {
+				t.Fatalf("expected error naming the missing field, got %v", err)
+			}
+
+			_, err = tx.CreateRecord("posts", fieldTypes(testPosts), map[string]any{"title": "Hello", "unknown": 1})
+			if err == nil {
+				t.Fatal("expected error for unknown field")
+			}
+		})
+	}
+}
+
+func TestGetRecord(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			publishedAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+			if _, err := tx.CreateRecord("posts", fieldTypes(testPosts), map[string]any{
+				"id":           testId0,
+				"title":        "Hello",
+				"rating":       4.5,
+				"published":    true,
+				"published_at": publishedAt,
+				"state":        "live",
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := tx.GetRecord("posts", fieldTypes(testPosts), testId0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := map[string]any{
+				"id":        testId0,
+				"title":     "Hello",
+				"views":     int64(0),
+				"rating":    4.5,
+				"published": true,
+				"state":     "live",
+				"author":    nil,
+			}
+
+			for key, value := range expected {
+				if record[key] != value {
+					t.Errorf("expected %s to be %#v, got %#v", key, value, record[key])
+				}
+			}
+
+			if date, ok := record["published_at"].(time.Time); !ok || !date.Equal(publishedAt) {
+				t.Errorf("expected published_at to be %v, got %#v", publishedAt, record["published_at"])
+			}
+
+			if _, err := tx.GetRecord("posts", fieldTypes(testPosts), testId1); !errors.Is(err, ldb.ErrRecordNotFound) {
+				t.Errorf("expected ErrRecordNotFound, got %v", err)
+			}
+
+			if _, err := tx.GetRecord("posts", fieldTypes(testPosts), "invalid"); err == nil {
+				t.Error("expected error for invalid id")
+			}
+		})
+	}
+}
+
+func TestUpdateRecord(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+			fields := fieldTypes(testPosts)
+
+			if _, err := tx.CreateRecord("posts", fields, map[string]any{"id": testId0, "title": "Hello", "rating": 1.5}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.UpdateRecord("posts", fields, testId0, map[string]any{"title": "Updated", "views": int64(3)}); err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := tx.GetRecord("posts", fields, testId0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// fields not present in data remain untouched
+			if record["title"] != "Updated" || record["views"] != int64(3) || record["rating"] != 1.5 {
+				t.Errorf("unexpected record after update: %v", record)
+			}
+
+			if err := tx.UpdateRecord("posts", fields, testId0, map[string]any{"unknown": 1}); err == nil {
+				t.Error("expected error for unknown field")
+			}
+
+			if err := tx.UpdateRecord("posts", fields, testId0, map[string]any{"id": testId1}); err == nil {
+				t.Error("expected error when updating primary key")
+			}
+
+			if err := tx.UpdateRecord("posts", fields, testId0, map[string]any{"title": 42}); err == nil {
+				t.Error("expected validation error")
+			}
+
+			if err := tx.UpdateRecord("posts", fields, testId1, map[string]any{"title": "Missing"}); !errors.Is(err, ldb.ErrRecordNotFound) {
+				t.Errorf("expected ErrRecordNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDeleteRecord(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("posts", fieldTypes(testPosts), map[string]any{"id": testId1, "title": "Hello", "author": testId0}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("posts", fieldTypes(testPosts), map[string]any{"id": testId2, "title": "Unrelated"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.DeleteRecord("posts", fieldTypes(testPosts), testId2); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.GetRecord("posts", fieldTypes(testPosts), testId2); !errors.Is(err, ldb.ErrRecordNotFound) {
+				t.Fatalf("expected deleted record to be gone, got %v", err)
+			}
+
+			if err := tx.DeleteRecord("posts", fieldTypes(testPosts), testId2); !errors.Is(err, ldb.ErrRecordNotFound) {
+				t.Fatalf("expected ErrRecordNotFound, got %v", err)
+			}
+
+			// post still references the author; DuckDB aborts the transaction
+			// on constraint errors, so this has to be the last statement
+			if err := tx.DeleteRecord("authors", fieldTypes(testAuthors), testId0); !errors.Is(err, ldb.ErrRecordReferenced) {
+				t.Fatalf("expected ErrRecordReferenced, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTruncateCollection(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecords("posts", fieldTypes(testPosts), []map[string]any{
+				{"id": testId1, "title": "Hello", "author": testId0},
+				{"id": testId2, "title": "Unrelated"},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if name == "duckdb" {
+				if err := tx.TruncateCollection("posts", fieldTypes(testPosts), ldb.TruncateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+
+				// DuckDB checks foreign keys against the committed posts
+				if err := tx.TruncateCollection("authors", fieldTypes(testAuthors), ldb.TruncateOptions{}); !errors.Is(err, ldb.ErrRecordReferenced) {
+					t.Fatalf("expected ErrRecordReferenced, got %v", err)
+				}
+
+				return
+			}
+
+			// the check of the posts still referencing the author is deferred
+			// until commit
+			for _, collection := range []ldb.Collection{testAuthors, testPosts} {
+				if err := tx.TruncateCollection(collection.Name, fieldTypes(collection), ldb.TruncateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+
+				if count, err := tx.CountRecords(collection.Name, fieldTypes(collection), ldb.ListOptions{}); err != nil || count != 0 {
+					t.Fatalf("expected %s to be empty, got %d (%v)", collection.Name, count, err)
+				}
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestTruncateCollectionRestartSequences(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	tickets := ldb.Collection{
+		Name: "tickets",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSerial{PrimaryKey: true}}},
+				{Name: "title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+			},
+		},
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := tx.SaveCollection(tickets); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(tickets)
+			for _, title := range []string{"first", "second"} {
+				if _, err := tx.CreateRecord("tickets", fields, map[string]any{"title": title}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err = tx.TruncateCollection("tickets", fields, ldb.TruncateOptions{RestartSequences: true})
+			if name == "duckdb" {
+				if !errors.Is(err, ldb.ErrUnsupported) {
+					t.Fatalf("expected ErrUnsupported, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			id, err := tx.CreateRecord("tickets", fields, map[string]any{"title": "again"})
+			if err != nil || id != "1" {
+				t.Fatalf("expected id 1, got %q (%v)", id, err)
+			}
+		})
+	}
+}
+
+func TestSQLiteDeleteRecordCascade(t *testing.T) {
+	adapter := openTestSQLiteAdapter(t)
+	defer adapter.Close()
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	comments := ldb.Collection{
+		Name: "comments",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "author", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Collection: "authors", CascadeDelete: true}}},
+			},
+		},
+	}
+
+	for _, collection := range []ldb.Collection{testAuthors, comments} {
+		if err := tx.SaveCollection(collection); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tx.CreateRecord("comments", fieldTypes(comments), map[string]any{"id": testId1, "author": testId0}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.DeleteRecord("authors", fieldTypes(testAuthors), testId0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tx.GetRecord("comments", fieldTypes(comments), testId1); !errors.Is(err, ldb.ErrRecordNotFound) {
+		t.Fatalf("expected comment to be deleted by cascade, got %v", err)
+	}
+}
+
+// cascading deletes are only supported by SQLite
+func TestDuckDBDeleteRecordCascade(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	tx := beginTestRecords(t, adapter)
+
+	for _, fieldType := range []ldb.FieldType{
+		ldb.FieldTypeSingleRelation{Collection: "authors", CascadeDelete: true},
+		ldb.FieldTypeMultiRelation{Collection: "authors", CascadeDelete: true},
+	} {
+		comments := ldb.Collection{
+			Name: "comments",
+			Schema: &ldb.CollectionSchema{
+				Fields: []*ldb.Field{
+					{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+					{Name: "author", Schema: &ldb.FieldSchema{Type: fieldType}},
+				},
+			},
+		}
+
+		if err := tx.SaveCollection(comments); !errors.Is(err, ldb.ErrUnsupported) || !strings.Contains(err.Error(), "ON DELETE CASCADE") {
+			t.Fatalf("expected unsupported cascade error for %s, got %v", fieldType, err)
+		}
+	}
+}
+
+func TestSQLiteRelationActions(t *testing.T) {
+	adapter := openTestSQLiteAdapter(t)
+	defer adapter.Close()
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	comments := ldb.Collection{
+		Name: "comments",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "author", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Nullable: true, Collection: "authors", OnDelete: ldb.DeleteSetNull}}},
+			},
+		},
+	}
+
+	for _, collection := range []ldb.Collection{testAuthors, comments} {
+		if err := tx.SaveCollection(collection); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tx.CreateRecord("comments", fieldTypes(comments), map[string]any{"id": testId1, "author": testId0}); err != nil {
+		t.Fatal(err)
+	}
+
+	// changing the referential actions rebuilds the table
+	comments.Forward()
+	comments.Schema.Fields[1].Schema.Type = ldb.FieldTypeSingleRelation{Nullable: true, Collection: "authors", OnDelete: ldb.DeleteSetNull, CascadeUpdate: true}
+	if err := tx.SaveCollection(comments); err != nil {
+		t.Fatal(err)
+	}
+
+	// primary keys cannot be changed through UpdateRecord
+	if _, err := tx.Query("authors", fieldTypes(testAuthors), `UPDATE authors SET id = ? WHERE id = ? RETURNING id`, testId2, testId0); err != nil {
+		t.Fatal(err)
+	}
+
+	comment, err := tx.GetRecord("comments", fieldTypes(comments), testId1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if comment["author"] != testId2 {
+		t.Fatalf("expected author to be updated by cascade, got %v", comment["author"])
+	}
+
+	if err := tx.DeleteRecord("authors", fieldTypes(testAuthors), testId2); err != nil {
+		t.Fatal(err)
+	}
+
+	comment, err = tx.GetRecord("comments", fieldTypes(comments), testId1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if comment["author"] != nil {
+		t.Fatalf("expected author to be set to null, got %v", comment["author"])
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	collections, err := ldb.ReflectCollections(adapter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := comments.Fields()["author"]
+	for _, collection := range collections {
+		if author := collection.Fields()["author"]; collection.Name == "comments" && !author.Equal(expected) {
+			t.Errorf("expected reflected relation %v, got %v", expected, author)
+		}
+	}
+}
+
+func TestDuckDBRelationActions(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	tx := beginTestRecords(t, adapter)
+
+	relations := map[string]ldb.FieldTypeSingleRelation{
+		"ON DELETE CASCADE":  {Collection: "authors", CascadeDelete: true},
+		"ON DELETE SET NULL": {Nullable: true, Collection: "authors", OnDelete: ldb.DeleteSetNull},
+		"ON UPDATE CASCADE":  {Collection: "authors", CascadeUpdate: true},
+	}
+
+	for action, relation := range relations {
+		comments := ldb.Collection{
+			Name: "comments",
+			Schema: &ldb.CollectionSchema{
+				Fields: []*ldb.Field{
+					{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+					{Name: "author", Schema: &ldb.FieldSchema{Type: relation}},
+				},
+			},
+		}
+
+		err := tx.SaveCollection(comments)
+		if !errors.Is(err, ldb.ErrUnsupported) || !strings.Contains(err.Error(), `"author"`) || !strings.Contains(err.Error(), action) {
+			t.Fatalf("expected unsupported %s error for field author, got %v", action, err)
+		}
+	}
+
+	// the transaction is still usable since no statement failed
+	if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"name": "Jane"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			publishedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+			if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, title := range []string{"a", "b"} {
+				if _, err := tx.CreateRecord("posts", fieldTypes(testPosts), map[string]any{
+					"title":        title,
+					"published":    true,
+					"published_at": publishedAt,
+					"author":       testId0,
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			records, err := tx.Query("posts", fieldTypes(testPosts), `SELECT p.published, MAX(p.published_at) AS published_at, a.name, COUNT(*) AS posts
+				FROM posts p JOIN authors a ON a.id = p.author
+				WHERE a.id = ?
+				GROUP BY p.published, a.name`, testId0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != 1 {
+				t.Fatalf("expected a single row, got %v", records)
+			}
+
+			record := records[0]
+			if date, ok := record["published_at"].(time.Time); !ok || !date.Equal(publishedAt) {
+				t.Errorf("expected published_at to be %v, got %#v", publishedAt, record["published_at"])
+			}
+
+			if record["published"] != true || record["name"] != "Jane" || record["posts"] != int64(2) {
+				t.Errorf("unexpected row %v", record)
+			}
+		})
+	}
+}
+
+func TestListRecords(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+			fields := fieldTypes(testPosts)
+
+			for i := int64(1); i <= 5; i++ {
+				data := map[string]any{"title": fmt.Sprintf("Post %d", i), "views": i}
+				if i%2 == 0 {
+					data["rating"] = 1.0
+				}
+
+				if _, err := tx.CreateRecord("posts", fields, data); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			records, total, err := tx.ListRecords("posts", fields, ldb.ListOptions{
+				Filters:   []ldb.Filter{{Field: "views", Operator: ldb.FilterGreaterOrEqual, Value: int64(2)}},
+				OrderBy:   "views",
+				OrderDesc: true,
+				Limit:     2,
+				Offset:    1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if total != 4 {
+				t.Errorf("expected total of 4, got %v", total)
+			}
+
+			views := lo.Map(records, func(record map[string]any, i int) any { return record["views"] })
+			if !slices.Equal(views, []any{int64(4), int64(3)}) {
+				t.Errorf("expected views [4 3], got %v", views)
+			}
+
+			records, total, err = tx.ListRecords("posts", fields, ldb.ListOptions{
+				Filters: []ldb.Filter{{Field: "rating", Operator: ldb.FilterEqual, Value: nil}},
+				Offset:  1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if total != 3 || len(records) != 2 {
+				t.Errorf("expected 2 of 3 records without rating, got %v of %v", len(records), total)
+			}
+
+			if _, _, err := tx.ListRecords("posts", fields, ldb.ListOptions{Filters: []ldb.Filter{{Field: "unknown", Operator: ldb.FilterEqual, Value: 1}}}); err == nil {
+				t.Error("expected error for unknown filter field")
+			}
+
+			if _, _, err := tx.ListRecords("posts", fields, ldb.ListOptions{OrderBy: "unknown"}); err == nil {
+				t.Error("expected error for unknown order field")
+			}
+		})
+	}
+}
+
+func TestIterateRecords(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+			fields := fieldTypes(testPosts)
+
+			for i := int64(1); i <= 5; i++ {
+				if _, err := tx.CreateRecord("posts", fields, map[string]any{"title": fmt.Sprintf("Post %d", i), "views": i}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			views := []any{}
+			err := tx.IterateRecords("posts", fields, ldb.ListOptions{
+				Filters: []ldb.Filter{{Field: "views", Operator: ldb.FilterGreaterOrEqual, Value: int64(2)}},
+				OrderBy: "views",
+			}, func(record map[string]any) error {
+				views = append(views, record["views"])
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(views, []any{int64(2), int64(3), int64(4), int64(5)}) {
+				t.Errorf("expected views [2 3 4 5], got %v", views)
+			}
+
+			// iteration stops at the first error of fn
+			stop := errors.New("stop")
+			calls := 0
+			err = tx.IterateRecords("posts", fields, ldb.ListOptions{}, func(record map[string]any) error {
+				calls++
+				return stop
+			})
+			if !errors.Is(err, stop) || calls != 1 {
+				t.Fatalf("expected stop error after 1 call, got %v after %d", err, calls)
+			}
+
+			// the transaction is still usable after an early return
+			if _, err := tx.CreateRecord("posts", fields, map[string]any{"title": "Post 6", "views": int64(6)}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.IterateRecords("posts", fields, ldb.ListOptions{OrderBy: "unknown"}, func(record map[string]any) error { return nil }); err == nil {
+				t.Error("expected error for unknown order field")
+			}
+		})
+	}
+}
+
+func TestListOptionsClone(t *testing.T) {
+	base := ldb.ListOptions{
+		Filters: []ldb.Filter{
+			{Field: "views", Operator: ldb.FilterGreater, Value: int64(2)},
+			{Field: "tags", Operator: ldb.FilterEqual, Value: []any{"a", "b"}},
+		},
+		Limit:   10,
+		OrderBy: "views",
+	}
+
+	cloned := base.Clone()
+	if !reflect.DeepEqual(*cloned, base) {
+		t.Fatalf("expected clone %v to equal original %v", *cloned, base)
+	}
+
+	cloned.Filters[0].Value = int64(5)
+	cloned.Filters[1].Value.([]any)[0] = "c"
+	cloned.Filters = append(cloned.Filters, ldb.Filter{Field: "title", Operator: ldb.FilterEqual, Value: "Post"})
+	cloned.Limit = 20
+
+	if base.Filters[0].Value != int64(2) {
+		t.Errorf("expected original filter value 2, got %v", base.Filters[0].Value)
+	}
+
+	if tags := base.Filters[1].Value.([]any); tags[0] != "a" {
+		t.Errorf("expected original tags [a b], got %v", tags)
+	}
+
+	if len(base.Filters) != 2 || base.Limit != 10 {
+		t.Errorf("expected original options to be unchanged, got %v", base)
+	}
+}
+
+func TestCaseInsensitiveFilters(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	tags := ldb.Collection{
+		Name: "tags",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "name", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{CaseInsensitive: true}}},
+				{Name: "kind", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeEnum{EnumValues: []string{"Topic", "Place"}, CaseInsensitive: true}}},
+			},
+		},
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := tx.SaveCollection(tags); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(tags)
+			id, err := tx.CreateRecord("tags", fields, map[string]any{"name": "GoLang", "kind": "TOPIC"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := tx.GetRecord("tags", fields, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// text values are stored as given, enum values in canonical casing
+			if record["name"] != "GoLang" || record["kind"] != "Topic" {
+				t.Fatalf("expected GoLang and Topic, got %v", record)
+			}
+
+			for _, filter := range []ldb.Filter{
+				{Field: "name", Operator: ldb.FilterEqual, Value: "golang"},
+				{Field: "kind", Operator: ldb.FilterEqual, Value: "topic"},
+			} {
+				count, err := tx.CountRecords("tags", fields, ldb.ListOptions{Filters: []ldb.Filter{filter}})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if count != 1 {
+					t.Errorf("%s: expected 1 record, got %d", filter.Field, count)
+				}
+			}
+
+			count, err := tx.CountRecords("tags", fields, ldb.ListOptions{Filters: []ldb.Filter{{Field: "name", Operator: ldb.FilterNotEqual, Value: "GOLANG"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if count != 0 {
+				t.Errorf("expected no records, got %d", count)
+			}
+		})
+	}
+}
+
+func TestCountRecords(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+			fields := fieldTypes(testPosts)
+
+			for i := int64(1); i <= 5; i++ {
+				if _, err := tx.CreateRecord("posts", fields, map[string]any{"title": fmt.Sprintf("Post %d", i), "views": i}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// limit and offset do not affect the count
+			count, err := tx.CountRecords("posts", fields, ldb.ListOptions{
+				Filters: []ldb.Filter{{Field: "views", Operator: ldb.FilterGreater, Value: int64(2)}},
+				Limit:   1,
+				Offset:  1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if count != 3 {
+				t.Errorf("expected count of 3, got %v", count)
+			}
+
+			if count, err := tx.CountRecords("posts", fields, ldb.ListOptions{}); err != nil || count != 5 {
+				t.Errorf("expected count of 5, got %v (%v)", count, err)
+			}
+
+			if _, err := tx.CountRecords("posts", fields, ldb.ListOptions{Filters: []ldb.Filter{{Field: "unknown", Operator: ldb.FilterEqual, Value: 1}}}); err == nil {
+				t.Error("expected error for unknown filter field")
+			}
+		})
+	}
+}
+
+func TestUpsertRecord(t *testing.T) {
+	users := ldb.Collection{
+		Name: "users",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "email", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}, Unique: true}},
+				{Name: "name", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+				{Name: "tags", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeArray{Nullable: true, Element: ldb.FieldTypeText{}}}},
+			},
+		},
+	}
+
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := tx.SaveCollection(users); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(users)
+
+			id, err := tx.UpsertRecord("users", fields, []string{"email"}, map[string]any{"email": "jane@example.com", "name": "Jane"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			again, err := tx.UpsertRecord("users", fields, []string{"email"}, map[string]any{"email": "jane@example.com", "name": "Jane Doe"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if again != id {
+				t.Fatalf("expected id %q of the existing record, got %q", id, again)
+			}
+
+			records, total, err := tx.ListRecords("users", fields, ldb.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if total != 1 || records[0]["name"] != "Jane Doe" {
+				t.Fatalf("expected a single updated record, got %v", records)
+			}
+
+			// the primary key can be used as conflict column as well
+			for _, name := range []string{"John", "Johnny"} {
+				if _, err := users.UpsertRecord(tx, []string{"id"}, map[string]any{"id": testId0, "email": "john@example.com", "name": name}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			record, err := tx.GetRecord("users", fields, testId0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["name"] != "Johnny" {
+				t.Fatalf("expected updated record, got %v", record)
+			}
+
+			// array values are bound element-wise when inserting
+			tagged, err := tx.UpsertRecord("users", fields, []string{"email"}, map[string]any{"email": "tagged@example.com", "name": "Tagged", "tags": []any{"a", "b"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record, err := tx.GetRecord("users", fields, tagged); err != nil || !reflect.DeepEqual(record["tags"], []any{"a", "b"}) {
+				t.Fatalf("expected tags [a b], got %v (%v)", record["tags"], err)
+			}
+
+			if _, err := tx.UpsertRecord("users", fields, []string{"name"}, map[string]any{"email": "x@example.com", "name": "Jane Doe"}); err == nil {
+				t.Error("expected error for conflict column without unique index")
+			}
+
+			if _, err := users.UpsertRecord(tx, []string{"name"}, map[string]any{"email": "x@example.com", "name": "Jane Doe"}); err == nil || !strings.Contains(err.Error(), "invalid conflict columns") {
+				t.Errorf("expected invalid conflict columns error, got %v", err)
+			}
+
+			if _, err := tx.UpsertRecord("users", fields, []string{"email"}, map[string]any{"name": "Nobody"}); err == nil {
+				t.Error("expected error for missing conflict value")
+			}
+		})
+	}
+}
+
+func TestUpsertRecordSerial(t *testing.T) {
+	codes := ldb.Collection{
+		Name: "codes",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSerial{PrimaryKey: true}}},
+				{Name: "code", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}, Unique: true}},
+				{Name: "label", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+			},
+		},
+	}
+
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := tx.SaveCollection(codes); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(codes)
+
+			id, err := tx.UpsertRecord("codes", fields, []string{"code"}, map[string]any{"code": "a", "label": "first"})
+			if err != nil || id != "1" {
+				t.Fatalf("expected id 1, got %q (%v)", id, err)
+			}
+
+			if again, err := tx.UpsertRecord("codes", fields, []string{"code"}, map[string]any{"code": "a", "label": "second"}); err != nil || again != id {
+				t.Fatalf("expected id %q of the existing record, got %q (%v)", id, again, err)
+			}
+
+			record, err := tx.GetRecord("codes", fields, id)
+			if err != nil || record["label"] != "second" {
+				t.Fatalf("expected updated record, got %v (%v)", record, err)
+			}
+
+			// DuckDB draws a value even if the insert conflicts, so only
+			// distinctness is checked
+			other, err := tx.UpsertRecord("codes", fields, []string{"code"}, map[string]any{"code": "b", "label": "other"})
+			if err != nil || other == "" || other == id {
+				t.Fatalf("expected a new id, got %q (%v)", other, err)
+			}
+		})
+	}
+}
+
+func TestUUIDPrimaryKey(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	tags := ldb.Collection{
+		Name: "tags",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeUUID{PrimaryKey: true}}},
+				{Name: "name", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+			},
+		},
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := tx.SaveCollection(tags); err != nil {
+				t.Fatal(err)
+			}
+
+			id, err := tx.CreateRecord("tags", fieldTypes(tags), map[string]any{"name": "go"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := tx.GetRecord("tags", fieldTypes(tags), strings.ToUpper(id))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["id"] != id || record["name"] != "go" {
+				t.Errorf("unexpected record %v", record)
+			}
+		})
+	}
+}
+
+func TestDecimalAndBytesFields(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	payments := ldb.Collection{
+		Name: "payments",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "amount", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeDecimal{Precision: 12, Scale: 2}}},
+				{Name: "receipt", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeBytes{Nullable: true}}},
+			},
+		},
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := tx.SaveCollection(payments); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("payments", fieldTypes(payments), map[string]any{"id": testId0, "amount": "1234567.1", "receipt": []byte{0, 1, 2}}); err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := tx.GetRecord("payments", fieldTypes(payments), testId0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["amount"] != "1234567.10" {
+				t.Errorf("expected amount 1234567.10, got %#v", record["amount"])
+			}
+
+			if receipt, ok := record["receipt"].([]byte); !ok || !slices.Equal(receipt, []byte{0, 1, 2}) {
+				t.Errorf("expected receipt [0 1 2], got %#v", record["receipt"])
+			}
+		})
+	}
+}
+
+func TestDurationField(t *testing.T) {
+	jobs := ldb.Collection{
+		Name: "jobs",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "timeout", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeDuration{Nullable: true}}},
+			},
+		},
+	}
+
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := tx.SaveCollection(jobs); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(jobs)
+			for _, timeout := range []any{"1h30m", 2 * time.Minute, nil} {
+				if _, err := tx.CreateRecord("jobs", fields, map[string]any{"timeout": timeout}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			records, _, err := tx.ListRecords("jobs", fields, ldb.ListOptions{
+				Filters: []ldb.Filter{{Field: "timeout", Operator: ldb.FilterGreater, Value: time.Hour}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != 1 || records[0]["timeout"] != 90*time.Minute {
+				t.Fatalf("expected a single record with a timeout of 90m, got %v", records)
+			}
+		})
+	}
+}
+
+func TestPointField(t *testing.T) {
+	places := ldb.Collection{
+		Name: "places",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "location", Schema: &ldb.FieldSchema{Type: ldb.FieldTypePoint{Nullable: true}}},
+			},
+		},
+	}
+
+	duckdbAdapter := openTestAdapter(t)
+	_, spatialErr := duckdbAdapter.DB().Exec("LOAD spatial")
+
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": duckdbAdapter,
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			err = tx.SaveCollection(places)
+			if name == "duckdb" && spatialErr != nil {
+				if err == nil || !strings.Contains(err.Error(), "spatial extension") {
+					t.Fatalf("expected setup error, got %v", err)
+				}
+
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(places)
+			id, err := tx.CreateRecord("places", fields, map[string]any{"location": [2]float64{52.52, 13.405}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := tx.GetRecord("places", fields, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if expected := (ldb.Point{Lat: 52.52, Lng: 13.405}); record["location"] != expected {
+				t.Fatalf("expected %v, got %v", expected, record["location"])
+			}
+		})
+	}
+}
+
+func TestArrayField(t *testing.T) {
+	posts := ldb.Collection{
+		Name: "posts",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "tags", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeArray{Element: ldb.FieldTypeText{Nullable: true}}}},
+				{Name: "scores", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeArray{Nullable: true, Element: ldb.FieldTypeArray{Element: ldb.FieldTypeDecimal{Precision: 5, Scale: 2}}}}},
+				{Name: "timeouts", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeArray{Nullable: true, Element: ldb.FieldTypeDuration{}}}},
+			},
+		},
+	}
+
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := tx.SaveCollection(posts); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(posts)
+			id, err := tx.CreateRecord("posts", fields, map[string]any{
+				"tags":     []string{"a, b", "\"c\"", ""},
+				"scores":   [][]string{{"1.5", "2"}, {}},
+				"timeouts": []any{"1m", nil},
+			})
+			if err == nil {
+				t.Fatal("expected error for null duration")
+			}
+
+			id, err = tx.CreateRecord("posts", fields, map[string]any{
+				"tags":     []any{"a, b", "\"c\"", nil},
+				"scores":   [][]string{{"1.5", "2"}, {}},
+				"timeouts": []any{"1m"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("posts", fields, map[string]any{"tags": []string{}}); err != nil {
+				t.Fatal(err)
+			}
+
+			records, _, err := tx.ListRecords("posts", fields, ldb.ListOptions{
+				Filters: []ldb.Filter{{Field: "tags", Operator: ldb.FilterEqual, Value: []any{"a, b", "\"c\"", nil}}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := map[string]any{
+				"id":       id,
+				"tags":     []any{"a, b", "\"c\"", nil},
+				"scores":   []any{[]any{"1.50", "2.00"}, []any{}},
+				"timeouts": []any{time.Minute},
+			}
+			if len(records) != 1 || !reflect.DeepEqual(records[0], expected) {
+				t.Fatalf("expected %v, got %v", expected, records)
+			}
+		})
+	}
+}
+
+func TestCreateRecords(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			ids, err := tx.CreateRecords("authors", fieldTypes(testAuthors), []map[string]any{
+				{"id": testId1, "name": "Jane"},
+				{"name": "John"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(ids) != 2 || ids[0] != testId1 || ldb.ValidateId(ids[1]) != nil {
+				t.Fatalf("unexpected ids %v", ids)
+			}
+
+			record, err := tx.GetRecord("authors", fieldTypes(testAuthors), ids[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["name"] != "John" {
+				t.Fatalf("unexpected record %v", record)
+			}
+
+			// invalid rows prevent all writes
+			_, err = tx.CreateRecords("authors", fieldTypes(testAuthors), []map[string]any{
+				{"id": testId2, "name": "Max"},
+				{"name": nil},
+			})
+			if err == nil || !strings.Contains(err.Error(), "row 1") {
+				t.Fatalf("expected error for row 1, got %v", err)
+			}
+
+			if _, err := tx.GetRecord("authors", fieldTypes(testAuthors), testId2); !errors.Is(err, ldb.ErrRecordNotFound) {
+				t.Fatalf("expected ErrRecordNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateRecordDefaultId(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			fields := fieldTypes(testAuthors)
+			fields["id"] = ldb.FieldTypeId{PrimaryKey: true, CreateDefaultValue: func() string { return testId2 }}
+
+			id, err := tx.CreateRecord("authors", fields, map[string]any{"name": "Jane"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if id != testId2 {
+				t.Fatalf("expected id from CreateDefaultValue, got %q", id)
+			}
+
+			record, err := tx.GetRecord("authors", fields, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["name"] != "Jane" {
+				t.Fatalf("unexpected record %v", record)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package ldb
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Reflection of existing databases into collections, e.g. to adopt ldb for a
+// legacy database. Column types are mapped to the closest field type; text and
+// UUID primary keys become the primary key of the collection, other primary
+// keys are kept as plain fields. Single column foreign keys become single
+// relations and junction tables created for multi relations are folded back
+// into the owning collection. Integer columns drawing their default from a
+// sequence become serial fields; other default values, views and indexes not
+// created for unique fields are not reflected.
+
+// implemented by adapters supporting ReflectCollections
+type reflectingAdapter interface {
+	// returns the columns of all tables ordered by table and column position
+	reflectColumns() ([]reflectedColumn, error)
+}
+
+// column of an existing table as reported by the database
+type reflectedColumn struct {
+	table string
+	name  string
+	// closest field type of the column type; nullability is applied later
+	fieldType  FieldType
+	nullable   bool
+	primaryKey bool
+	unique     bool
+	// whether the column draws its default from a sequence
+	serial bool
+	// referenced table if the column is a foreign key
+	references    string
+	onDelete      DeleteAction
+	cascadeUpdate bool
+}
+
+// ReflectCollections builds collections from the tables of the database. The
+// returned collections are forwarded, so that saving them only applies changes
+// made after reflection. Internal tables of ldb are skipped.
+func ReflectCollections(adapter DatabaseAdapter) ([]Collection, error) {
+	reflector, ok := adapter.(reflectingAdapter)
+	if !ok {
+		return nil, fmt.Errorf("adapter %T does not support reflection", adapter)
+	}
+
+	columns, err := reflector.reflectColumns()
+	if err != nil {
+		return nil, err
+	}
+
+	collections := []*Collection{}
+	junctionColumns := map[string][]reflectedColumn{}
+
+	for _, column := range columns {
+		if strings.HasPrefix(column.table, "_ldb_rel_") {
+			junctionColumns[column.table] = append(junctionColumns[column.table], column)
+			continue
+		}
+
+		if strings.HasPrefix(column.table, "_ldb_") {
+			continue
+		}
+
+		if len(collections) == 0 || collections[len(collections)-1].Name != column.table {
+			collections = append(collections, &Collection{Name: column.table, Schema: &CollectionSchema{Fields: []*Field{}}})
+		}
+
+		collection := collections[len(collections)-1]
+		collection.Schema.Fields = append(collection.Schema.Fields, &Field{
+			Name:   column.name,
+			Schema: &FieldSchema{Type: reflectedFieldType(column), Unique: column.unique && !column.primaryKey},
+		})
+	}
+
+	tables := lo.Keys(junctionColumns)
+	slices.Sort(tables)
+
+	for _, table := range tables {
+		target, ok := lo.Find(junctionColumns[table], func(column reflectedColumn) bool {
+			return column.name == "target" && column.references != ""
+		})
+		if !ok {
+			continue
+		}
+
+		// collection names may contain underscores, so the longest matching
+		// collection is used
+		var owner *Collection
+		for _, collection := range collections {
+			prefix, suffix := "_ldb_rel_"+collection.Name+"_", "_"+target.references
+			if len(table) > len(prefix)+len(suffix) && strings.HasPrefix(table, prefix) && strings.HasSuffix(table, suffix) {
+				if owner == nil || len(collection.Name) > len(owner.Name) {
+					owner = collection
+				}
+			}
+		}
+
+		if owner == nil {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(table, "_ldb_rel_"+owner.Name+"_"), "_"+target.references)
+		owner.Schema.Fields = append(owner.Schema.Fields, &Field{
+			Name:   name,
+			Schema: &FieldSchema{Type: FieldTypeMultiRelation{Collection: target.references, CascadeDelete: target.onDelete == DeleteCascade}},
+		})
+	}
+
+	return lo.Map(collections, func(collection *Collection, i int) Collection {
+		collection.Forward()
+		return *collection
+	}), nil
+}
+
+// returns the delete action of the given ON DELETE rule as reported by the
+// database
+func reflectedDeleteAction(rule string) DeleteAction {
+	switch rule {
+	case "CASCADE":
+		return DeleteCascade
+	case "SET NULL":
+		return DeleteSetNull
+	}
+
+	return DeleteNoAction
+}
+
+// returns the field type of the given column taking keys and nullability into account
+func reflectedFieldType(column reflectedColumn) FieldType {
+	if column.references != "" {
+		return FieldTypeSingleRelation{Nullable: column.nullable, Collection: column.references, OnDelete: column.onDelete, CascadeUpdate: column.cascadeUpdate}
+	}
+
+	if _, ok := column.fieldType.(FieldTypeInt); ok && column.serial {
+		return FieldTypeSerial{PrimaryKey: column.primaryKey}
+	}
+
+	switch ft := column.fieldType.(type) {
+	case FieldTypeBool:
+		ft.Nullable = column.nullable
+		return ft
+
+	case FieldTypeBytes:
+		ft.Nullable = column.nullable
+		return ft
+
+	case FieldTypeDateTime:
+		ft.Nullable = column.nullable
+		return ft
+
+	case FieldTypeDecimal:
+		ft.Nullable = column.nullable
+		return ft
+
+	case FieldTypeEnum:
+		ft.Nullable = column.nullable
+		return ft
+
+	case FieldTypeFloat:
+		ft.Nullable = column.nullable
+		return ft
+
+	case FieldTypeInt:
+		ft.Nullable = column.nullable
+		return ft
+
+	case FieldTypeJSON:
+		ft.Nullable = column.nullable
+		return ft
+
+	case FieldTypeText:
+		if column.primaryKey {
+			return FieldTypeId{PrimaryKey: true}
+		}
+
+		ft.Nullable = column.nullable
+		return ft
+
+	case FieldTypeUUID:
+		ft.PrimaryKey = column.primaryKey
+		ft.Nullable = column.nullable && !column.primaryKey
+		return ft
+
+	default:
+		return column.fieldType
+	}
+}
+
+// parses the values of an enum type like ENUM('a', 'b')
+func parseEnumValues(dataType string) ([]string, error) {
+	inner, ok := strings.CutPrefix(dataType, "ENUM(")
+	if !ok || !strings.HasSuffix(inner, ")") {
+		return nil, fmt.Errorf("invalid enum type %q", dataType)
+	}
+
+	inner = strings.TrimSuffix(inner, ")")
+
+	values := []string{}
+	for len(inner) > 0 {
+		inner = strings.TrimLeft(inner, ", ")
+		if !strings.HasPrefix(inner, "'") {
+			return nil, fmt.Errorf("invalid enum type %q", dataType)
+		}
+
+		// quotes within values are doubled
+		var value strings.Builder
+		i := 1
+		for ; i < len(inner); i++ {
+			if inner[i] == '\'' {
+				if i+1 < len(inner) && inner[i+1] == '\'' {
+					i++
+				} else {
+					break
+				}
+			}
+
+			value.WriteByte(inner[i])
+		}
+
+		if i >= len(inner) {
+			return nil, fmt.Errorf("invalid enum type %q", dataType)
+		}
+
+		values = append(values, value.String())
+		inner = strings.TrimLeft(inner[i+1:], ", ")
+	}
+
+	return values, nil
+}
@@ -0,0 +1,101 @@
+package ldb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestReflectCollections(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	books := ldb.Collection{
+		Name: "books",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "isbn", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}, Unique: true}},
+				{Name: "price", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeDecimal{Nullable: true, Precision: 10, Scale: 2}}},
+				{Name: "pages", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{}}},
+				{Name: "format", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeEnum{EnumValues: []string{"hard", "it's soft"}, Storage: ldb.EnumStorageNative}}},
+				{Name: "main_author", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Nullable: true, Collection: "authors"}}},
+				{Name: "co_authors", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeMultiRelation{Collection: "authors"}}},
+			},
+		},
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, collection := range []ldb.Collection{testAuthors, books} {
+				if err := tx.SaveCollection(collection); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			collections, err := ldb.ReflectCollections(adapter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(collections) != 2 || collections[0].Name != "authors" || collections[1].Name != "books" {
+				t.Fatalf("unexpected collections %v", collections)
+			}
+
+			// decimals and enums are stored as TEXT by SQLite
+			expected := map[string]ldb.FieldType{
+				"id":          ldb.FieldTypeId{PrimaryKey: true},
+				"isbn":        ldb.FieldTypeText{},
+				"price":       ldb.FieldTypeDecimal{Nullable: true, Precision: 10, Scale: 2},
+				"pages":       ldb.FieldTypeInt{},
+				"format":      ldb.FieldTypeEnum{EnumValues: []string{"hard", "it's soft"}, Storage: ldb.EnumStorageNative},
+				"main_author": ldb.FieldTypeSingleRelation{Nullable: true, Collection: "authors"},
+				"co_authors":  ldb.FieldTypeMultiRelation{Collection: "authors"},
+			}
+			if name == "sqlite" {
+				expected["price"] = ldb.FieldTypeText{Nullable: true}
+				expected["format"] = ldb.FieldTypeText{}
+			}
+
+			reflected := collections[1]
+			if fields := reflected.Fields(); !reflect.DeepEqual(fields, expected) {
+				t.Fatalf("unexpected fields %v", fields)
+			}
+
+			if !reflected.Schema.Fields[1].Schema.Unique || reflected.Schema.Fields[2].Schema.Unique {
+				t.Fatal("expected only isbn to be unique")
+			}
+
+			// reflected collections are forwarded and can be changed
+			reflected.Schema.Fields = append(reflected.Schema.Fields, &ldb.Field{Name: "title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{Nullable: true}}})
+
+			tx, err = adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := tx.SaveCollection(reflected); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("books", reflected.Fields(), map[string]any{"isbn": "1", "pages": 10, "format": "hard", "title": "x"}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package ldb
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/samber/lo"
+)
+
+// Schema holds the collections of an app and ensures that relations point at
+// collections of the same schema.
+type Schema struct {
+	collections []Collection
+}
+
+func NewSchema(collections ...Collection) *Schema {
+	return &Schema{collections: slices.Clone(collections)}
+}
+
+// adds the given collection; duplicate names are reported by Validate
+func (s *Schema) Add(collection Collection) {
+	s.collections = append(s.collections, collection)
+}
+
+// returns the collection with the given name
+func (s *Schema) Collection(name string) (Collection, bool) {
+	for _, collection := range s.collections {
+		if collection.Name == name {
+			return collection, true
+		}
+	}
+
+	return Collection{}, false
+}
+
+// ensures that collection names are unique, that all relations point at
+// collections of the schema having an id primary key and that relations
+// between different collections do not form a cycle
+func (s *Schema) Validate() error {
+	for _, collection := range s.collections {
+		if err := ValidateIdentifier(collection.Name); err != nil {
+			return fmt.Errorf("collection %q: %w", collection.Name, err)
+		}
+	}
+
+	for _, collection := range s.collections {
+		for _, field := range collection.Schema.Fields {
+			target, ok := relationTarget(field.Schema.Type)
+			if !ok {
+				continue
+			}
+
+			if err := s.validateRelationTarget(field.Name, target); err != nil {
+				return fmt.Errorf("collection %q: %w", collection.Name, err)
+			}
+		}
+	}
+
+	_, err := sortCollections(s.collections)
+	return err
+}
+
+// ensures that the target of a relation exists and that its primary key is
+// the id field referenced by foreign keys
+func (s *Schema) validateRelationTarget(field string, target string) error {
+	collection, ok := s.Collection(target)
+	if !ok {
+		return fmt.Errorf("configuration error, relation field %q references unknown collection %q", field, target)
+	}
+
+	if primaryKey := collection.Schema.field("id"); primaryKey != nil {
+		if ft, ok := primaryKey.Schema.Type.(FieldTypeId); ok && ft.PrimaryKey {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("configuration error, relation field %q references collection %q without id primary key", field, target)
+}
+
+// returns the collections ordered so that referenced collections come before
+// the collections referencing them; otherwise the order of addition is kept.
+// Collections that are part of a cycle are omitted; returns nil if collection
+// names are not unique.
+func (s *Schema) OrderedCollections() []Collection {
+	ordered, _ := sortCollections(s.collections)
+	return ordered
+}
+
+// validates the schema and saves its collections in dependency order
+func (s *Schema) Save(tx DatabaseTransaction) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	return SaveCollections(tx, s.collections)
+}
+
+// saves the given collections ordered by their relations, so that referenced
+// tables are created first; relations to collections not given are expected to
+// target existing tables
+func SaveCollections(tx DatabaseTransaction, collections []Collection) error {
+	ordered, err := sortCollections(collections)
+	if err != nil {
+		return err
+	}
+
+	for _, collection := range ordered {
+		if err := tx.SaveCollection(collection); err != nil {
+			return fmt.Errorf("collection %q: %w", collection.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// drops the collections of the schema with the given names in reverse
+// dependency order. Fails if other collections of the schema reference them,
+// unless cascade is set, in which case the referencing collections are
+// dropped as well.
+func (s *Schema) Drop(tx DatabaseTransaction, cascade bool, names ...string) error {
+	drop := map[string]bool{}
+	for _, name := range names {
+		if _, ok := s.Collection(name); !ok {
+			return fmt.Errorf("unknown collection %q", name)
+		}
+
+		drop[name] = true
+	}
+
+	// adds referencing collections until no further collections are added
+	for changed := true; changed; {
+		changed = false
+
+		for _, collection := range s.collections {
+			if drop[collection.Name] {
+				continue
+			}
+
+			for _, field := range collection.Schema.Fields {
+				target, ok := relationTarget(field.Schema.Type)
+				if !ok || !drop[target] {
+					continue
+				}
+
+				if !cascade {
+					return fmt.Errorf("collection %q is referenced by collection %q", target, collection.Name)
+				}
+
+				drop[collection.Name] = true
+				changed = true
+				break
+			}
+		}
+	}
+
+	collections := slices.DeleteFunc(slices.Clone(s.collections), func(collection Collection) bool {
+		return !drop[collection.Name]
+	})
+
+	return DropCollections(tx, collections)
+}
+
+// drops the given collections in reverse dependency order, so that referencing
+// tables are dropped before the tables they reference
+func DropCollections(tx DatabaseTransaction, collections []Collection) error {
+	ordered, err := sortCollections(collections)
+	if err != nil {
+		return err
+	}
+
+	for _, collection := range lo.Reverse(ordered) {
+		if err := tx.DropCollection(collection); err != nil {
+			return fmt.Errorf("collection %q: %w", collection.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// orders the given collections so that referenced collections come first while
+// keeping the given order otherwise; self references and references to other
+// collections are ignored. Returns the collections that could be ordered and
+// an error if names are not unique or relations form a cycle.
+func sortCollections(collections []Collection) ([]Collection, error) {
+	given := map[string]bool{}
+	for _, collection := range collections {
+		if given[collection.Name] {
+			return nil, fmt.Errorf("configuration error, duplicate collection %q", collection.Name)
+		}
+
+		given[collection.Name] = true
+	}
+
+	ordered := []Collection{}
+	added := map[string]bool{}
+
+	// repeatedly adds the first collection whose references are all added
+	for len(ordered) < len(collections) {
+		next := slices.IndexFunc(collections, func(collection Collection) bool {
+			if added[collection.Name] {
+				return false
+			}
+
+			return !slices.ContainsFunc(collection.Schema.Fields, func(field *Field) bool {
+				target, ok := relationTarget(field.Schema.Type)
+				return ok && target != collection.Name && given[target] && !added[target]
+			})
+		})
+
+		if next < 0 {
+			pending := []string{}
+			for _, collection := range collections {
+				if !added[collection.Name] {
+					pending = append(pending, collection.Name)
+				}
+			}
+
+			return ordered, fmt.Errorf("configuration error, cyclic relations between collections %q", pending)
+		}
+
+		ordered = append(ordered, collections[next])
+		added[collections[next].Name] = true
+	}
+
+	return ordered, nil
+}
+
+// returns the collection referenced by the given field type
+func relationTarget(fieldType FieldType) (string, bool) {
+	switch ft := fieldType.(type) {
+	case FieldTypeSingleRelation:
+		return ft.Collection, true
+	case FieldTypeMultiRelation:
+		return ft.Collection, true
+	default:
+		return "", false
+	}
+}
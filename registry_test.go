@@ -0,0 +1,191 @@
+package ldb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"lehnert.dev/ldb"
+)
+
+func TestSchema(t *testing.T) {
+	comments := ldb.Collection{
+		Name: "comments",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "post", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Collection: "posts"}}},
+				{Name: "parent", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Nullable: true, Collection: "comments"}}},
+			},
+		},
+	}
+
+	schema := ldb.NewSchema(comments, testPosts)
+	schema.Add(testAuthors)
+
+	if err := schema.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	names := lo.Map(schema.OrderedCollections(), func(collection ldb.Collection, i int) string {
+		return collection.Name
+	})
+
+	if strings.Join(names, ",") != "authors,posts,comments" {
+		t.Fatalf("unexpected order %v", names)
+	}
+
+	if err := ldb.NewSchema(testPosts).Validate(); err == nil || !strings.Contains(err.Error(), `relation field "author" references unknown collection "authors"`) {
+		t.Fatalf("expected unknown collection error, got %v", err)
+	}
+
+	keyless := ldb.Collection{
+		Name: "authors",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeUUID{PrimaryKey: true}}},
+			},
+		},
+	}
+
+	if err := ldb.NewSchema(keyless, testPosts).Validate(); err == nil || !strings.Contains(err.Error(), "without id primary key") {
+		t.Fatalf("expected primary key error, got %v", err)
+	}
+
+	if err := ldb.NewSchema(testAuthors, testAuthors).Validate(); err == nil || !strings.Contains(err.Error(), "duplicate collection") {
+		t.Fatalf("expected duplicate collection error, got %v", err)
+	}
+
+	authors := *testAuthors.Clone()
+	authors.Schema.Fields = append(authors.Schema.Fields, &ldb.Field{
+		Name:   "favorite",
+		Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Nullable: true, Collection: "posts"}},
+	})
+
+	if err := ldb.NewSchema(authors, testPosts).Validate(); err == nil || !strings.Contains(err.Error(), "cyclic") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := schema.Save(tx); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestSaveCollections(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			// posts reference authors, which therefore must be created first
+			if err := ldb.SaveCollections(tx, []ldb.Collection{testPosts, testAuthors}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("posts", fieldTypes(testPosts), map[string]any{"title": "a", "author": testId0}); err != nil {
+				t.Fatal(err)
+			}
+
+			authors := *testAuthors.Clone()
+			authors.Name = "writers"
+			authors.Schema.Fields = append(authors.Schema.Fields, &ldb.Field{
+				Name:   "favorite",
+				Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Nullable: true, Collection: "articles"}},
+			})
+
+			articles := *testPosts.Clone()
+			articles.Name = "articles"
+			articles.Schema.Fields[len(articles.Schema.Fields)-1].Schema.Type = ldb.FieldTypeSingleRelation{Collection: "writers"}
+
+			err = ldb.SaveCollections(tx, []ldb.Collection{authors, articles})
+			if err == nil || !strings.Contains(err.Error(), "cyclic") {
+				t.Fatalf("expected cycle error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDropCollections(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			if _, err := tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("posts", fieldTypes(testPosts), map[string]any{"title": "a", "author": testId0}); err != nil {
+				t.Fatal(err)
+			}
+
+			authors, posts := *testAuthors.Clone(), *testPosts.Clone()
+			authors.Forward()
+			posts.Forward()
+
+			// posts reference authors, which therefore must be dropped last
+			if err := ldb.DropCollections(tx, []ldb.Collection{authors, posts}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ldb.SaveCollections(tx, []ldb.Collection{testAuthors, testPosts}); err != nil {
+				t.Fatal(err)
+			}
+
+			schema := ldb.NewSchema(authors, posts)
+			if err := schema.Drop(tx, false, "authors"); err == nil || !strings.Contains(err.Error(), "referenced") {
+				t.Fatalf("expected reference error, got %v", err)
+			}
+
+			if err := schema.Drop(tx, true, "authors"); err != nil {
+				t.Fatal(err)
+			}
+
+			// both tables have been dropped, so they can be created again
+			if err := ldb.SaveCollections(tx, []ldb.Collection{testAuthors, testPosts}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
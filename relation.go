@@ -0,0 +1,179 @@
+package ldb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Multi relations are not stored as columns of the owning collection but in
+// junction tables, one per multi relation field. Each row of a junction table
+// links a record of the owning collection (source) to a record of the target
+// collection (target).
+
+// returns the name of the junction table of a multi relation field;
+// the name is derived from the owning collection, the field and the target
+func junctionTableName(collection string, field string, target string) string {
+	return fmt.Sprintf("_ldb_rel_%s_%s_%s", collection, field, target)
+}
+
+func isMultiRelation(fieldType FieldType) bool {
+	_, ok := fieldType.(FieldTypeMultiRelation)
+	return ok
+}
+
+// returns the sorted names of all fields stored as columns
+func columnFieldNames(fields map[string]FieldType) []string {
+	return lo.Filter(sortedFieldNames(fields), func(name string, i int) bool {
+		return !isMultiRelation(fields[name])
+	})
+}
+
+// returns the sorted names of all multi relation fields
+func multiRelationFieldNames(fields map[string]FieldType) []string {
+	return lo.Filter(sortedFieldNames(fields), func(name string, i int) bool {
+		return isMultiRelation(fields[name])
+	})
+}
+
+// computes the statements required to keep the junction tables in sync with
+// the multi relation fields of the collection; createSQL returns the
+// statement creating a junction table for the given owning collection
+func junctionStatements(dialect Dialect, collection Collection, createSQL func(table string, collection string, ft FieldTypeMultiRelation) (string, error)) ([]string, error) {
+	statements := []string{}
+
+	if collection.original != nil {
+		for _, origField := range collection.original.Schema.Fields {
+			ft, ok := origField.Schema.Type.(FieldTypeMultiRelation)
+			if !ok {
+				continue
+			}
+
+			kept := lo.ContainsBy(collection.Schema.Fields, func(field *Field) bool {
+				return field.original != nil && field.original.Name == origField.Name
+			})
+
+			if !kept {
+				table := junctionTableName(collection.original.Name, origField.Name, ft.Collection)
+				statements = append(statements, fmt.Sprintf("DROP TABLE IF EXISTS %s", dialect.Quote(table)))
+			}
+		}
+	}
+
+	for _, field := range collection.Schema.Fields {
+		ft, ok := field.Schema.Type.(FieldTypeMultiRelation)
+		if !ok {
+			continue
+		}
+
+		table := junctionTableName(collection.Name, field.Name, ft.Collection)
+
+		var origFt FieldTypeMultiRelation
+		isNew := collection.original == nil || field.original == nil
+		if !isNew {
+			origFt, ok = field.original.Schema.Type.(FieldTypeMultiRelation)
+			isNew = !ok
+		}
+
+		if isNew {
+			sql, err := createSQL(table, collection.Name, ft)
+			if err != nil {
+				return nil, err
+			}
+
+			statements = append(statements, sql)
+			continue
+		}
+
+		origTable := junctionTableName(collection.original.Name, field.original.Name, origFt.Collection)
+		if origTable != table {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s RENAME TO %s", dialect.Quote(origTable), dialect.Quote(table)))
+		}
+	}
+
+	return statements, nil
+}
+
+// returns the statements dropping all junction tables of the collection
+func dropJunctionStatements(dialect Dialect, collection Collection) []string {
+	statements := []string{}
+
+	for _, field := range collection.Schema.Fields {
+		if ft, ok := field.Schema.Type.(FieldTypeMultiRelation); ok {
+			table := junctionTableName(collection.Name, field.Name, ft.Collection)
+			statements = append(statements, fmt.Sprintf("DROP TABLE IF EXISTS %s", dialect.Quote(table)))
+		}
+	}
+
+	return statements
+}
+
+// replaces the targets linked to the given record via the given field
+func saveRelations(tx contextTx, collection string, field string, ft FieldTypeMultiRelation, id string, targets []string) error {
+	table := tx.dialect.Quote(junctionTableName(collection, field, ft.Collection))
+
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE source = ?", table), id); err != nil {
+		return err
+	}
+
+	if len(targets) == 0 {
+		return nil
+	}
+
+	placeholders := []string{}
+	args := []any{}
+	for _, target := range targets {
+		placeholders = append(placeholders, "(?, ?)")
+		args = append(args, id, target)
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (source, target) VALUES %s", table, strings.Join(placeholders, ", "))
+	_, err := tx.Exec(sql, args...)
+	return err
+}
+
+// loads the targets linked to the given record for all multi relation fields
+func loadRelations(tx contextTx, collection string, fields map[string]FieldType, id string, record map[string]any) error {
+	for _, name := range multiRelationFieldNames(fields) {
+		ft := fields[name].(FieldTypeMultiRelation)
+		table := tx.dialect.Quote(junctionTableName(collection, name, ft.Collection))
+
+		rows, err := tx.Query(fmt.Sprintf("SELECT target FROM %s WHERE source = ? ORDER BY target", table), id)
+		if err != nil {
+			return err
+		}
+
+		targets := []string{}
+		for rows.Next() {
+			var target string
+			if err := rows.Scan(&target); err != nil {
+				rows.Close()
+				return err
+			}
+
+			targets = append(targets, target)
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		record[name] = targets
+	}
+
+	return nil
+}
+
+// removes all links of the given record
+func deleteRelations(tx contextTx, collection string, fields map[string]FieldType, id string) error {
+	for _, name := range multiRelationFieldNames(fields) {
+		ft := fields[name].(FieldTypeMultiRelation)
+		if err := saveRelations(tx, collection, name, ft, id, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package ldb_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestMultiRelation(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			authors := *testAuthors.Clone()
+			books := ldb.Collection{
+				Name: "books",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "authors", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeMultiRelation{Collection: "authors"}}},
+					},
+				},
+			}
+
+			for _, collection := range []*ldb.Collection{&authors, &books} {
+				if err := tx.SaveCollection(*collection); err != nil {
+					t.Fatal(err)
+				}
+
+				collection.Forward()
+			}
+
+			for _, id := range []string{testId0, testId1} {
+				if _, err := tx.CreateRecord("authors", fieldTypes(authors), map[string]any{"id": id, "name": id}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, err := tx.CreateRecord("books", fieldTypes(books), map[string]any{"id": testId2, "authors": []string{testId1, testId0, testId1}}); err != nil {
+				t.Fatal(err)
+			}
+
+			expectAuthors := func(field string, expected []string) {
+				t.Helper()
+
+				record, err := tx.GetRecord("books", fieldTypes(books), testId2)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !slices.Equal(record[field].([]string), expected) {
+					t.Fatalf("expected %s %v, got %v", field, expected, record[field])
+				}
+			}
+
+			expectAuthors("authors", []string{testId0, testId1})
+
+			if err := tx.UpdateRecord("books", fieldTypes(books), testId2, map[string]any{"authors": []any{testId1}}); err != nil {
+				t.Fatal(err)
+			}
+
+			expectAuthors("authors", []string{testId1})
+
+			if _, err := tx.CreateRecord("books", fieldTypes(books), map[string]any{"authors": []string{"invalid"}}); err == nil {
+				t.Fatal("expected error for invalid id")
+			}
+
+			// links are kept when the field is renamed
+			books.Schema.Fields[1].Name = "writers"
+			if err := tx.SaveCollection(books); err != nil {
+				t.Fatal(err)
+			}
+
+			books.Forward()
+			expectAuthors("writers", []string{testId1})
+
+			records, _, err := tx.ListRecords("books", fieldTypes(books), ldb.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != 1 || !slices.Equal(records[0]["writers"].([]string), []string{testId1}) {
+				t.Fatalf("unexpected records %v", records)
+			}
+
+			if err := tx.DeleteRecord("books", fieldTypes(books), testId2); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.GetRecord("books", fieldTypes(books), testId2); !errors.Is(err, ldb.ErrRecordNotFound) {
+				t.Fatalf("expected ErrRecordNotFound, got %v", err)
+			}
+
+			// removing the field drops the junction table
+			books.Schema.Fields = books.Schema.Fields[:1]
+			if err := tx.SaveCollection(books); err != nil {
+				t.Fatal(err)
+			}
+
+			books.Forward()
+			if err := tx.DropCollection(books); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestSelfRelation(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			categories := ldb.Collection{
+				Name: "categories",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "parent", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Nullable: true, Collection: "categories"}}},
+					},
+				},
+			}
+
+			if err := tx.SaveCollection(categories); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(categories)
+			if _, err := tx.CreateRecord("categories", fields, map[string]any{"id": testId0}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("categories", fields, map[string]any{"id": testId1, "parent": testId0}); err != nil {
+				t.Fatal(err)
+			}
+
+			children, _, err := tx.ListRecords("categories", fields, ldb.ListOptions{
+				Filters: []ldb.Filter{{Field: "parent", Operator: ldb.FilterEqual, Value: testId0}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(children) != 1 || children[0]["id"] != testId1 {
+				t.Fatalf("expected a single child, got %v", children)
+			}
+
+			// adding a field with a CHECK constraint rebuilds the table
+			categories.Forward()
+			categories.Schema.Fields = append(categories.Schema.Fields, &ldb.Field{
+				Name: "position",
+				Schema: &ldb.FieldSchema{DatabaseCheck: true, Type: ldb.FieldTypeInt{
+					Nullable:       true,
+					CreateMinValue: func() int64 { return 0 },
+				}},
+			})
+
+			err = tx.SaveCollection(categories)
+			if name == "duckdb" {
+				if err == nil || !strings.Contains(err.Error(), "references itself") {
+					t.Fatalf("expected rebuild error, got %v", err)
+				}
+
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			fields = fieldTypes(categories)
+			if record, err := tx.GetRecord("categories", fields, testId1); err != nil || record["parent"] != testId0 {
+				t.Fatalf("expected parent to be kept, got %v (%v)", record, err)
+			}
+
+			if _, err := tx.CreateRecord("categories", fields, map[string]any{"parent": testId2}); err == nil {
+				t.Fatal("expected error for unknown parent")
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package ldb
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type DropAllOptions struct {
+	// also drops internal tables of ldb that belong to no collection, e.g.
+	// junction tables whose owning collection has been removed by hand
+	DropUnknownTables bool
+}
+
+// DropAll drops all views and collections of the database together with the
+// migration history within a single transaction, e.g. to tear down the
+// database of an integration test. THIS IS DESTRUCTIVE: all records are
+// deleted and cannot be restored.
+//
+// Collections are found by reflection and dropped in reverse dependency
+// order. Internal tables other than the migration history and the junction
+// tables of the collections are kept unless DropUnknownTables is set.
+func DropAll(adapter DatabaseAdapter, opts DropAllOptions) error {
+	reflector, ok := adapter.(reflectingAdapter)
+	if !ok {
+		return fmt.Errorf("adapter %T does not support reflection", adapter)
+	}
+
+	collections, err := ReflectCollections(adapter)
+	if err != nil {
+		return err
+	}
+
+	ordered, err := sortCollections(collections)
+	if err != nil {
+		return err
+	}
+
+	columns, err := reflector.reflectColumns()
+	if err != nil {
+		return err
+	}
+
+	known := []string{migrationTableName}
+	for _, collection := range collections {
+		for _, name := range multiRelationFieldNames(collection.Fields()) {
+			ft := collection.Schema.field(name).Schema.Type.(FieldTypeMultiRelation)
+			known = append(known, junctionTableName(collection.Name, name, ft.Collection))
+		}
+	}
+
+	unknown := lo.Uniq(lo.FilterMap(columns, func(column reflectedColumn, i int) (string, bool) {
+		return column.table, strings.HasPrefix(column.table, "_ldb_") && !slices.Contains(known, column.table)
+	}))
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := dropAll(tx, ordered, unknown, opts); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// drops the views, the given unknown internal tables if requested, the given
+// collections in reverse order and the migration history
+func dropAll(tx DatabaseTransaction, collections []Collection, unknown []string, opts DropAllOptions) error {
+	views, err := tx.ListViews()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range views {
+		view := View{Name: name}
+		view.Forward()
+
+		if err := tx.DropView(view); err != nil {
+			return err
+		}
+	}
+
+	tables := []Collection{}
+	if opts.DropUnknownTables {
+		// unknown tables may reference collections, so they are dropped first
+		for _, name := range unknown {
+			tables = append(tables, Collection{Name: name, Schema: &CollectionSchema{}})
+		}
+	}
+
+	tables = append(tables, lo.Reverse(collections)...)
+	tables = append(tables, Collection{Name: migrationTableName, Schema: &CollectionSchema{}})
+
+	for _, table := range tables {
+		table.Forward()
+
+		if err := tx.DropCollection(table); err != nil {
+			return fmt.Errorf("dropping %s: %w", table.Name, err)
+		}
+	}
+
+	return nil
+}
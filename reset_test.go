@@ -0,0 +1,102 @@
+package ldb_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestDropAll(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	tickets := ldb.Collection{
+		Name: "tickets",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSerial{PrimaryKey: true}}},
+				{Name: "post", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Collection: "posts"}}},
+				{Name: "watchers", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeMultiRelation{Collection: "authors"}}},
+			},
+		},
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			db := adapter.(interface{ DB() *sql.DB }).DB()
+
+			// saves the test collections, the tickets and a view
+			setup := func() {
+				tx := beginTestRecords(t, adapter)
+
+				if err := tx.SaveCollection(tickets); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := tx.SaveView(ldb.View{Name: "titles", Schema: ldb.ViewSchema{Query: `SELECT title FROM posts`}}); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := tx.FinishMigration("0001_init", ""); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := tx.Commit(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			setup()
+
+			if _, err := db.Exec(`CREATE TABLE _ldb_custom (id INTEGER)`); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ldb.DropAll(adapter, ldb.DropAllOptions{}); err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			collections, err := tx.ListCollections()
+			if err != nil || len(collections) != 0 {
+				t.Fatalf("expected no collections, got %v (%v)", collections, err)
+			}
+
+			views, err := tx.ListViews()
+			if err != nil || len(views) != 0 {
+				t.Fatalf("expected no views, got %v (%v)", views, err)
+			}
+
+			if version, err := tx.CurrentSchemaVersion(); err != nil || version != 0 {
+				t.Fatalf("expected empty migration history, got version %d (%v)", version, err)
+			}
+
+			tx.Rollback()
+
+			// unknown internal tables are kept
+			if _, err := db.Exec(`SELECT * FROM _ldb_custom`); err != nil {
+				t.Fatalf("expected unknown table to be kept, got %v", err)
+			}
+
+			// sequences are dropped with their collection
+			setup()
+
+			if err := ldb.DropAll(adapter, ldb.DropAllOptions{DropUnknownTables: true}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := db.Exec(`SELECT * FROM _ldb_custom`); err == nil {
+				t.Fatal("expected unknown table to be dropped")
+			}
+		})
+	}
+}
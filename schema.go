@@ -1,11 +1,25 @@
 package ldb
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"reflect"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/samber/lo"
 )
 
 type Forwardable interface {
@@ -19,6 +33,7 @@ type Clonable[T any] interface {
 // ensure interface implementation
 var _ Forwardable = (*Collection)(nil)
 var _ Forwardable = (*Field)(nil)
+var _ Forwardable = (*View)(nil)
 var _ Clonable[*Collection] = Collection{}
 var _ Clonable[*CollectionSchema] = CollectionSchema{}
 var _ Clonable[*Field] = Field{}
@@ -32,6 +47,19 @@ var _ FieldType = FieldTypeBool{}
 var _ FieldType = FieldTypeDateTime{}
 var _ FieldType = FieldTypeEnum{}
 var _ FieldType = FieldTypeSingleRelation{}
+var _ FieldType = FieldTypeJSON{}
+var _ FieldType = FieldTypeUUID{}
+var _ FieldType = FieldTypeDecimal{}
+var _ FieldType = FieldTypeBytes{}
+var _ FieldType = FieldTypeMultiRelation{}
+var _ FieldType = FieldTypeEmail{}
+var _ FieldType = FieldTypeURL{}
+var _ FieldType = FieldTypeIP{}
+var _ FieldType = FieldTypeDuration{}
+var _ FieldType = FieldTypePoint{}
+var _ FieldType = FieldTypeArray{}
+var _ FieldType = FieldTypeComputed{}
+var _ FieldType = FieldTypeSerial{}
 
 type Collection struct {
 	// collection data on last migration; useful for detecting schema changes
@@ -39,6 +67,10 @@ type Collection struct {
 
 	Name   string
 	Schema *CollectionSchema
+
+	// whether null values of fields becoming non-nullable are replaced by
+	// their default value; set by WithNullBackfill for a single save
+	backfillNulls bool
 }
 
 func (c *Collection) Forward() {
@@ -56,12 +88,316 @@ func (c Collection) Clone() *Collection {
 	return &cloned
 }
 
+// describes the collection like a table definition with one line per field
+// and index, e.g. for debugging
+func (c Collection) String() string {
+	var b strings.Builder
+	b.WriteString(c.Name + " (\n")
+
+	if c.Schema != nil {
+		for _, field := range c.Schema.Fields {
+			b.WriteString("\t" + field.String() + "\n")
+		}
+
+		if len(c.Schema.PrimaryKey) > 0 {
+			b.WriteString("\tprimaryKey(" + strings.Join(c.Schema.PrimaryKey, ", ") + ")\n")
+		}
+
+		for _, columns := range c.Schema.UniqueConstraints {
+			b.WriteString("\tunique(" + strings.Join(columns, ", ") + ")\n")
+		}
+
+		for _, index := range c.Schema.Indexes {
+			b.WriteString("\tindex " + index.Name + "(" + strings.Join(index.Columns, ", ") + ")")
+			if index.Unique {
+				b.WriteString(" unique")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString(")")
+
+	if c.Schema != nil {
+		if c.Schema.Timestamps {
+			b.WriteString(" timestamps")
+		}
+		if c.Schema.Versioned {
+			b.WriteString(" versioned")
+		}
+	}
+
+	return b.String()
+}
+
+// reports whether other has the same name, equal fields and indexes in the
+// same order and the same options; access control callbacks are ignored
+func (c Collection) Equal(other Collection) bool {
+	if c.Name != other.Name {
+		return false
+	}
+
+	if c.Schema == nil || other.Schema == nil {
+		return c.Schema == nil && other.Schema == nil
+	}
+
+	equalFields := slices.EqualFunc(c.Schema.Fields, other.Schema.Fields, func(a, b *Field) bool {
+		return a.Equal(*b)
+	})
+
+	equalIndexes := slices.EqualFunc(c.Schema.Indexes, other.Schema.Indexes, func(a, b IndexSchema) bool {
+		return a.Name == b.Name && a.Unique == b.Unique && slices.Equal(a.Columns, b.Columns)
+	})
+
+	equalConstraints := slices.EqualFunc(c.Schema.UniqueConstraints, other.Schema.UniqueConstraints, func(a, b []string) bool {
+		return slices.Equal(a, b)
+	})
+
+	return equalFields && equalIndexes && equalConstraints && slices.Equal(c.Schema.PrimaryKey, other.Schema.PrimaryKey) &&
+		c.Schema.Timestamps == other.Schema.Timestamps && c.Schema.Versioned == other.Schema.Versioned
+}
+
+// returns the field with the given name
+func (s CollectionSchema) field(name string) *Field {
+	field, _ := lo.Find(s.Fields, func(field *Field) bool {
+		return field.Name == name
+	})
+
+	return field
+}
+
+// computes the columns to create, rename and remove since the last migration
+// based on DiffCollection; multi relations are not stored as columns and
+// therefore not included
+func (c Collection) fieldChanges() (createFields, renameFields, removeFields []*Field) {
+	diff := DiffCollection(c.original, &c)
+
+	isColumn := func(field *Field, i int) bool {
+		return !isMultiRelation(field.Schema.Type)
+	}
+
+	createFields = lo.Filter(lo.Map(diff.AddedFields, func(name string, i int) *Field {
+		return c.Schema.field(name)
+	}), isColumn)
+
+	renameFields = lo.Filter(lo.Map(diff.RenamedFields, func(rename FieldRename, i int) *Field {
+		return c.Schema.field(rename.To)
+	}), isColumn)
+
+	removeFields = []*Field{}
+	if c.original != nil {
+		removeFields = lo.Filter(lo.Map(diff.RemovedFields, func(name string, i int) *Field {
+			return c.original.Schema.field(name)
+		}), isColumn)
+	}
+
+	return createFields, renameFields, removeFields
+}
+
+// returns the fields whose column type in the given dialect changed since the
+// last migration
+func (c Collection) retypedFields(dialect Dialect) []*Field {
+	diff := DiffCollection(c.original, &c)
+
+	fields := lo.Map(diff.RetypedFields, func(name string, i int) *Field {
+		return c.Schema.field(name)
+	})
+
+	return lo.Filter(fields, func(field *Field, i int) bool {
+		if isMultiRelation(field.Schema.Type) || isMultiRelation(field.original.Schema.Type) {
+			return false
+		}
+
+		return dialect.ColumnType(field.original.Schema.Type) != dialect.ColumnType(field.Schema.Type)
+	})
+}
+
+// ensures that the default value of the given field type can be stored in the database
+func validateDatabaseDefault(fieldType FieldType) error {
+	switch fieldType.(type) {
+	case FieldTypeBool, FieldTypeDecimal, FieldTypeEmail, FieldTypeEnum, FieldTypeFloat, FieldTypeInt, FieldTypeIP, FieldTypeText, FieldTypeURL:
+	default:
+		return fmt.Errorf("configuration error, database default not supported by field type")
+	}
+
+	value, err := fieldType.ValidateValue(nil)
+	if err != nil || value == nil {
+		return fmt.Errorf("configuration error, database default requires a valid default value")
+	}
+
+	return nil
+}
+
+// validates the configuration of all field types of the collection
+func validateFieldTypes(collection Collection) error {
+	for _, field := range collection.Schema.Fields {
+		if config, ok := fieldIdConfig(field.Schema.Type); ok {
+			if err := config.validateConfig(); err != nil {
+				return fmt.Errorf("field %q: %w", field.Name, err)
+			}
+		}
+
+		if nullablePrimaryKey(field.Schema.Type) {
+			return fmt.Errorf("field %q: configuration error, primary key cannot be nullable", field.Name)
+		}
+
+		if ft, ok := field.Schema.Type.(FieldTypeEnum); ok {
+			if err := ft.validateConfig(); err != nil {
+				return fmt.Errorf("field %q: %w", field.Name, err)
+			}
+		}
+
+		if ft, ok := field.Schema.Type.(FieldTypeArray); ok {
+			if err := ft.validateConfig(); err != nil {
+				return fmt.Errorf("field %q: %w", field.Name, err)
+			}
+		}
+
+		if ft, ok := field.Schema.Type.(FieldTypeSingleRelation); ok {
+			if err := ft.validateConfig(); err != nil {
+				return fmt.Errorf("field %q: %w", field.Name, err)
+			}
+		}
+
+		if ft, ok := field.Schema.Type.(FieldTypeComputed); ok {
+			if err := ft.validateConfig(); err != nil {
+				return fmt.Errorf("field %q: %w", field.Name, err)
+			}
+		}
+
+		if field.Schema.DatabaseDefault {
+			if err := validateDatabaseDefault(field.Schema.Type); err != nil {
+				return fmt.Errorf("field %q: %w", field.Name, err)
+			}
+		}
+
+		if field.Schema.DatabaseCheck {
+			switch field.Schema.Type.(type) {
+			case FieldTypeFloat, FieldTypeInt, FieldTypeText:
+			default:
+				return fmt.Errorf("field %q: configuration error, database check not supported by field type", field.Name)
+			}
+		}
+
+		if field.Schema.After != "" {
+			anchor := collection.Schema.field(field.Schema.After)
+			if anchor == nil || anchor == field || isMultiRelation(anchor.Schema.Type) {
+				return fmt.Errorf("field %q: configuration error, cannot place column after unknown column %q", field.Name, field.Schema.After)
+			}
+		}
+	}
+
+	return nil
+}
+
+// reports whether the given field type is both primary key and nullable;
+// primary key columns are never nullable, so the combination is contradictory
+func nullablePrimaryKey(fieldType FieldType) bool {
+	switch ft := fieldType.(type) {
+	case FieldTypeId:
+		return ft.PrimaryKey && ft.Nullable
+
+	case FieldTypeUUID:
+		return ft.PrimaryKey && ft.Nullable
+	}
+
+	return false
+}
+
+// ensures that the fields of the composite primary key exist, are stored as
+// columns, are not nullable and are only relations if explicitly allowed
+func validateCompositePrimaryKey(collection Collection) error {
+	for i, name := range collection.Schema.PrimaryKey {
+		field := collection.Schema.field(name)
+		if field == nil {
+			return fmt.Errorf("invalid primary key, unknown field %q", name)
+		}
+
+		if isMultiRelation(field.Schema.Type) {
+			return fmt.Errorf("invalid primary key, multi relation field %q", name)
+		}
+
+		if _, ok := field.Schema.Type.(FieldTypeSingleRelation); ok && !collection.Schema.RelationPrimaryKey {
+			return fmt.Errorf("invalid primary key, relation field %q requires RelationPrimaryKey", name)
+		}
+
+		if nullableColumn(field.Schema.Type) {
+			return fmt.Errorf("invalid primary key, nullable field %q", name)
+		}
+
+		if slices.Contains(collection.Schema.PrimaryKey[:i], name) {
+			return fmt.Errorf("invalid primary key, duplicate field %q", name)
+		}
+	}
+
+	return nil
+}
+
+// ensures that the columns of the unique constraints exist and are stored as
+// columns and that neither columns nor constraints are duplicated
+func validateUniqueConstraints(collection Collection) error {
+	for i, columns := range collection.Schema.UniqueConstraints {
+		if len(columns) == 0 {
+			return fmt.Errorf("invalid unique constraint, no columns given")
+		}
+
+		for j, name := range columns {
+			field := collection.Schema.field(name)
+			if field == nil {
+				return fmt.Errorf("invalid unique constraint, unknown field %q", name)
+			}
+
+			if isMultiRelation(field.Schema.Type) {
+				return fmt.Errorf("invalid unique constraint, multi relation field %q", name)
+			}
+
+			if slices.Contains(columns[:j], name) {
+				return fmt.Errorf("invalid unique constraint, duplicate field %q", name)
+			}
+		}
+
+		duplicate := slices.ContainsFunc(collection.Schema.UniqueConstraints[:i], func(other []string) bool {
+			return slices.Equal(other, columns)
+		})
+
+		if duplicate {
+			return fmt.Errorf("invalid unique constraint, duplicate constraint (%s)", strings.Join(columns, ", "))
+		}
+	}
+
+	return nil
+}
+
 type CollectionSchema struct {
-	Fields      []*Field
-	ViewFilter  func() bool
-	AllowCreate func() bool
-	AllowUpdate func() bool
-	AllowDelete func() bool
+	Fields  []*Field
+	Indexes []IndexSchema
+
+	// names of the fields forming a composite primary key in order; replaces
+	// the per-column primary keys of id and uuid fields in the table
+	// definition, while the record methods keep addressing records by the
+	// primary key field if there is one
+	PrimaryKey []string
+	// allows single relation fields to be part of PrimaryKey, e.g. for
+	// collections linking or extending records of other collections
+	RelationPrimaryKey bool
+
+	// column sets whose combined values must be unique, e.g. one row per user
+	// and day; enforced by named UNIQUE table constraints, unlike unique
+	// indexes which are managed separately from the table definition
+	UniqueConstraints [][]string
+
+	// adds the fields created_at and updated_at, which are set by the record
+	// methods of Collection; see CreatedAtField and UpdatedAtField
+	Timestamps bool
+	// adds the field version, which is used for optimistic locking by the
+	// record methods of Collection; see VersionField
+	Versioned bool
+
+	// access control callbacks; evaluated by the record methods of Collection
+	ViewFilter  func() []Filter
+	AllowCreate func(data map[string]any) bool
+	AllowUpdate func(record map[string]any, data map[string]any) bool
+	AllowDelete func(record map[string]any) bool
 }
 
 func (s CollectionSchema) Clone() *CollectionSchema {
@@ -72,6 +408,18 @@ func (s CollectionSchema) Clone() *CollectionSchema {
 		clonedFields[i] = field.Clone()
 	}
 
+	cloned.Fields = clonedFields
+
+	clonedIndexes := make([]IndexSchema, len(s.Indexes))
+	for i, index := range s.Indexes {
+		clonedIndexes[i] = index.Clone()
+	}
+
+	cloned.Indexes = clonedIndexes
+	cloned.PrimaryKey = slices.Clone(s.PrimaryKey)
+	cloned.UniqueConstraints = lo.Map(s.UniqueConstraints, func(columns []string, i int) []string {
+		return slices.Clone(columns)
+	})
 	return &cloned
 }
 
@@ -94,23 +442,118 @@ func (f Field) Clone() *Field {
 	return &cloned
 }
 
+// describes the field as name followed by its type and schema flags, e.g.
+// title text(maxLen=255) unique
+func (f Field) String() string {
+	if f.Schema == nil || f.Schema.Type == nil {
+		return f.Name
+	}
+
+	flags := flagAttr(nil, "unique", f.Schema.Unique)
+	flags = flagAttr(flags, "databaseDefault", f.Schema.DatabaseDefault)
+	flags = flagAttr(flags, "databaseCheck", f.Schema.DatabaseCheck)
+
+	return strings.Join(append([]string{f.Name, f.Schema.Type.String()}, flags...), " ")
+}
+
+// reports whether other has the same name, an equal type and the same schema
+// flags; see FieldType.Equal. Position hints, descriptions and metadata are
+// ignored.
+func (f Field) Equal(other Field) bool {
+	if f.Name != other.Name {
+		return false
+	}
+
+	if f.Schema == nil || other.Schema == nil {
+		return f.Schema == nil && other.Schema == nil
+	}
+
+	return equalFieldTypes(f.Schema.Type, other.Schema.Type) && f.Schema.Unique == other.Schema.Unique &&
+		f.Schema.DatabaseDefault == other.Schema.DatabaseDefault && f.Schema.DatabaseCheck == other.Schema.DatabaseCheck
+}
+
 type FieldSchema struct {
 	Type FieldType
+
+	// whether duplicate values are rejected by the database;
+	// enforced using a unique index
+	Unique bool
+
+	// whether CreateDefaultValue is evaluated at migration time and stored as
+	// DEFAULT of the column, so that rows inserted by other clients receive it
+	// as well; only supported for constant defaults of bool, decimal, enum,
+	// float, int and text fields, other defaults (like timestamps) are always
+	// applied by ValidateValue
+	DatabaseDefault bool
+
+	// whether the min and max bounds of int and float fields and the min and
+	// max lengths of text fields are enforced by a CHECK constraint as well;
+	// the bounds are evaluated at migration time
+	DatabaseCheck bool
+
+	// name of the field the column is placed after when it is added to an
+	// existing table; only a hint for tools listing columns in table order,
+	// databases not supporting column positions (DuckDB, SQLite) append the
+	// column at the end, see Dialect.ColumnPosition
+	After string
+
+	// human readable description and arbitrary metadata of the field for
+	// tooling such as API docs and admin UIs; neither affects the database
+	Description string
+	Meta        map[string]string
 }
 
 func (s FieldSchema) Clone() *FieldSchema {
 	cloned := FieldSchema{}
 	cloned.Type = s.Type.Clone()
+	cloned.Unique = s.Unique
+	cloned.DatabaseDefault = s.DatabaseDefault
+	cloned.DatabaseCheck = s.DatabaseCheck
+	cloned.After = s.After
+	cloned.Description = s.Description
+	cloned.Meta = maps.Clone(s.Meta)
 	return &cloned
 }
 
 type FieldType interface {
 	Clone() FieldType
+	// describes the type and its constraints for debugging, e.g.
+	// text(nullable, maxLen=255); constraints given by functions are
+	// evaluated, default values and validators are only indicated
+	fmt.Stringer
+	// reports whether other is of the same type and has the same declarative
+	// options, e.g. nullability, enum values or relation target; functions
+	// such as CreateDefaultValue cannot be compared and are ignored
+	Equal(other FieldType) bool
 
 	// validates if the specified value suits the field type;
 	// returns the value either in original or in encoded/decoded/recoded form;
 	// returns a comprehensive error message if the value is not suitable
 	ValidateValue(value any) (any, error)
+	// validates the given value and converts it into its storage
+	// representation shared by all adapters, which may convert it further
+	// into the representation of their column types
+	Encode(value any) (any, error)
+	// converts a value read from the database into its go representation,
+	// e.g. JSON text into the unmarshaled value; inverse of Encode
+	Decode(value any) (any, error)
+}
+
+// validates the given value using validate and then the validators created by
+// createValidators unless the validated value is nil
+func runValidators(createValidators func() []func(value any) error, value any, validate func(value any) (any, error)) (any, error) {
+	value, err := validate(value)
+	if err != nil || value == nil || createValidators == nil {
+		return value, err
+	}
+
+	for _, validator := range createValidators() {
+		if err := validator(value); err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
 }
 
 func validateNullable(nullable bool, value any) error {
@@ -121,18 +564,89 @@ func validateNullable(nullable bool, value any) error {
 	return nil
 }
 
+// formats the name of a field type followed by its attributes in parentheses
+// unless there are none
+func formatFieldType(name string, attrs []string) string {
+	if len(attrs) == 0 {
+		return name
+	}
+
+	return name + "(" + strings.Join(attrs, ", ") + ")"
+}
+
+// appends the given attribute if set is true
+func flagAttr(attrs []string, attr string, set bool) []string {
+	if !set {
+		return attrs
+	}
+
+	return append(attrs, attr)
+}
+
+// appends the given id config unless it is the zero value
+func idConfigAttr(attrs []string, config IdConfig) []string {
+	if config == (IdConfig{}) {
+		return attrs
+	}
+
+	return append(attrs, fmt.Sprintf("idConfig=%+v", config))
+}
+
+// appends name=value with the value created by create unless create is nil
+func createdAttr[T any](attrs []string, name string, create func() T) []string {
+	if create == nil {
+		return attrs
+	}
+
+	return append(attrs, fmt.Sprintf("%s=%v", name, create()))
+}
+
 type FieldTypeId struct {
 	Nullable           bool
 	PrimaryKey         bool
 	CreateDefaultValue func() string
+	// format of the ids; the zero value means DefaultIdConfig
+	IdConfig IdConfig
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
 }
 
 func (ft FieldTypeId) Clone() FieldType {
 	return FieldType(ft)
 }
 
-func (fieldType FieldTypeId) ValidateValue(value any) (any, error) {
-	if err := validateNullable(fieldType.Nullable || fieldType.PrimaryKey, value); err != nil {
+func (fieldType FieldTypeId) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = flagAttr(attrs, "primaryKey", fieldType.PrimaryKey)
+	attrs = idConfigAttr(attrs, fieldType.IdConfig)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("id", attrs)
+}
+
+func (fieldType FieldTypeId) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeId)
+	return ok && fieldType.Nullable == o.Nullable && fieldType.PrimaryKey == o.PrimaryKey &&
+		fieldType.IdConfig.orDefault() == o.IdConfig.orDefault()
+}
+
+// nil values are replaced by CreateDefaultValue if set; primary keys and
+// non-nullable fields without CreateDefaultValue receive a generated id
+func (fieldType FieldTypeId) validateValue(value any) (any, error) {
+	if value == nil {
+		if fieldType.CreateDefaultValue != nil {
+			value = fieldType.CreateDefaultValue()
+		} else if fieldType.PrimaryKey || !fieldType.Nullable {
+			id, err := fieldType.IdConfig.orDefault().Generate()
+			if err != nil {
+				return nil, err
+			}
+
+			value = id
+		}
+	}
+
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
 		return nil, err
 	}
 
@@ -140,35 +654,87 @@ func (fieldType FieldTypeId) ValidateValue(value any) (any, error) {
 		return nil, nil
 	}
 
-	if err := ValidateId(value); err != nil {
+	if err := fieldType.IdConfig.orDefault().Validate(value); err != nil {
 		return nil, err
 	}
 
 	return value, nil
 }
 
+func (fieldType FieldTypeId) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeId) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+func (fieldType FieldTypeId) Decode(value any) (any, error) {
+	return value, nil
+}
+
+// compiled text patterns keyed by pattern
+var patternCache sync.Map
+
+// compiles the given pattern once and caches the result
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
 type FieldTypeText struct {
 	Nullable           bool
 	CreateDefaultValue func() string
 	CreateMaxLength    func() int
 	CreateMinLength    func() int
 	CreatePattern      func() string
+	// compare values ignoring case in filters; values are stored as given
+	CaseInsensitive bool
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
 }
 
 func (ft FieldTypeText) Clone() FieldType {
 	return FieldType(ft)
 }
 
-func (fieldType FieldTypeText) ValidateValue(value any) (any, error) {
+func (fieldType FieldTypeText) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = createdAttr(attrs, "minLen", fieldType.CreateMinLength)
+	attrs = createdAttr(attrs, "maxLen", fieldType.CreateMaxLength)
+	if fieldType.CreatePattern != nil {
+		attrs = append(attrs, fmt.Sprintf("pattern=%q", fieldType.CreatePattern()))
+	}
+	attrs = flagAttr(attrs, "caseInsensitive", fieldType.CaseInsensitive)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("text", attrs)
+}
+
+func (fieldType FieldTypeText) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeText)
+	return ok && fieldType.Nullable == o.Nullable && fieldType.CaseInsensitive == o.CaseInsensitive
+}
+
+func (fieldType FieldTypeText) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		return fieldType.CreateDefaultValue(), nil
+	}
+
 	if err := validateNullable(fieldType.Nullable, value); err != nil {
 		return nil, err
 	}
 
 	if value == nil {
-		if fieldType.CreateDefaultValue != nil {
-			return fieldType.CreateDefaultValue(), nil
-		}
-
 		return nil, nil
 	}
 
@@ -191,7 +757,13 @@ func (fieldType FieldTypeText) ValidateValue(value any) (any, error) {
 
 	if fieldType.CreatePattern != nil {
 		pattern := fieldType.CreatePattern()
-		if _, err := regexp.MatchString(pattern, str); err != nil {
+
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("configuration error, invalid pattern %v", pattern)
+		}
+
+		if !re.MatchString(str) {
 			return nil, fmt.Errorf("value does not match pattern, pattern is %v", pattern)
 		}
 	}
@@ -199,33 +771,126 @@ func (fieldType FieldTypeText) ValidateValue(value any) (any, error) {
 	return str, nil
 }
 
+func (fieldType FieldTypeText) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeText) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+func (fieldType FieldTypeText) Decode(value any) (any, error) {
+	return value, nil
+}
+
 type FieldTypeInt struct {
 	Nullable           bool
 	CreateDefaultValue func() int64
 	CreateMinValue     func() int64
 	CreateMaxValue     func() int64
+	// accept decimal strings like "42", e.g. from query strings or forms
+	ParseStrings bool
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
 }
 
 func (ft FieldTypeInt) Clone() FieldType {
 	return FieldType(ft)
 }
 
-func (fieldType FieldTypeInt) ValidateValue(value any) (any, error) {
+func (fieldType FieldTypeInt) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = createdAttr(attrs, "min", fieldType.CreateMinValue)
+	attrs = createdAttr(attrs, "max", fieldType.CreateMaxValue)
+	attrs = flagAttr(attrs, "parseStrings", fieldType.ParseStrings)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("int", attrs)
+}
+
+func (fieldType FieldTypeInt) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeInt)
+	return ok && fieldType.Nullable == o.Nullable && fieldType.ParseStrings == o.ParseStrings
+}
+
+// converts integer values of any size and integral floats into int64
+func toInt64(value any) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint:
+		return uintToInt64(uint64(v))
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return uintToInt64(v)
+	case float32:
+		return floatToInt64(float64(v))
+	case float64:
+		return floatToInt64(v)
+	default:
+		return 0, fmt.Errorf("invalid value, expected integer")
+	}
+}
+
+func uintToInt64(v uint64) (int64, error) {
+	if v > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid value, integer out of range")
+	}
+
+	return int64(v), nil
+}
+
+func floatToInt64(v float64) (int64, error) {
+	if v != math.Trunc(v) {
+		return 0, fmt.Errorf("invalid value, expected integer")
+	}
+
+	// 2^63 is the smallest float64 too big for int64
+	if v < math.MinInt64 || v >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid value, integer out of range")
+	}
+
+	return int64(v), nil
+}
+
+func (fieldType FieldTypeInt) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		return fieldType.CreateDefaultValue(), nil
+	}
+
 	if err := validateNullable(fieldType.Nullable, value); err != nil {
 		return nil, err
 	}
 
 	if value == nil {
-		if fieldType.CreateDefaultValue != nil {
-			return fieldType.CreateDefaultValue(), nil
+		return nil, nil
+	}
+
+	if str, ok := value.(string); ok && fieldType.ParseStrings {
+		parsed, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value, cannot parse %q as integer", str)
 		}
 
-		return nil, nil
+		value = parsed
 	}
 
-	i, ok := value.(int64)
-	if !ok {
-		return nil, fmt.Errorf("invalid value, expected integer")
+	i, err := toInt64(value)
+	if err != nil {
+		return nil, err
 	}
 
 	if fieldType.CreateMinValue != nil {
@@ -243,28 +908,73 @@ func (fieldType FieldTypeInt) ValidateValue(value any) (any, error) {
 	return i, nil
 }
 
+func (fieldType FieldTypeInt) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeInt) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+// accepts numbers of JSON text, e.g. elements of arrays stored as JSON
+func (fieldType FieldTypeInt) Decode(value any) (any, error) {
+	if number, ok := value.(json.Number); ok {
+		return number.Int64()
+	}
+
+	return value, nil
+}
+
 type FieldTypeFloat struct {
 	Nullable           bool
 	CreateDefaultValue func() float64
 	CreateMinValue     func() float64
 	CreateMaxValue     func() float64
+	// accept numeric strings like "3.14", e.g. from query strings or forms
+	ParseStrings bool
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
 }
 
 func (ft FieldTypeFloat) Clone() FieldType {
 	return FieldType(ft)
 }
 
-func (fieldType FieldTypeFloat) ValidateValue(value any) (any, error) {
+func (fieldType FieldTypeFloat) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = createdAttr(attrs, "min", fieldType.CreateMinValue)
+	attrs = createdAttr(attrs, "max", fieldType.CreateMaxValue)
+	attrs = flagAttr(attrs, "parseStrings", fieldType.ParseStrings)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("float", attrs)
+}
+
+func (fieldType FieldTypeFloat) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeFloat)
+	return ok && fieldType.Nullable == o.Nullable && fieldType.ParseStrings == o.ParseStrings
+}
+
+func (fieldType FieldTypeFloat) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		return fieldType.CreateDefaultValue(), nil
+	}
+
 	if err := validateNullable(fieldType.Nullable, value); err != nil {
 		return nil, err
 	}
 
 	if value == nil {
-		if fieldType.CreateDefaultValue != nil {
-			return fieldType.CreateDefaultValue(), nil
+		return nil, nil
+	}
+
+	if str, ok := value.(string); ok && fieldType.ParseStrings {
+		parsed, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value, cannot parse %q as float", str)
 		}
 
-		return nil, nil
+		value = parsed
 	}
 
 	f, ok := value.(float64)
@@ -287,25 +997,60 @@ func (fieldType FieldTypeFloat) ValidateValue(value any) (any, error) {
 	return f, nil
 }
 
-type FieldTypeBool struct {
-	Nullable           bool
-	CreateDefaultValue func() bool
+func (fieldType FieldTypeFloat) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
 }
 
-func (ft FieldTypeBool) Clone() FieldType {
-	return FieldType(ft)
+func (fieldType FieldTypeFloat) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
 }
 
-func (fieldType FieldTypeBool) ValidateValue(value any) (any, error) {
-	if err := validateNullable(fieldType.Nullable, value); err != nil {
-		return nil, err
+// accepts single precision floats and numbers of JSON text
+func (fieldType FieldTypeFloat) Decode(value any) (any, error) {
+	switch v := value.(type) {
+	case float32:
+		return float64(v), nil
+
+	case json.Number:
+		return v.Float64()
 	}
 
-	if value == nil {
-		if fieldType.CreateDefaultValue != nil {
-			return fieldType.CreateDefaultValue(), nil
-		}
+	return value, nil
+}
+
+type FieldTypeBool struct {
+	Nullable           bool
+	CreateDefaultValue func() bool
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypeBool) Clone() FieldType {
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeBool) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("bool", attrs)
+}
 
+func (fieldType FieldTypeBool) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeBool)
+	return ok && fieldType.Nullable == o.Nullable
+}
+
+func (fieldType FieldTypeBool) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		return fieldType.CreateDefaultValue(), nil
+	}
+
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
 		return nil, nil
 	}
 
@@ -317,27 +1062,64 @@ func (fieldType FieldTypeBool) ValidateValue(value any) (any, error) {
 	return b, nil
 }
 
+func (fieldType FieldTypeBool) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeBool) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+// accepts bools stored as integers
+func (fieldType FieldTypeBool) Decode(value any) (any, error) {
+	if i, ok := value.(int64); ok {
+		return i != 0, nil
+	}
+
+	return value, nil
+}
+
 type FieldTypeDateTime struct {
 	Nullable           bool
 	CreateDefaultValue func() time.Time
 	CreateMinValue     func() time.Time
 	CreateMaxValue     func() time.Time
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
 }
 
 func (ft FieldTypeDateTime) Clone() FieldType {
 	return FieldType(ft)
 }
 
-func (fieldType FieldTypeDateTime) ValidateValue(value any) (any, error) {
+func (fieldType FieldTypeDateTime) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	if fieldType.CreateMinValue != nil {
+		attrs = append(attrs, "min="+fieldType.CreateMinValue().Format(time.RFC3339Nano))
+	}
+	if fieldType.CreateMaxValue != nil {
+		attrs = append(attrs, "max="+fieldType.CreateMaxValue().Format(time.RFC3339Nano))
+	}
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("datetime", attrs)
+}
+
+func (fieldType FieldTypeDateTime) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeDateTime)
+	return ok && fieldType.Nullable == o.Nullable
+}
+
+func (fieldType FieldTypeDateTime) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		return fieldType.CreateDefaultValue(), nil
+	}
+
 	if err := validateNullable(fieldType.Nullable, value); err != nil {
 		return nil, err
 	}
 
 	if value == nil {
-		if fieldType.CreateDefaultValue != nil {
-			return fieldType.CreateDefaultValue(), nil
-		}
-
 		return nil, nil
 	}
 
@@ -357,24 +1139,58 @@ func (fieldType FieldTypeDateTime) ValidateValue(value any) (any, error) {
 	if fieldType.CreateMinValue != nil {
 		minValue := fieldType.CreateMinValue()
 		if d.Before(minValue) {
-			return nil, fmt.Errorf("value too early, min value is %s", d.Format(timeFormat))
+			return nil, fmt.Errorf("value too early, min value is %s", minValue.Format(timeFormat))
 		}
 	}
 
 	if fieldType.CreateMaxValue != nil {
 		maxValue := fieldType.CreateMaxValue()
 		if d.After(maxValue) {
-			return nil, fmt.Errorf("value too late, max value is %s", d.Format(timeFormat))
+			return nil, fmt.Errorf("value too late, max value is %s", maxValue.Format(timeFormat))
 		}
 	}
 
 	return d, nil
 }
 
+func (fieldType FieldTypeDateTime) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeDateTime) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+// accepts datetimes stored as RFC-3339 strings
+func (fieldType FieldTypeDateTime) Decode(value any) (any, error) {
+	if str, ok := value.(string); ok {
+		return time.Parse(time.RFC3339Nano, str)
+	}
+
+	return value, nil
+}
+
+// storage strategy of enum values
+type EnumStorage int
+
+const (
+	// store values as TEXT
+	EnumStorageText EnumStorage = iota
+	// use the native enum type of the database if supported; changing the
+	// enum values converts the column and fails if a removed value is in use
+	EnumStorageNative
+)
+
 type FieldTypeEnum struct {
 	Nullable           bool
 	EnumValues         []string
 	CreateDefaultValue func() string
+	Storage            EnumStorage
+	// accept values in any case; values are normalized to the casing of
+	// EnumValues and compared ignoring case in filters
+	CaseInsensitive bool
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
 }
 
 func (ft FieldTypeEnum) Clone() FieldType {
@@ -384,7 +1200,51 @@ func (ft FieldTypeEnum) Clone() FieldType {
 	return FieldType(ft)
 }
 
-func (fieldType FieldTypeEnum) ValidateValue(value any) (any, error) {
+func (fieldType FieldTypeEnum) String() string {
+	attrs := []string{strings.Join(fieldType.EnumValues, "|")}
+	attrs = flagAttr(attrs, "nullable", fieldType.Nullable)
+	attrs = flagAttr(attrs, "native", fieldType.Storage == EnumStorageNative)
+	attrs = flagAttr(attrs, "caseInsensitive", fieldType.CaseInsensitive)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("enum", attrs)
+}
+
+func (fieldType FieldTypeEnum) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeEnum)
+	return ok && fieldType.Nullable == o.Nullable && slices.Equal(fieldType.EnumValues, o.EnumValues) &&
+		fieldType.Storage == o.Storage && fieldType.CaseInsensitive == o.CaseInsensitive
+}
+
+// ensures that the enum values are non-empty and unique; values differing in
+// case only are duplicates if the enum is case-insensitive
+func (fieldType FieldTypeEnum) validateConfig() error {
+	if len(fieldType.EnumValues) == 0 {
+		return fmt.Errorf("configuration error, expected at least one enum value")
+	}
+
+	seen := map[string]bool{}
+	for _, value := range fieldType.EnumValues {
+		if value == "" {
+			return fmt.Errorf("configuration error, empty enum value")
+		}
+
+		key := value
+		if fieldType.CaseInsensitive {
+			key = strings.ToLower(value)
+		}
+
+		if seen[key] {
+			return fmt.Errorf("configuration error, duplicate enum value %q", value)
+		}
+
+		seen[key] = true
+	}
+
+	return nil
+}
+
+func (fieldType FieldTypeEnum) validateValue(value any) (any, error) {
 	var defaultValue string = ""
 	if fieldType.CreateDefaultValue != nil {
 		defaultValue = fieldType.CreateDefaultValue()
@@ -393,48 +1253,1448 @@ func (fieldType FieldTypeEnum) ValidateValue(value any) (any, error) {
 		}
 	}
 
+	if value == nil && len(defaultValue) > 0 {
+		return defaultValue, nil
+	}
+
 	if err := validateNullable(fieldType.Nullable, value); err != nil {
 		return nil, err
 	}
 
 	if value == nil {
-		if len(defaultValue) > 0 {
-			return defaultValue, nil
-		}
-
 		return nil, nil
 	}
 
 	str, ok := value.(string)
-	if !ok || !slices.Contains(fieldType.EnumValues, str) {
+	index := slices.Index(fieldType.EnumValues, str)
+	if index < 0 && fieldType.CaseInsensitive {
+		index = slices.IndexFunc(fieldType.EnumValues, func(enumValue string) bool {
+			return strings.EqualFold(enumValue, str)
+		})
+	}
+
+	if !ok || index < 0 {
 		return nil, fmt.Errorf("invalid value, expected one of [%s]", strings.Join(fieldType.EnumValues, ", "))
 	}
 
-	return str, nil
+	// case-insensitive values are normalized to the casing of EnumValues
+	return fieldType.EnumValues[index], nil
+}
+
+func (fieldType FieldTypeEnum) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeEnum) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
 }
 
+func (fieldType FieldTypeEnum) Decode(value any) (any, error) {
+	return value, nil
+}
+
+// action taken on records referencing a deleted record
+type DeleteAction int
+
+const (
+	// reject deleting referenced records
+	DeleteNoAction DeleteAction = iota
+	// delete the referencing records as well
+	DeleteCascade
+	// set the relation of the referencing records to null; requires a
+	// nullable relation
+	DeleteSetNull
+)
+
+// Referential actions, i.e. CascadeDelete, OnDelete and CascadeUpdate, are only
+// supported by SQLite; DuckDB rejects them with ErrUnsupported.
 type FieldTypeSingleRelation struct {
-	Nullable      bool
-	Collection    string
+	Nullable   bool
+	Collection string
+	// shorthand for OnDelete DeleteCascade
 	CascadeDelete bool
+	OnDelete      DeleteAction
+	// updates the relation of referencing records if the id of the
+	// referenced record changes
+	CascadeUpdate bool
+	// format of the ids of the target collection; the zero value means
+	// DefaultIdConfig
+	IdConfig IdConfig
 }
 
 func (ft FieldTypeSingleRelation) Clone() FieldType {
 	return FieldType(ft)
 }
 
+func (fieldType FieldTypeSingleRelation) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = flagAttr(attrs, "cascade", fieldType.deleteAction() == DeleteCascade)
+	attrs = flagAttr(attrs, "setNull", fieldType.deleteAction() == DeleteSetNull)
+	attrs = flagAttr(attrs, "cascadeUpdate", fieldType.CascadeUpdate)
+	attrs = idConfigAttr(attrs, fieldType.IdConfig)
+	return formatFieldType("singleRelation->"+fieldType.Collection, attrs)
+}
+
+func (fieldType FieldTypeSingleRelation) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeSingleRelation)
+	return ok && fieldType.Nullable == o.Nullable && fieldType.Collection == o.Collection && fieldType.deleteAction() == o.deleteAction() &&
+		fieldType.CascadeUpdate == o.CascadeUpdate && fieldType.IdConfig.orDefault() == o.IdConfig.orDefault()
+}
+
+// returns the effective delete action, taking CascadeDelete into account
+func (fieldType FieldTypeSingleRelation) deleteAction() DeleteAction {
+	if fieldType.CascadeDelete {
+		return DeleteCascade
+	}
+
+	return fieldType.OnDelete
+}
+
+// ensures that the delete action is known, consistent with CascadeDelete and
+// applicable to the nullability of the relation
+func (fieldType FieldTypeSingleRelation) validateConfig() error {
+	switch {
+	case fieldType.OnDelete < DeleteNoAction || fieldType.OnDelete > DeleteSetNull:
+		return fmt.Errorf("configuration error, unknown delete action %d", fieldType.OnDelete)
+
+	case fieldType.CascadeDelete && fieldType.OnDelete != DeleteNoAction && fieldType.OnDelete != DeleteCascade:
+		return fmt.Errorf("configuration error, cascade delete conflicts with delete action")
+
+	case fieldType.OnDelete == DeleteSetNull && !fieldType.Nullable:
+		return fmt.Errorf("configuration error, delete action set null requires a nullable relation")
+	}
+
+	return nil
+}
+
+// accepts ids; unlike FieldTypeId, no ids are generated for nil values
 func (fieldType FieldTypeSingleRelation) ValidateValue(value any) (any, error) {
-	idType := FieldTypeId{Nullable: fieldType.Nullable}
-	return idType.ValidateValue(value)
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	if err := fieldType.IdConfig.orDefault().Validate(value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
 }
 
-type View struct {
-	// collection name on last migration; empty for newly created collections;
-	// useful for detecting when a collection has been renamed
-	originalName string
+func (fieldType FieldTypeSingleRelation) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
 
-	Name   string
-	Schema ViewSchema
+func (fieldType FieldTypeSingleRelation) Decode(value any) (any, error) {
+	return value, nil
+}
+
+type FieldTypeJSON struct {
+	Nullable           bool
+	CreateDefaultValue func() any
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypeJSON) Clone() FieldType {
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeJSON) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("json", attrs)
+}
+
+func (fieldType FieldTypeJSON) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeJSON)
+	return ok && fieldType.Nullable == o.Nullable
+}
+
+// accepts any JSON-marshalable value as well as json.RawMessage and strings
+// containing JSON; returns the value as compact JSON string with sorted keys
+func (fieldType FieldTypeJSON) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		value = fieldType.CreateDefaultValue()
+	}
+
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case json.RawMessage:
+		data = v
+
+	case string:
+		data = []byte(v)
+
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("invalid value, expected JSON-marshalable value")
+		}
+	}
+
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("invalid value, expected valid JSON")
+	}
+
+	// keep numbers as they are instead of converting them to float64
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	canonical, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(canonical), nil
+}
+
+func (fieldType FieldTypeJSON) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+// encodes the value as compact JSON text
+func (fieldType FieldTypeJSON) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+// unmarshals JSON text; numbers are decoded as json.Number to keep them exact
+func (fieldType FieldTypeJSON) Decode(value any) (any, error) {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+
+	case []byte:
+		data = v
+
+	default:
+		return value, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	return decoded, nil
+}
+
+type FieldTypeUUID struct {
+	Nullable   bool
+	PrimaryKey bool
+	// use GenerateUUID to auto-generate version 4 UUIDs
+	CreateDefaultValue func() string
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypeUUID) Clone() FieldType {
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeUUID) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = flagAttr(attrs, "primaryKey", fieldType.PrimaryKey)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("uuid", attrs)
+}
+
+func (fieldType FieldTypeUUID) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeUUID)
+	return ok && fieldType.Nullable == o.Nullable && fieldType.PrimaryKey == o.PrimaryKey
+}
+
+// accepts UUID strings and [16]byte values;
+// returns the UUID in canonical lowercase hyphenated form
+func (fieldType FieldTypeUUID) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		value = fieldType.CreateDefaultValue()
+	}
+
+	if err := validateNullable(fieldType.Nullable || fieldType.PrimaryKey, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	switch v := value.(type) {
+	case [16]byte:
+		return formatUUID(v), nil
+
+	case string:
+		uuid, err := parseUUID(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return formatUUID(uuid), nil
+
+	default:
+		return nil, fmt.Errorf("invalid value, expected uuid string")
+	}
+}
+
+func (fieldType FieldTypeUUID) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeUUID) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+// accepts uuids stored as 16 bytes
+func (fieldType FieldTypeUUID) Decode(value any) (any, error) {
+	if b, ok := value.([]byte); ok && len(b) == 16 {
+		return formatUUID([16]byte(b)), nil
+	}
+
+	return value, nil
+}
+
+type FieldTypeDecimal struct {
+	Nullable bool
+	// total number of digits; at most 38
+	Precision int
+	// number of digits after the decimal point; at most Precision
+	Scale              int
+	CreateDefaultValue func() *big.Rat
+	CreateMinValue     func() *big.Rat
+	CreateMaxValue     func() *big.Rat
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypeDecimal) Clone() FieldType {
+	return FieldType(ft)
 }
 
-type ViewSchema struct{}
+func (fieldType FieldTypeDecimal) String() string {
+	attrs := []string{fmt.Sprintf("precision=%d", fieldType.Precision), fmt.Sprintf("scale=%d", fieldType.Scale)}
+	attrs = flagAttr(attrs, "nullable", fieldType.Nullable)
+	if fieldType.CreateMinValue != nil {
+		attrs = append(attrs, "min="+fieldType.CreateMinValue().RatString())
+	}
+	if fieldType.CreateMaxValue != nil {
+		attrs = append(attrs, "max="+fieldType.CreateMaxValue().RatString())
+	}
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("decimal", attrs)
+}
+
+func (fieldType FieldTypeDecimal) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeDecimal)
+	return ok && fieldType.Nullable == o.Nullable && fieldType.Precision == o.Precision && fieldType.Scale == o.Scale
+}
+
+// accepts decimal strings, int64, *big.Rat and big.Float values; returns the
+// value as decimal string with exactly Scale fractional digits; values with
+// more fractional digits than Scale are rejected rather than rounded
+func (fieldType FieldTypeDecimal) validateValue(value any) (any, error) {
+	if fieldType.Precision < 1 || fieldType.Precision > 38 || fieldType.Scale < 0 || fieldType.Scale > fieldType.Precision {
+		return nil, fmt.Errorf("configuration error, invalid precision or scale")
+	}
+
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		value = fieldType.CreateDefaultValue()
+	}
+
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	var r *big.Rat
+	switch v := value.(type) {
+	case string:
+		var ok bool
+		if r, ok = new(big.Rat).SetString(v); !ok || strings.Contains(v, "/") {
+			return nil, fmt.Errorf("invalid value, expected decimal string")
+		}
+
+	case int64:
+		r = new(big.Rat).SetInt64(v)
+
+	case *big.Rat:
+		r = v
+
+	case big.Float:
+		r, _ = v.Rat(nil)
+
+	case *big.Float:
+		r, _ = v.Rat(nil)
+	}
+
+	if r == nil {
+		return nil, fmt.Errorf("invalid value, expected decimal")
+	}
+
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(fieldType.Scale)))
+	if !scaled.IsInt() {
+		return nil, fmt.Errorf("value has too many fractional digits, max scale is %v", fieldType.Scale)
+	}
+
+	if new(big.Int).Abs(scaled.Num()).Cmp(pow10(fieldType.Precision)) >= 0 {
+		return nil, fmt.Errorf("value too big, max precision is %v with scale %v", fieldType.Precision, fieldType.Scale)
+	}
+
+	if fieldType.CreateMinValue != nil {
+		if minValue := fieldType.CreateMinValue(); r.Cmp(minValue) < 0 {
+			return nil, fmt.Errorf("value too small, min value is %s", minValue.FloatString(fieldType.Scale))
+		}
+	}
+
+	if fieldType.CreateMaxValue != nil {
+		if maxValue := fieldType.CreateMaxValue(); r.Cmp(maxValue) > 0 {
+			return nil, fmt.Errorf("value too big, max value is %s", maxValue.FloatString(fieldType.Scale))
+		}
+	}
+
+	return r.FloatString(fieldType.Scale), nil
+}
+
+func (fieldType FieldTypeDecimal) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeDecimal) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+func (fieldType FieldTypeDecimal) Decode(value any) (any, error) {
+	return value, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+type FieldTypeBytes struct {
+	Nullable           bool
+	CreateDefaultValue func() []byte
+	CreateMaxLength    func() int
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypeBytes) Clone() FieldType {
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeBytes) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = createdAttr(attrs, "maxLen", fieldType.CreateMaxLength)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("bytes", attrs)
+}
+
+func (fieldType FieldTypeBytes) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeBytes)
+	return ok && fieldType.Nullable == o.Nullable
+}
+
+// accepts byte slices and standard base64 encoded strings
+func (fieldType FieldTypeBytes) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		value = fieldType.CreateDefaultValue()
+	}
+
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+
+	case string:
+		var err error
+		if b, err = base64.StdEncoding.DecodeString(v); err != nil {
+			return nil, fmt.Errorf("invalid value, expected base64 string")
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid value, expected bytes or base64 string")
+	}
+
+	if fieldType.CreateMaxLength != nil {
+		if maxLength := fieldType.CreateMaxLength(); len(b) > maxLength {
+			return nil, fmt.Errorf("value too long, max length is %v bytes", maxLength)
+		}
+	}
+
+	return b, nil
+}
+
+func (fieldType FieldTypeBytes) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeBytes) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+// accepts base64 strings, e.g. elements of arrays stored as JSON
+func (fieldType FieldTypeBytes) Decode(value any) (any, error) {
+	if str, ok := value.(string); ok {
+		return base64.StdEncoding.DecodeString(str)
+	}
+
+	return value, nil
+}
+
+// Models a many-to-many relation to the records of another collection. The
+// links are stored in a junction table instead of a column; see relation.go.
+//
+// Deleting a record of the owning collection always removes its links.
+// Deleting a record of the target collection removes the links pointing to it
+// if CascadeDelete is set and is rejected while it is linked otherwise; the
+// linking records themselves are never deleted. Note that DuckDB does not
+// support cascading foreign keys.
+type FieldTypeMultiRelation struct {
+	Collection string
+	// removes the links to deleted target records; only supported by SQLite,
+	// DuckDB rejects it with ErrUnsupported
+	CascadeDelete bool
+	// format of the ids of the target collection; the zero value means
+	// DefaultIdConfig
+	IdConfig IdConfig
+}
+
+func (ft FieldTypeMultiRelation) Clone() FieldType {
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeMultiRelation) String() string {
+	attrs := flagAttr(nil, "cascade", fieldType.CascadeDelete)
+	attrs = idConfigAttr(attrs, fieldType.IdConfig)
+	return formatFieldType("multiRelation->"+fieldType.Collection, attrs)
+}
+
+func (fieldType FieldTypeMultiRelation) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeMultiRelation)
+	return ok && fieldType.Collection == o.Collection && fieldType.CascadeDelete == o.CascadeDelete &&
+		fieldType.IdConfig.orDefault() == o.IdConfig.orDefault()
+}
+
+// accepts slices of ids; returns the deduplicated ids;
+// nil is treated as empty slice
+func (fieldType FieldTypeMultiRelation) ValidateValue(value any) (any, error) {
+	if value == nil {
+		return []string{}, nil
+	}
+
+	var values []any
+	switch v := value.(type) {
+	case []string:
+		values = lo.ToAnySlice(v)
+
+	case []any:
+		values = v
+
+	default:
+		return nil, fmt.Errorf("invalid value, expected list of ids")
+	}
+
+	config := fieldType.IdConfig.orDefault()
+
+	ids := []string{}
+	for i, v := range values {
+		if err := config.Validate(v); err != nil {
+			return nil, fmt.Errorf("invalid value at index %v, %w", i, err)
+		}
+
+		ids = append(ids, v.(string))
+	}
+
+	return lo.Uniq(ids), nil
+}
+
+func (fieldType FieldTypeMultiRelation) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+func (fieldType FieldTypeMultiRelation) Decode(value any) (any, error) {
+	return value, nil
+}
+
+// Email addresses are stored as text. The domain part is converted to lower
+// case while the local part is kept, since it may be case sensitive. Display
+// names and comments are not accepted.
+type FieldTypeEmail struct {
+	Nullable           bool
+	CreateDefaultValue func() string
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypeEmail) Clone() FieldType {
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeEmail) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("email", attrs)
+}
+
+func (fieldType FieldTypeEmail) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeEmail)
+	return ok && fieldType.Nullable == o.Nullable
+}
+
+func (fieldType FieldTypeEmail) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		value = fieldType.CreateDefaultValue()
+	}
+
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid value, expected string")
+	}
+
+	return normalizeEmail(str)
+}
+
+func (fieldType FieldTypeEmail) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeEmail) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+func (fieldType FieldTypeEmail) Decode(value any) (any, error) {
+	return value, nil
+}
+
+// validates the given email address and converts its domain to lower case
+func normalizeEmail(str string) (string, error) {
+	// addr-spec of RFC 5322 as parsed by net/mail; max length of RFC 5321
+	address, err := mail.ParseAddress(str)
+	if err != nil || address.Name != "" || address.Address != str || len(str) > 254 {
+		return "", fmt.Errorf("invalid value, expected email address like name@example.com")
+	}
+
+	at := strings.LastIndex(str, "@")
+	local, domain := str[:at], str[at+1:]
+
+	if strings.HasPrefix(domain, "[") || !strings.Contains(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", fmt.Errorf("invalid value, expected email address with domain like example.com")
+	}
+
+	return local + "@" + strings.ToLower(domain), nil
+}
+
+// Absolute URLs with host are stored as text in normalized form, i.e. with lower
+// case scheme and host.
+type FieldTypeURL struct {
+	Nullable           bool
+	CreateDefaultValue func() string
+	// accepted schemes in lower case, e.g. http and https; all schemes are
+	// accepted if not set
+	CreateAllowedSchemes func() []string
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypeURL) Clone() FieldType {
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeURL) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	if fieldType.CreateAllowedSchemes != nil {
+		attrs = append(attrs, "schemes="+strings.Join(fieldType.CreateAllowedSchemes(), "|"))
+	}
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("url", attrs)
+}
+
+func (fieldType FieldTypeURL) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeURL)
+	return ok && fieldType.Nullable == o.Nullable
+}
+
+func (fieldType FieldTypeURL) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		value = fieldType.CreateDefaultValue()
+	}
+
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid value, expected string")
+	}
+
+	parsed, err := url.Parse(str)
+	if err != nil || !parsed.IsAbs() || parsed.Host == "" || strings.ContainsAny(str, " \t\n") {
+		return nil, fmt.Errorf("invalid value, expected absolute URL like https://example.com")
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+
+	if fieldType.CreateAllowedSchemes != nil {
+		if schemes := fieldType.CreateAllowedSchemes(); !slices.Contains(schemes, parsed.Scheme) {
+			return nil, fmt.Errorf("invalid value, expected URL with scheme %s", strings.Join(schemes, " or "))
+		}
+	}
+
+	return parsed.String(), nil
+}
+
+func (fieldType FieldTypeURL) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeURL) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+func (fieldType FieldTypeURL) Decode(value any) (any, error) {
+	return value, nil
+}
+
+// IPv4 and IPv6 addresses are stored as text in canonical form, e.g. ::1
+// instead of 0:0:0:0:0:0:0:1. CIDR ranges are accepted if AllowCIDR is set and
+// stored with the host bits cleared, e.g. 10.0.0.0/8 for 10.1.2.3/8.
+type FieldTypeIP struct {
+	Nullable           bool
+	AllowCIDR          bool
+	CreateDefaultValue func() string
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypeIP) Clone() FieldType {
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeIP) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = flagAttr(attrs, "cidr", fieldType.AllowCIDR)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("ip", attrs)
+}
+
+func (fieldType FieldTypeIP) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeIP)
+	return ok && fieldType.Nullable == o.Nullable && fieldType.AllowCIDR == o.AllowCIDR
+}
+
+// accepts strings, netip.Addr and, if CIDR ranges are allowed, netip.Prefix;
+// returns the canonical string
+func (fieldType FieldTypeIP) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		value = fieldType.CreateDefaultValue()
+	}
+
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	expected := "IP address"
+	if fieldType.AllowCIDR {
+		expected = "IP address or CIDR range"
+	}
+
+	switch v := value.(type) {
+	case netip.Addr:
+		if v.IsValid() {
+			return v.String(), nil
+		}
+
+	case netip.Prefix:
+		if v.IsValid() && fieldType.AllowCIDR {
+			return v.Masked().String(), nil
+		}
+
+	case string:
+		if addr, err := netip.ParseAddr(v); err == nil {
+			return addr.String(), nil
+		}
+
+		if prefix, err := netip.ParsePrefix(v); err == nil && fieldType.AllowCIDR {
+			return prefix.Masked().String(), nil
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid value, expected string")
+	}
+
+	return nil, fmt.Errorf("invalid value, expected %s", expected)
+}
+
+func (fieldType FieldTypeIP) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeIP) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+func (fieldType FieldTypeIP) Decode(value any) (any, error) {
+	return value, nil
+}
+
+// Durations are stored as integer nanoseconds.
+type FieldTypeDuration struct {
+	Nullable           bool
+	CreateDefaultValue func() time.Duration
+	CreateMinValue     func() time.Duration
+	CreateMaxValue     func() time.Duration
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypeDuration) Clone() FieldType {
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeDuration) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = createdAttr(attrs, "min", fieldType.CreateMinValue)
+	attrs = createdAttr(attrs, "max", fieldType.CreateMaxValue)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("duration", attrs)
+}
+
+func (fieldType FieldTypeDuration) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeDuration)
+	return ok && fieldType.Nullable == o.Nullable
+}
+
+// accepts durations, integers of nanoseconds and duration strings like 1h30m;
+// returns a time.Duration
+func (fieldType FieldTypeDuration) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		value = fieldType.CreateDefaultValue()
+	}
+
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	var d time.Duration
+	switch v := value.(type) {
+	case time.Duration:
+		d = v
+
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value, cannot parse %q as duration like 1h30m", v)
+		}
+
+		d = parsed
+
+	default:
+		i, err := toInt64(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value, expected duration, nanoseconds or duration string")
+		}
+
+		d = time.Duration(i)
+	}
+
+	if fieldType.CreateMinValue != nil {
+		if minValue := fieldType.CreateMinValue(); d < minValue {
+			return nil, fmt.Errorf("value too small, min value is %v", minValue)
+		}
+	}
+
+	if fieldType.CreateMaxValue != nil {
+		if maxValue := fieldType.CreateMaxValue(); d > maxValue {
+			return nil, fmt.Errorf("value too large, max value is %v", maxValue)
+		}
+	}
+
+	return d, nil
+}
+
+func (fieldType FieldTypeDuration) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+// encodes durations as integer nanoseconds
+func (fieldType FieldTypeDuration) Encode(value any) (any, error) {
+	value, err := fieldType.ValidateValue(value)
+	if d, ok := value.(time.Duration); ok && err == nil {
+		return int64(d), nil
+	}
+
+	return value, err
+}
+
+func (fieldType FieldTypeDuration) Decode(value any) (any, error) {
+	switch v := value.(type) {
+	case int64:
+		return time.Duration(v), nil
+
+	case json.Number:
+		i, err := v.Int64()
+		return time.Duration(i), err
+	}
+
+	return value, nil
+}
+
+// geographic coordinate in degrees
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// returns the point as well-known text; note that longitude comes first
+func (p Point) WKT() string {
+	return "POINT (" + strconv.FormatFloat(p.Lng, 'g', -1, 64) + " " + strconv.FormatFloat(p.Lat, 'g', -1, 64) + ")"
+}
+
+// parses a point given as well-known text like POINT (lng lat)
+func parsePointWKT(str string) (Point, bool) {
+	inner, ok := strings.CutPrefix(strings.ToUpper(strings.TrimSpace(str)), "POINT")
+	inner = strings.TrimSpace(inner)
+	if !ok || !strings.HasPrefix(inner, "(") || !strings.HasSuffix(inner, ")") {
+		return Point{}, false
+	}
+
+	coordinates := strings.Fields(inner[1 : len(inner)-1])
+	if len(coordinates) != 2 {
+		return Point{}, false
+	}
+
+	lng, errLng := strconv.ParseFloat(coordinates[0], 64)
+	lat, errLat := strconv.ParseFloat(coordinates[1], 64)
+	if errLng != nil || errLat != nil {
+		return Point{}, false
+	}
+
+	return Point{Lat: lat, Lng: lng}, true
+}
+
+// parses a point read from the database as well-known text
+func decodePoint(str string) (Point, error) {
+	p, ok := parsePointWKT(str)
+	if !ok {
+		return Point{}, fmt.Errorf("invalid point %q", str)
+	}
+
+	return p, nil
+}
+
+// Points are stored as GEOMETRY by DuckDB, which requires the spatial
+// extension, and as well-known text by SQLite. SRID identifies the coordinate
+// reference system and defaults to 4326 (WGS 84); it is not stored.
+type FieldTypePoint struct {
+	Nullable           bool
+	SRID               int
+	CreateDefaultValue func() Point
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypePoint) Clone() FieldType {
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypePoint) String() string {
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	if fieldType.SRID != 0 {
+		attrs = append(attrs, fmt.Sprintf("srid=%d", fieldType.SRID))
+	}
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("point", attrs)
+}
+
+func (fieldType FieldTypePoint) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypePoint)
+	return ok && fieldType.Nullable == o.Nullable && fieldType.SRID == o.SRID
+}
+
+// accepts points, [2]float64 and slices of latitude and longitude, maps with
+// the keys lat and lng and well-known text; returns a Point
+func (fieldType FieldTypePoint) validateValue(value any) (any, error) {
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		value = fieldType.CreateDefaultValue()
+	}
+
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	var coordinates []any
+	switch v := value.(type) {
+	case Point:
+		coordinates = []any{v.Lat, v.Lng}
+
+	case [2]float64:
+		coordinates = []any{v[0], v[1]}
+
+	case []float64:
+		coordinates = lo.ToAnySlice(v)
+
+	case []any:
+		coordinates = v
+
+	case map[string]any:
+		if len(v) == 2 {
+			coordinates = []any{v["lat"], v["lng"]}
+		}
+
+	case string:
+		if p, ok := parsePointWKT(v); ok {
+			coordinates = []any{p.Lat, p.Lng}
+		}
+	}
+
+	if len(coordinates) != 2 {
+		return nil, fmt.Errorf("invalid value, expected point of latitude and longitude")
+	}
+
+	var p Point
+	for i, dest := range []*float64{&p.Lat, &p.Lng} {
+		switch c := coordinates[i].(type) {
+		case float64:
+			*dest = c
+
+		case json.Number:
+			f, err := c.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("invalid value, expected numeric coordinates")
+			}
+
+			*dest = f
+
+		default:
+			i, err := toInt64(c)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value, expected numeric coordinates")
+			}
+
+			*dest = float64(i)
+		}
+	}
+
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return nil, fmt.Errorf("invalid value, latitude must be between -90 and 90")
+	}
+
+	if math.IsNaN(p.Lng) || p.Lng < -180 || p.Lng > 180 {
+		return nil, fmt.Errorf("invalid value, longitude must be between -180 and 180")
+	}
+
+	return p, nil
+}
+
+func (fieldType FieldTypePoint) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+// encodes points as well-known text
+func (fieldType FieldTypePoint) Encode(value any) (any, error) {
+	value, err := fieldType.ValidateValue(value)
+	if point, ok := value.(Point); ok && err == nil {
+		return point.WKT(), nil
+	}
+
+	return value, err
+}
+
+// accepts points stored as well-known text
+func (fieldType FieldTypePoint) Decode(value any) (any, error) {
+	if str, ok := value.(string); ok {
+		return decodePoint(str)
+	}
+
+	return value, nil
+}
+
+// Arrays hold a list of values of the element type; arrays of arrays are built
+// by using another array as element type. DuckDB stores arrays as LIST columns
+// and SQLite as JSON text.
+type FieldTypeArray struct {
+	Nullable           bool
+	Element            FieldType
+	CreateDefaultValue func() []any
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypeArray) Clone() FieldType {
+	if ft.Element != nil {
+		ft.Element = ft.Element.Clone()
+	}
+
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeArray) String() string {
+	element := "<nil>"
+	if fieldType.Element != nil {
+		element = fieldType.Element.String()
+	}
+
+	attrs := flagAttr(nil, "nullable", fieldType.Nullable)
+	attrs = flagAttr(attrs, "default", fieldType.CreateDefaultValue != nil)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("array<"+element+">", attrs)
+}
+
+func (fieldType FieldTypeArray) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeArray)
+	return ok && fieldType.Nullable == o.Nullable && equalFieldTypes(fieldType.Element, o.Element)
+}
+
+// ensures that the element type is a scalar type or an array of such
+func (fieldType FieldTypeArray) validateConfig() error {
+	switch ft := fieldType.Element.(type) {
+	case nil:
+		return fmt.Errorf("configuration error, expected element type")
+
+	case FieldTypeArray:
+		return ft.validateConfig()
+
+	case FieldTypeEnum:
+		// anonymous enum types of DuckDB cannot be cast into each other
+		if ft.Storage == EnumStorageNative {
+			return fmt.Errorf("configuration error, array elements cannot use native enum storage")
+		}
+
+		return ft.validateConfig()
+
+	case FieldTypeId:
+		if ft.PrimaryKey {
+			return fmt.Errorf("configuration error, array elements cannot be primary keys")
+		}
+
+	case FieldTypeUUID:
+		if ft.PrimaryKey {
+			return fmt.Errorf("configuration error, array elements cannot be primary keys")
+		}
+
+	case FieldTypeSingleRelation, FieldTypeMultiRelation, FieldTypeJSON, FieldTypePoint, FieldTypeSerial:
+		return fmt.Errorf("configuration error, unsupported element type %T", ft)
+	}
+
+	return nil
+}
+
+// accepts slices and arrays whose elements are valid for the element type;
+// returns the normalized elements as []any
+func (fieldType FieldTypeArray) validateValue(value any) (any, error) {
+	if fieldType.Element == nil {
+		return nil, fmt.Errorf("configuration error, expected element type")
+	}
+
+	if value == nil && fieldType.CreateDefaultValue != nil {
+		value = fieldType.CreateDefaultValue()
+	}
+
+	if err := validateNullable(fieldType.Nullable, value); err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("invalid value, expected array")
+	}
+
+	elements := make([]any, v.Len())
+	for i := range elements {
+		element, err := fieldType.Element.ValidateValue(v.Index(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+
+		elements[i] = element
+	}
+
+	return elements, nil
+}
+
+func (fieldType FieldTypeArray) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+// encodes the elements using the element type
+func (fieldType FieldTypeArray) Encode(value any) (any, error) {
+	value, err := fieldType.ValidateValue(value)
+	values, ok := value.([]any)
+	if !ok || err != nil {
+		return value, err
+	}
+
+	elements := make([]any, len(values))
+	for i, element := range values {
+		if elements[i], err = fieldType.Element.Encode(element); err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+	}
+
+	return elements, nil
+}
+
+// decodes the elements using the element type
+func (fieldType FieldTypeArray) Decode(value any) (any, error) {
+	values, ok := value.([]any)
+	if !ok {
+		return value, nil
+	}
+
+	elements := make([]any, len(values))
+	for i, element := range values {
+		if element == nil {
+			continue
+		}
+
+		decoded, err := fieldType.Element.Decode(element)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+
+		elements[i] = decoded
+	}
+
+	return elements, nil
+}
+
+// generated column computed by the database from other columns of the same
+// record, e.g. first || ' ' || last; values are read like values of the base
+// type, but cannot be written
+type FieldTypeComputed struct {
+	// SQL expression over the columns of the table
+	Expression string
+	// type of the computed values; determines the column type and how values
+	// are decoded and filtered. Computed values may always be null.
+	Base FieldType
+	// store the values when records are written instead of computing them
+	// when read; not supported by DuckDB
+	Stored bool
+}
+
+func (ft FieldTypeComputed) Clone() FieldType {
+	if ft.Base != nil {
+		ft.Base = ft.Base.Clone()
+	}
+
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeComputed) String() string {
+	attrs := []string{fmt.Sprintf("%q", fieldType.Expression)}
+	attrs = flagAttr(attrs, "stored", fieldType.Stored)
+
+	base := "<nil>"
+	if fieldType.Base != nil {
+		base = fieldType.Base.String()
+	}
+
+	return formatFieldType("computed<"+base+">", attrs)
+}
+
+func (fieldType FieldTypeComputed) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeComputed)
+	return ok && fieldType.Expression == o.Expression && equalFieldTypes(fieldType.Base, o.Base) && fieldType.Stored == o.Stored
+}
+
+// ensures that an expression and a base type stored as a single column are
+// given
+func (fieldType FieldTypeComputed) validateConfig() error {
+	if strings.TrimSpace(fieldType.Expression) == "" {
+		return fmt.Errorf("configuration error, expected expression")
+	}
+
+	switch ft := fieldType.Base.(type) {
+	case nil:
+		return fmt.Errorf("configuration error, expected base type")
+
+	case FieldTypeComputed, FieldTypeMultiRelation, FieldTypeSerial, FieldTypeSingleRelation:
+		return fmt.Errorf("configuration error, unsupported base type %T", ft)
+
+	case FieldTypeArray:
+		return ft.validateConfig()
+	}
+
+	return nil
+}
+
+// only accepts nil, since values are computed by the database
+func (fieldType FieldTypeComputed) ValidateValue(value any) (any, error) {
+	if value != nil {
+		return nil, fmt.Errorf("invalid value, computed fields cannot be written")
+	}
+
+	return nil, nil
+}
+
+func (fieldType FieldTypeComputed) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+// decodes values like the base type
+func (fieldType FieldTypeComputed) Decode(value any) (any, error) {
+	if value == nil || fieldType.Base == nil {
+		return value, nil
+	}
+
+	return fieldType.Base.Decode(value)
+}
+
+// returns the base type of computed fields and the given type otherwise
+func baseFieldType(fieldType FieldType) FieldType {
+	if ft, ok := fieldType.(FieldTypeComputed); ok && ft.Base != nil {
+		return ft.Base
+	}
+
+	return fieldType
+}
+
+func isComputed(fieldType FieldType) bool {
+	_, ok := fieldType.(FieldTypeComputed)
+	return ok
+}
+
+// integer assigned by the database if no value is given, e.g. for classic
+// auto-increment primary keys. DuckDB draws the values from a sequence named
+// after the table and column (see serialSequenceName), which is created and
+// dropped along with the column; values given explicitly do not advance the
+// sequence. SQLite only supports serial primary keys, which alias the rowid.
+type FieldTypeSerial struct {
+	PrimaryKey bool
+	// validators run after the built-in validation on non-null values
+	CreateValidators func() []func(value any) error
+}
+
+func (ft FieldTypeSerial) Clone() FieldType {
+	return FieldType(ft)
+}
+
+func (fieldType FieldTypeSerial) String() string {
+	attrs := flagAttr(nil, "primaryKey", fieldType.PrimaryKey)
+	attrs = flagAttr(attrs, "validators", fieldType.CreateValidators != nil)
+	return formatFieldType("serial", attrs)
+}
+
+func (fieldType FieldTypeSerial) Equal(other FieldType) bool {
+	o, ok := other.(FieldTypeSerial)
+	return ok && fieldType.PrimaryKey == o.PrimaryKey
+}
+
+// accepts nil, which leaves assigning the value to the database, integers and
+// decimal strings like ids given as strings to the record methods
+func (fieldType FieldTypeSerial) validateValue(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if str, ok := value.(string); ok {
+		parsed, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value, cannot parse %q as integer", str)
+		}
+
+		return parsed, nil
+	}
+
+	return toInt64(value)
+}
+
+func (fieldType FieldTypeSerial) ValidateValue(value any) (any, error) {
+	return runValidators(fieldType.CreateValidators, value, fieldType.validateValue)
+}
+
+func (fieldType FieldTypeSerial) Encode(value any) (any, error) {
+	return fieldType.ValidateValue(value)
+}
+
+func (fieldType FieldTypeSerial) Decode(value any) (any, error) {
+	return value, nil
+}
+
+type View struct {
+	// collection name on last migration; empty for newly created collections;
+	// useful for detecting when a collection has been renamed
+	originalName string
+
+	Name   string
+	Schema ViewSchema
+}
+
+func (v *View) Forward() {
+	v.originalName = v.Name
+}
+
+type ViewSchema struct {
+	// SELECT statement defining the view
+	Query string
+}
@@ -0,0 +1,833 @@
+package ldb_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"lehnert.dev/ldb"
+)
+
+func TestCollectionSchemaClone(t *testing.T) {
+	original := ldb.CollectionSchema{
+		Fields: []*ldb.Field{
+			{Name: "title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}, Description: "shown above posts", Meta: map[string]string{"widget": "heading"}}},
+			{Name: "state", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeEnum{EnumValues: []string{"a", "b"}}}},
+		},
+	}
+
+	cloned := original.Clone()
+	if cloned.Fields[0].Schema.Description != "shown above posts" || cloned.Fields[0].Schema.Meta["widget"] != "heading" {
+		t.Fatalf("expected description and metadata to be cloned, got %+v", cloned.Fields[0].Schema)
+	}
+
+	cloned.Fields[0].Name = "changed"
+	cloned.Fields[0].Schema.Meta["widget"] = "changed"
+	cloned.Fields[1].Schema.Type.(ldb.FieldTypeEnum).EnumValues[0] = "changed"
+	cloned.Fields = append(cloned.Fields, &ldb.Field{Name: "added"})
+
+	if original.Fields[0].Name != "title" {
+		t.Errorf("expected original field name to be unchanged, got %q", original.Fields[0].Name)
+	}
+
+	if original.Fields[1].Schema.Type.(ldb.FieldTypeEnum).EnumValues[0] != "a" {
+		t.Error("expected original enum values to be unchanged")
+	}
+
+	if original.Fields[0].Schema.Meta["widget"] != "heading" {
+		t.Error("expected original metadata to be unchanged")
+	}
+
+	if len(original.Fields) != 2 {
+		t.Errorf("expected original to have 2 fields, got %v", len(original.Fields))
+	}
+}
+
+func TestFieldTypeJSON(t *testing.T) {
+	fieldType := ldb.FieldTypeJSON{}
+
+	valid := map[string]any{
+		`{"a":1,"b":[true,null]}`:    map[string]any{"b": []any{true, nil}, "a": 1},
+		`{"a":12345678901234567890}`: json.RawMessage(`{ "a": 12345678901234567890 }`),
+		`"text"`:                     `"text"`,
+		`[1,2.5]`:                    []float64{1, 2.5},
+	}
+
+	for expected, value := range valid {
+		encoded, err := fieldType.ValidateValue(value)
+		if err != nil {
+			t.Errorf("expected %v to be valid, got %v", value, err)
+		} else if encoded != expected {
+			t.Errorf("expected %v to be encoded as %s, got %v", value, expected, encoded)
+		}
+	}
+
+	invalid := []any{`{"a":`, "text", json.RawMessage(`[1,`), make(chan int), nil}
+	for _, value := range invalid {
+		if _, err := fieldType.ValidateValue(value); err == nil {
+			t.Errorf("expected %v to be invalid", value)
+		}
+	}
+
+	nullable := ldb.FieldTypeJSON{Nullable: true}
+	if value, err := nullable.ValidateValue(nil); err != nil || value != nil {
+		t.Errorf("expected nil to be valid for nullable field, got %v, %v", value, err)
+	}
+
+	withDefault := ldb.FieldTypeJSON{CreateDefaultValue: func() any { return []string{} }}
+	if value, err := withDefault.ValidateValue(nil); err != nil || value != "[]" {
+		t.Errorf("expected default value to be encoded, got %v, %v", value, err)
+	}
+}
+
+func TestFieldTypeIdDefault(t *testing.T) {
+	for _, fieldType := range []ldb.FieldTypeId{{PrimaryKey: true}, {}} {
+		first, err := fieldType.ValidateValue(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		second, err := fieldType.ValidateValue(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ldb.ValidateId(first) != nil || first == second {
+			t.Errorf("expected distinct generated ids for %+v, got %v and %v", fieldType, first, second)
+		}
+	}
+
+	if value, err := (ldb.FieldTypeId{Nullable: true}).ValidateValue(nil); err != nil || value != nil {
+		t.Errorf("expected nil for nullable id, got %v (%v)", value, err)
+	}
+
+	const id = "0123456789abcdef0123456789abcde"
+	fixed := ldb.FieldTypeId{PrimaryKey: true, CreateDefaultValue: func() string { return id }}
+	if value, err := fixed.ValidateValue(nil); err != nil || value != id {
+		t.Errorf("expected default id, got %v (%v)", value, err)
+	}
+
+	invalid := ldb.FieldTypeId{CreateDefaultValue: func() string { return "invalid" }}
+	if _, err := invalid.ValidateValue(nil); err == nil {
+		t.Error("expected invalid default id to be rejected")
+	}
+
+	// relations never generate ids
+	if _, err := (ldb.FieldTypeSingleRelation{Collection: "authors"}).ValidateValue(nil); err == nil {
+		t.Error("expected nil to be rejected for required relation")
+	}
+}
+
+func TestFieldTypeUUID(t *testing.T) {
+	fieldType := ldb.FieldTypeUUID{}
+	expected := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+	valid := []any{
+		"6BA7B810-9DAD-11D1-80B4-00C04FD430C8",
+		"6ba7b8109dad11d180b400c04fd430c8",
+		[16]byte{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8},
+	}
+
+	for _, value := range valid {
+		if normalized, err := fieldType.ValidateValue(value); err != nil {
+			t.Errorf("expected %v to be valid, got %v", value, err)
+		} else if normalized != expected {
+			t.Errorf("expected %v to be normalized to %s, got %v", value, expected, normalized)
+		}
+	}
+
+	invalid := []any{"6ba7b810-9dad-11d1-80b4", "6ba7b810_9dad_11d1_80b4_00c04fd430c8", "zba7b810-9dad-11d1-80b4-00c04fd430c8", 42, nil}
+	for _, value := range invalid {
+		if _, err := fieldType.ValidateValue(value); err == nil {
+			t.Errorf("expected %v to be invalid", value)
+		}
+	}
+
+	generated := ldb.FieldTypeUUID{CreateDefaultValue: ldb.GenerateUUID}
+	value, err := generated.ValidateValue(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uuid := value.(string); uuid[14] != '4' {
+		t.Errorf("expected version 4 uuid, got %s", uuid)
+	}
+}
+
+func TestFieldTypeDecimal(t *testing.T) {
+	fieldType := ldb.FieldTypeDecimal{
+		Precision:      6,
+		Scale:          2,
+		CreateMinValue: func() *big.Rat { return big.NewRat(-1000, 1) },
+	}
+
+	valid := map[any]string{
+		"12.3":            "12.30",
+		"-999.99":         "-999.99",
+		int64(42):         "42.00",
+		big.NewRat(1, 4):  "0.25",
+		big.NewFloat(1.5): "1.50",
+		"9999.99":         "9999.99",
+		"0.10":            "0.10",
+	}
+
+	for value, expected := range valid {
+		if normalized, err := fieldType.ValidateValue(value); err != nil {
+			t.Errorf("expected %v to be valid, got %v", value, err)
+		} else if normalized != expected {
+			t.Errorf("expected %v to be normalized to %s, got %v", value, expected, normalized)
+		}
+	}
+
+	invalid := []any{"1.234", "10000", "-1000.01", "1/3", "abc", 1.5, nil}
+	for _, value := range invalid {
+		if _, err := fieldType.ValidateValue(value); err == nil {
+			t.Errorf("expected %v to be invalid", value)
+		}
+	}
+
+	if _, err := (ldb.FieldTypeDecimal{Precision: 2, Scale: 3}).ValidateValue("0"); err == nil {
+		t.Error("expected configuration error for scale exceeding precision")
+	}
+}
+
+func TestFieldTypeBytes(t *testing.T) {
+	fieldType := ldb.FieldTypeBytes{CreateMaxLength: func() int { return 4 }}
+
+	valid := map[any][]byte{
+		"AQID": {1, 2, 3},
+		"":     {},
+	}
+
+	for value, expected := range valid {
+		if decoded, err := fieldType.ValidateValue(value); err != nil {
+			t.Errorf("expected %v to be valid, got %v", value, err)
+		} else if !bytes.Equal(decoded.([]byte), expected) {
+			t.Errorf("expected %v to be decoded to %v, got %v", value, expected, decoded)
+		}
+	}
+
+	if _, err := fieldType.ValidateValue([]byte{1, 2, 3, 4}); err != nil {
+		t.Errorf("expected bytes at max length to be valid, got %v", err)
+	}
+
+	_, err := fieldType.ValidateValue([]byte{1, 2, 3, 4, 5})
+	if err == nil || !strings.Contains(err.Error(), "4") {
+		t.Errorf("expected error naming the limit, got %v", err)
+	}
+
+	invalid := []any{"not base64!", 42, nil}
+	for _, value := range invalid {
+		if _, err := fieldType.ValidateValue(value); err == nil {
+			t.Errorf("expected %v to be invalid", value)
+		}
+	}
+}
+
+func TestFieldTypeEmail(t *testing.T) {
+	email := ldb.FieldTypeEmail{}
+
+	valid := map[string]string{
+		"jane@example.com":          "jane@example.com",
+		"Jane.Doe@Example.COM":      "Jane.Doe@example.com",
+		"jane+tag@mail.example.org": "jane+tag@mail.example.org",
+	}
+
+	for value, expected := range valid {
+		normalized, err := email.ValidateValue(value)
+		if err != nil {
+			t.Fatalf("expected %q to be valid, got %v", value, err)
+		}
+
+		if normalized != expected {
+			t.Fatalf("expected %q, got %q", expected, normalized)
+		}
+	}
+
+	for _, value := range []any{"", "jane", "jane@", "@example.com", "jane@localhost", "Jane <jane@example.com>", " jane@example.com", "jane@example.com.", 1} {
+		if _, err := email.ValidateValue(value); err == nil {
+			t.Fatalf("expected %q to be invalid", value)
+		}
+	}
+
+	if _, err := email.ValidateValue(nil); err == nil {
+		t.Fatal("expected error for nil value")
+	}
+
+	withDefault := ldb.FieldTypeEmail{CreateDefaultValue: func() string { return "info@EXAMPLE.com" }}
+	if value, err := withDefault.ValidateValue(nil); err != nil || value != "info@example.com" {
+		t.Fatalf("expected normalized default, got %v (%v)", value, err)
+	}
+
+	if value, err := (ldb.FieldTypeEmail{Nullable: true}).ValidateValue(nil); err != nil || value != nil {
+		t.Fatalf("expected nil, got %v (%v)", value, err)
+	}
+}
+
+func TestFieldTypeURL(t *testing.T) {
+	link := ldb.FieldTypeURL{CreateAllowedSchemes: func() []string { return []string{"http", "https"} }}
+
+	if value, err := link.ValidateValue("HTTPS://Example.COM/Path?q=A"); err != nil || value != "https://example.com/Path?q=A" {
+		t.Fatalf("expected normalized URL, got %v (%v)", value, err)
+	}
+
+	for _, value := range []any{"", "example.com", "/relative/path", "https://", "http://exa mple.com", "ftp://example.com/file", 1} {
+		if _, err := link.ValidateValue(value); err == nil {
+			t.Fatalf("expected %q to be invalid", value)
+		}
+	}
+
+	if _, err := link.ValidateValue("ftp://example.com"); err == nil || !strings.Contains(err.Error(), "http or https") {
+		t.Fatalf("expected scheme error, got %v", err)
+	}
+
+	if _, err := (ldb.FieldTypeURL{}).ValidateValue("ftp://example.com/file"); err != nil {
+		t.Fatalf("expected all schemes to be accepted, got %v", err)
+	}
+
+	if value, err := (ldb.FieldTypeURL{Nullable: true}).ValidateValue(nil); err != nil || value != nil {
+		t.Fatalf("expected nil, got %v (%v)", value, err)
+	}
+}
+
+func TestFieldTypeIP(t *testing.T) {
+	ip := ldb.FieldTypeIP{}
+	cidr := ldb.FieldTypeIP{AllowCIDR: true}
+
+	valid := []struct {
+		fieldType ldb.FieldTypeIP
+		value     any
+		expected  string
+	}{
+		{ip, "192.168.0.1", "192.168.0.1"},
+		{ip, "0:0:0:0:0:0:0:1", "::1"},
+		{ip, "2001:DB8::1", "2001:db8::1"},
+		{ip, netip.MustParseAddr("10.0.0.1"), "10.0.0.1"},
+		{cidr, "10.1.2.3/8", "10.0.0.0/8"},
+		{cidr, "2001:db8::/32", "2001:db8::/32"},
+		{cidr, "127.0.0.1", "127.0.0.1"},
+	}
+
+	for _, test := range valid {
+		value, err := test.fieldType.ValidateValue(test.value)
+		if err != nil || value != test.expected {
+			t.Fatalf("expected %q for %v, got %v (%v)", test.expected, test.value, value, err)
+		}
+	}
+
+	for _, value := range []any{"", "256.0.0.1", "1.2.3", "10.0.0.0/8", "example.com", netip.MustParsePrefix("10.0.0.0/8"), 1} {
+		if _, err := ip.ValidateValue(value); err == nil {
+			t.Fatalf("expected %v to be invalid", value)
+		}
+	}
+
+	if _, err := cidr.ValidateValue("10.0.0.0/33"); err == nil || !strings.Contains(err.Error(), "CIDR range") {
+		t.Fatalf("expected CIDR error, got %v", err)
+	}
+
+	if value, err := (ldb.FieldTypeIP{Nullable: true}).ValidateValue(nil); err != nil || value != nil {
+		t.Fatalf("expected nil, got %v (%v)", value, err)
+	}
+}
+
+func TestFieldTypeDuration(t *testing.T) {
+	timeout := ldb.FieldTypeDuration{
+		CreateMinValue: func() time.Duration { return time.Second },
+		CreateMaxValue: func() time.Duration { return 2 * time.Hour },
+	}
+
+	for value, expected := range map[any]time.Duration{
+		90 * time.Minute:     90 * time.Minute,
+		"1h30m":              90 * time.Minute,
+		int64(5_000_000_000): 5 * time.Second,
+		3_000_000_000.0:      3 * time.Second,
+	} {
+		normalized, err := timeout.ValidateValue(value)
+		if err != nil || normalized != expected {
+			t.Fatalf("expected %v for %v, got %v (%v)", expected, value, normalized, err)
+		}
+	}
+
+	for _, value := range []any{"90 minutes", "", true, 1.5, "500ms", "3h"} {
+		if _, err := timeout.ValidateValue(value); err == nil {
+			t.Fatalf("expected %v to be invalid", value)
+		}
+	}
+
+	withDefault := ldb.FieldTypeDuration{CreateDefaultValue: func() time.Duration { return time.Minute }}
+	if value, err := withDefault.ValidateValue(nil); err != nil || value != time.Minute {
+		t.Fatalf("expected default, got %v (%v)", value, err)
+	}
+}
+
+func TestFieldTypePoint(t *testing.T) {
+	location := ldb.FieldTypePoint{}
+	berlin := ldb.Point{Lat: 52.52, Lng: 13.405}
+
+	for _, value := range []any{
+		berlin,
+		[2]float64{52.52, 13.405},
+		[]any{json.Number("52.52"), 13.405},
+		map[string]any{"lat": 52.52, "lng": json.Number("13.405")},
+		"POINT (13.405 52.52)",
+	} {
+		normalized, err := location.ValidateValue(value)
+		if err != nil || normalized != berlin {
+			t.Fatalf("expected %v for %v, got %v (%v)", berlin, value, normalized, err)
+		}
+	}
+
+	for _, value := range []any{
+		ldb.Point{Lat: 91},
+		[2]float64{0, -180.5},
+		[]float64{1},
+		map[string]any{"lat": 1, "lon": 2},
+		"POINT (1)",
+		"52.52, 13.405",
+		[]any{"52.52", "13.405"},
+	} {
+		if _, err := location.ValidateValue(value); err == nil {
+			t.Fatalf("expected %v to be invalid", value)
+		}
+	}
+
+	if _, err := location.ValidateValue(nil); err == nil {
+		t.Fatal("expected nil to be invalid")
+	}
+}
+
+func TestFieldTypeArray(t *testing.T) {
+	matrix := ldb.FieldTypeArray{Element: ldb.FieldTypeArray{Element: ldb.FieldTypeInt{}}}
+
+	value, err := matrix.ValidateValue([][]int{{1, 2}, {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []any{[]any{int64(1), int64(2)}, []any{}}; !reflect.DeepEqual(value, expected) {
+		t.Fatalf("expected %v, got %v", expected, value)
+	}
+
+	_, err = matrix.ValidateValue([]any{[]any{1}, []any{2, "three"}})
+	if err == nil || !strings.Contains(err.Error(), "index 1: index 1: ") {
+		t.Fatalf("expected error naming the indexes, got %v", err)
+	}
+
+	for _, value := range []any{"[1, 2]", 1, []any{nil}} {
+		if _, err := matrix.ValidateValue(value); err == nil {
+			t.Fatalf("expected %v to be invalid", value)
+		}
+	}
+
+	if _, err := (ldb.FieldTypeArray{}).ValidateValue([]any{}); err == nil {
+		t.Fatal("expected array without element type to be invalid")
+	}
+}
+
+func TestFieldTypeEnumConfig(t *testing.T) {
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	tests := map[string][]string{
+		"empty":     {},
+		"duplicate": {"a", "b", "a"},
+		"blank":     {"a", ""},
+	}
+
+	for name, values := range tests {
+		collection := ldb.Collection{
+			Name: "enums",
+			Schema: &ldb.CollectionSchema{
+				Fields: []*ldb.Field{
+					{Name: "state", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeEnum{EnumValues: values}}},
+				},
+			},
+		}
+
+		if err := tx.SaveCollection(collection); err == nil || !strings.Contains(err.Error(), "configuration error") {
+			t.Fatalf("%s: expected configuration error, got %v", name, err)
+		}
+	}
+}
+
+func TestFieldTypeEnumCaseInsensitive(t *testing.T) {
+	fieldType := ldb.FieldTypeEnum{EnumValues: []string{"draft", "Live"}, CaseInsensitive: true}
+
+	tests := map[string]string{
+		"draft": "draft",
+		"DRAFT": "draft",
+		"Draft": "draft",
+		"live":  "Live",
+		"LiVe":  "Live",
+	}
+
+	for input, expected := range tests {
+		if value, err := fieldType.ValidateValue(input); err != nil || value != expected {
+			t.Errorf("%s: expected %q, got %v, %v", input, expected, value, err)
+		}
+	}
+
+	if _, err := fieldType.ValidateValue("archived"); err == nil {
+		t.Error("expected unknown value to be rejected")
+	}
+
+	sensitive := ldb.FieldTypeEnum{EnumValues: []string{"draft"}}
+	if _, err := sensitive.ValidateValue("DRAFT"); err == nil {
+		t.Error("expected case-sensitive enum to reject DRAFT")
+	}
+
+	adapter := openTestAdapter(t)
+	defer adapter.Close()
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	collection := ldb.Collection{
+		Name: "enums",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "state", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeEnum{EnumValues: []string{"a", "A"}, CaseInsensitive: true}}},
+			},
+		},
+	}
+
+	if err := tx.SaveCollection(collection); err == nil || !strings.Contains(err.Error(), "duplicate enum value") {
+		t.Fatalf("expected duplicate enum value, got %v", err)
+	}
+}
+
+func TestFieldTypeTextPattern(t *testing.T) {
+	fieldType := ldb.FieldTypeText{CreatePattern: func() string { return `^[a-z]+$` }}
+
+	if value, err := fieldType.ValidateValue("abc"); err != nil || value != "abc" {
+		t.Fatalf("expected matching value to be accepted, got %v, %v", value, err)
+	}
+
+	for _, value := range []string{"ABC", "abc1", ""} {
+		if _, err := fieldType.ValidateValue(value); err == nil || !strings.Contains(err.Error(), "does not match pattern") {
+			t.Fatalf("expected %q to be rejected, got %v", value, err)
+		}
+	}
+
+	invalid := ldb.FieldTypeText{CreatePattern: func() string { return `[` }}
+	if _, err := invalid.ValidateValue("abc"); err == nil || !strings.Contains(err.Error(), "configuration error") {
+		t.Fatalf("expected configuration error, got %v", err)
+	}
+}
+
+func TestFieldTypeDateTimeBounds(t *testing.T) {
+	minValue := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxValue := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	fieldType := ldb.FieldTypeDateTime{
+		CreateMinValue: func() time.Time { return minValue },
+		CreateMaxValue: func() time.Time { return maxValue },
+	}
+
+	// bounds are inclusive
+	for _, value := range []time.Time{minValue, maxValue, minValue.AddDate(0, 6, 0)} {
+		if _, err := fieldType.ValidateValue(value); err != nil {
+			t.Fatalf("expected %v to be accepted, got %v", value, err)
+		}
+	}
+
+	_, err := fieldType.ValidateValue(minValue.Add(-time.Second))
+	if err == nil || err.Error() != "value too early, min value is 2024-01-01T00:00:00Z" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	_, err = fieldType.ValidateValue(maxValue.Add(time.Second))
+	if err == nil || err.Error() != "value too late, max value is 2024-12-31T00:00:00Z" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestFieldTypeIntCoercion(t *testing.T) {
+	fieldType := ldb.FieldTypeInt{}
+
+	for _, value := range []any{int(42), int8(42), int16(42), int32(42), int64(42), uint(42), uint8(42), uint16(42), uint32(42), uint64(42), float32(42), float64(42)} {
+		result, err := fieldType.ValidateValue(value)
+		if err != nil {
+			t.Fatalf("expected %T to be accepted, got %v", value, err)
+		}
+
+		if result != int64(42) {
+			t.Fatalf("expected int64(42) for %T, got %#v", value, result)
+		}
+	}
+
+	for _, value := range []any{4.2, uint64(math.MaxUint64), float64(math.MaxInt64), "42"} {
+		if _, err := fieldType.ValidateValue(value); err == nil {
+			t.Fatalf("expected %T %v to be rejected", value, value)
+		}
+	}
+}
+
+func TestParseNumericStrings(t *testing.T) {
+	if value, err := (ldb.FieldTypeInt{ParseStrings: true}).ValidateValue("42"); err != nil || value != int64(42) {
+		t.Fatalf("expected 42, got %v, %v", value, err)
+	}
+
+	if value, err := (ldb.FieldTypeFloat{ParseStrings: true}).ValidateValue("3.14"); err != nil || value != 3.14 {
+		t.Fatalf("expected 3.14, got %v, %v", value, err)
+	}
+
+	if _, err := (ldb.FieldTypeInt{ParseStrings: true}).ValidateValue("4.2"); err == nil || !strings.Contains(err.Error(), `"4.2"`) {
+		t.Fatalf("expected parse error quoting the input, got %v", err)
+	}
+
+	if _, err := (ldb.FieldTypeFloat{ParseStrings: true}).ValidateValue("abc"); err == nil || !strings.Contains(err.Error(), `"abc"`) {
+		t.Fatalf("expected parse error quoting the input, got %v", err)
+	}
+
+	// strings are rejected unless enabled
+	if _, err := (ldb.FieldTypeInt{}).ValidateValue("42"); err == nil {
+		t.Fatal("expected string to be rejected")
+	}
+
+	if _, err := (ldb.FieldTypeFloat{}).ValidateValue("3.14"); err == nil {
+		t.Fatal("expected string to be rejected")
+	}
+}
+
+func TestFieldTypeValidators(t *testing.T) {
+	iban := ldb.FieldTypeText{
+		Nullable:        true,
+		CreateMaxLength: func() int { return 34 },
+		CreateValidators: func() []func(value any) error {
+			return []func(value any) error{
+				func(value any) error {
+					if !strings.HasPrefix(value.(string), "DE") {
+						return errors.New("invalid value, expected german IBAN")
+					}
+
+					return nil
+				},
+			}
+		},
+	}
+
+	if _, err := iban.ValidateValue("DE89370400440532013000"); err != nil {
+		t.Fatal(err)
+	}
+
+	// validators are not run for null values
+	if value, err := iban.ValidateValue(nil); err != nil || value != nil {
+		t.Fatalf("expected nil, got %v (%v)", value, err)
+	}
+
+	// built-in validation runs first
+	if _, err := iban.ValidateValue(strings.Repeat("X", 35)); err == nil || strings.Contains(err.Error(), "IBAN") {
+		t.Fatalf("expected length error, got %v", err)
+	}
+
+	even := ldb.FieldTypeInt{
+		ParseStrings: true,
+		CreateValidators: func() []func(value any) error {
+			return []func(value any) error{
+				func(value any) error {
+					if value.(int64)%2 != 0 {
+						return errors.New("invalid value, expected even number")
+					}
+
+					return nil
+				},
+			}
+		},
+	}
+
+	// validators receive the converted value
+	if value, err := even.ValidateValue("4"); err != nil || value != int64(4) {
+		t.Fatalf("expected 4, got %v (%v)", value, err)
+	}
+
+	err := ldb.ValidateRecord(map[string]ldb.FieldType{"iban": iban, "count": even}, map[string]any{"iban": "GB29NWBK60161331926819", "count": int64(3)})
+
+	var validationErr *ldb.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	fields := validationErr.Fields()
+	if fields["iban"] != "invalid value, expected german IBAN" || fields["count"] != "invalid value, expected even number" {
+		t.Fatalf("unexpected field errors %v", fields)
+	}
+}
+
+func TestFieldTypeEncodeDecode(t *testing.T) {
+	publishedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		ft       ldb.FieldType
+		value    any
+		encoded  any
+		expected any
+	}{
+		{"text", ldb.FieldTypeText{}, "abc", "abc", "abc"},
+		{"datetime", ldb.FieldTypeDateTime{}, publishedAt, publishedAt, publishedAt},
+		{"duration", ldb.FieldTypeDuration{}, time.Minute, int64(time.Minute), time.Minute},
+		{"point", ldb.FieldTypePoint{}, ldb.Point{Lat: 1, Lng: 2}, "POINT (2 1)", ldb.Point{Lat: 1, Lng: 2}},
+		{"json", ldb.FieldTypeJSON{}, map[string]any{"a": []int{1}}, `{"a":[1]}`, map[string]any{"a": []any{json.Number("1")}}},
+		{"array", ldb.FieldTypeArray{Element: ldb.FieldTypeDuration{}}, []any{time.Second}, []any{int64(time.Second)}, []any{time.Second}},
+	}
+
+	for _, test := range tests {
+		encoded, err := test.ft.Encode(test.value)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if !reflect.DeepEqual(encoded, test.encoded) {
+			t.Errorf("%s: expected encoded %#v, got %#v", test.name, test.encoded, encoded)
+		}
+
+		decoded, err := test.ft.Decode(encoded)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if !reflect.DeepEqual(decoded, test.expected) {
+			t.Errorf("%s: expected decoded %#v, got %#v", test.name, test.expected, decoded)
+		}
+	}
+
+	// storage representations of adapters
+	if value, err := (ldb.FieldTypeDateTime{}).Decode("2024-01-02T03:04:05.000000000Z"); err != nil || !value.(time.Time).Equal(publishedAt) {
+		t.Errorf("expected %v, got %v (%v)", publishedAt, value, err)
+	}
+
+	if value, err := (ldb.FieldTypeBool{}).Decode(int64(1)); err != nil || value != true {
+		t.Errorf("expected true, got %v (%v)", value, err)
+	}
+
+	if _, err := (ldb.FieldTypeInt{}).Encode("abc"); err == nil {
+		t.Error("expected invalid value to be rejected by Encode")
+	}
+
+	// serial values may be left to the database
+	for value, expected := range map[any]any{nil: nil, 7: int64(7), "8": int64(8), 9.0: int64(9)} {
+		if validated, err := (ldb.FieldTypeSerial{}).ValidateValue(value); err != nil || validated != expected {
+			t.Errorf("expected %v, got %v (%v)", expected, validated, err)
+		}
+	}
+
+	if _, err := (ldb.FieldTypeSerial{}).ValidateValue(1.5); err == nil {
+		t.Error("expected non-integer serial value to be rejected")
+	}
+}
+
+func TestFieldTypeString(t *testing.T) {
+	tests := map[string]ldb.FieldType{
+		"id(primaryKey)":                                          ldb.FieldTypeId{PrimaryKey: true},
+		"text(nullable, maxLen=255)":                              ldb.FieldTypeText{Nullable: true, CreateMaxLength: func() int { return 255 }},
+		"int(min=1, default)":                                     ldb.FieldTypeInt{CreateMinValue: func() int64 { return 1 }, CreateDefaultValue: func() int64 { return 1 }},
+		"enum(draft|live, native)":                                ldb.FieldTypeEnum{EnumValues: []string{"draft", "live"}, Storage: ldb.EnumStorageNative},
+		"singleRelation->users(cascade)":                          ldb.FieldTypeSingleRelation{Collection: "users", CascadeDelete: true},
+		"singleRelation->users(nullable, setNull, cascadeUpdate)": ldb.FieldTypeSingleRelation{Nullable: true, Collection: "users", OnDelete: ldb.DeleteSetNull, CascadeUpdate: true},
+		`computed<int>("a + b", stored)`:                          ldb.FieldTypeComputed{Expression: "a + b", Base: ldb.FieldTypeInt{}, Stored: true},
+		"decimal(precision=10, scale=2)":                          ldb.FieldTypeDecimal{Precision: 10, Scale: 2},
+		"duration(max=1h0m0s)":                                    ldb.FieldTypeDuration{CreateMaxValue: func() time.Duration { return time.Hour }},
+		"array<text(maxLen=3)>(nullable)":                         ldb.FieldTypeArray{Nullable: true, Element: ldb.FieldTypeText{CreateMaxLength: func() int { return 3 }}},
+		"url(schemes=http|https, validators)":                     ldb.FieldTypeURL{CreateAllowedSchemes: func() []string { return []string{"http", "https"} }, CreateValidators: func() []func(value any) error { return nil }},
+		"multiRelation->tags":                                     ldb.FieldTypeMultiRelation{Collection: "tags"},
+		"serial(primaryKey)":                                      ldb.FieldTypeSerial{PrimaryKey: true},
+	}
+
+	for expected, fieldType := range tests {
+		if str := fieldType.String(); str != expected {
+			t.Errorf("expected %q, got %q", expected, str)
+		}
+	}
+
+	collection := ldb.Collection{
+		Name: "posts",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}, Unique: true}},
+			},
+			Indexes:           []ldb.IndexSchema{{Name: "posts_title", Columns: []string{"title", "id"}}},
+			UniqueConstraints: [][]string{{"id", "title"}},
+			Timestamps:        true,
+		},
+	}
+
+	expected := "posts (\n\tid id(primaryKey)\n\ttitle text unique\n\tunique(id, title)\n\tindex posts_title(title, id)\n) timestamps"
+	if str := collection.String(); str != expected {
+		t.Errorf("expected %q, got %q", expected, str)
+	}
+}
+
+func TestFieldTypeEqual(t *testing.T) {
+	maxLength := func() int { return 10 }
+
+	equal := [][2]ldb.FieldType{
+		{ldb.FieldTypeText{}, ldb.FieldTypeText{CreateMaxLength: maxLength}},
+		{ldb.FieldTypeEnum{EnumValues: []string{"a", "b"}}, ldb.FieldTypeEnum{EnumValues: []string{"a", "b"}}},
+		{ldb.FieldTypeSingleRelation{Collection: "users"}, ldb.FieldTypeSingleRelation{Collection: "users"}},
+		{ldb.FieldTypeSingleRelation{Collection: "users", CascadeDelete: true}, ldb.FieldTypeSingleRelation{Collection: "users", OnDelete: ldb.DeleteCascade}},
+		// functions of element types are ignored as well
+		{ldb.FieldTypeArray{Element: ldb.FieldTypeText{CreateMaxLength: maxLength}}, ldb.FieldTypeArray{Element: ldb.FieldTypeText{}}},
+	}
+
+	for _, pair := range equal {
+		if !pair[0].Equal(pair[1]) || !pair[1].Equal(pair[0]) {
+			t.Errorf("expected %v to equal %v", pair[0], pair[1])
+		}
+	}
+
+	different := [][2]ldb.FieldType{
+		{ldb.FieldTypeText{}, ldb.FieldTypeEmail{}},
+		{ldb.FieldTypeText{}, ldb.FieldTypeText{Nullable: true}},
+		{ldb.FieldTypeEnum{EnumValues: []string{"a", "b"}}, ldb.FieldTypeEnum{EnumValues: []string{"b", "a"}}},
+		{ldb.FieldTypeSingleRelation{Collection: "users"}, ldb.FieldTypeSingleRelation{Collection: "teams"}},
+		{ldb.FieldTypeSingleRelation{Collection: "users"}, ldb.FieldTypeSingleRelation{Collection: "users", CascadeUpdate: true}},
+		{ldb.FieldTypeDecimal{Precision: 10, Scale: 2}, ldb.FieldTypeDecimal{Precision: 10, Scale: 3}},
+		{ldb.FieldTypeArray{Element: ldb.FieldTypeText{}}, ldb.FieldTypeArray{Element: ldb.FieldTypeInt{}}},
+	}
+
+	for _, pair := range different {
+		if pair[0].Equal(pair[1]) || pair[1].Equal(pair[0]) {
+			t.Errorf("expected %v to differ from %v", pair[0], pair[1])
+		}
+	}
+
+	collection := *testPosts.Clone()
+	if !collection.Equal(testPosts) {
+		t.Fatal("expected clone to equal the original collection")
+	}
+
+	collection.Schema.Fields[1].Schema.Unique = true
+	if collection.Equal(testPosts) {
+		t.Error("expected collections with different fields to differ")
+	}
+
+	collection = *testPosts.Clone()
+	collection.Schema.Indexes = []ldb.IndexSchema{{Name: "posts_title", Columns: []string{"title"}}}
+	if collection.Equal(testPosts) {
+		t.Error("expected collections with different indexes to differ")
+	}
+
+	collection = *testPosts.Clone()
+	collection.Schema.UniqueConstraints = [][]string{{"title", "state"}}
+	if collection.Equal(testPosts) {
+		t.Error("expected collections with different unique constraints to differ")
+	}
+}
@@ -0,0 +1,639 @@
+package ldb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// JSON representation of collections, e.g. for schemas kept in config files.
+// Field types are encoded as tagged union using the "type" key. Constraints
+// given by functions (min and max values, lengths and patterns) are evaluated
+// once and stored as constants. Default values are only stored for fields
+// with DatabaseDefault since other defaults (like timestamps or generated ids)
+// cannot be represented. Access control callbacks are omitted.
+
+type collectionJSON struct {
+	Name    string      `json:"name"`
+	Fields  []fieldJSON `json:"fields"`
+	Indexes []indexJSON `json:"indexes,omitempty"`
+	// names of the fields forming a composite primary key
+	PrimaryKey         []string `json:"primaryKey,omitempty"`
+	RelationPrimaryKey bool     `json:"relationPrimaryKey,omitempty"`
+	// column sets of the unique constraints
+	UniqueConstraints [][]string `json:"uniqueConstraints,omitempty"`
+
+	Timestamps bool `json:"timestamps,omitempty"`
+	Versioned  bool `json:"versioned,omitempty"`
+}
+
+type indexJSON struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+type fieldJSON struct {
+	Name string `json:"name"`
+	// id, text, int, float, bool, datetime, enum, singleRelation, json, uuid,
+	// decimal, bytes, multiRelation, email, url, ip, duration, point, array,
+	// computed or serial; the other keys depend on the type; durations are
+	// given in nanoseconds and the element type of arrays is given as
+	// nameless field
+	Type string `json:"type"`
+
+	Unique          bool              `json:"unique,omitempty"`
+	DatabaseDefault bool              `json:"databaseDefault,omitempty"`
+	Default         json.RawMessage   `json:"default,omitempty"`
+	DatabaseCheck   bool              `json:"databaseCheck,omitempty"`
+	After           string            `json:"after,omitempty"`
+	Description     string            `json:"description,omitempty"`
+	Meta            map[string]string `json:"meta,omitempty"`
+
+	Nullable        bool            `json:"nullable,omitempty"`
+	PrimaryKey      bool            `json:"primaryKey,omitempty"`
+	Min             json.RawMessage `json:"min,omitempty"`
+	Max             json.RawMessage `json:"max,omitempty"`
+	MinLength       json.RawMessage `json:"minLength,omitempty"`
+	MaxLength       json.RawMessage `json:"maxLength,omitempty"`
+	Pattern         json.RawMessage `json:"pattern,omitempty"`
+	ParseStrings    bool            `json:"parseStrings,omitempty"`
+	Values          []string        `json:"values,omitempty"`
+	Storage         string          `json:"storage,omitempty"`
+	CaseInsensitive bool            `json:"caseInsensitive,omitempty"`
+	Collection      string          `json:"collection,omitempty"`
+	CascadeDelete   bool            `json:"cascadeDelete,omitempty"`
+	OnDelete        string          `json:"onDelete,omitempty"`
+	CascadeUpdate   bool            `json:"cascadeUpdate,omitempty"`
+	Precision       int             `json:"precision,omitempty"`
+	Scale           int             `json:"scale,omitempty"`
+	Schemes         []string        `json:"schemes,omitempty"`
+	AllowCIDR       bool            `json:"allowCidr,omitempty"`
+	SRID            int             `json:"srid,omitempty"`
+	Element         *fieldJSON      `json:"element,omitempty"`
+	Expression      string          `json:"expression,omitempty"`
+	Base            *fieldJSON      `json:"base,omitempty"`
+	Stored          bool            `json:"stored,omitempty"`
+	// only given if different from the zero value
+	IdConfig *idConfigJSON `json:"idConfig,omitempty"`
+}
+
+type idConfigJSON struct {
+	Length          int    `json:"length"`
+	Alphabet        string `json:"alphabet"`
+	Timestamp       bool   `json:"timestamp,omitempty"`
+	CaseInsensitive bool   `json:"caseInsensitive,omitempty"`
+}
+
+// encodes the given id config; returns nil for the zero value
+func marshalIdConfig(config IdConfig) *idConfigJSON {
+	if config == (IdConfig{}) {
+		return nil
+	}
+
+	return &idConfigJSON{Length: config.Length, Alphabet: config.Alphabet, Timestamp: config.Timestamp, CaseInsensitive: config.CaseInsensitive}
+}
+
+// decodes the given id config; returns the zero value for nil
+func unmarshalIdConfig(data *idConfigJSON) IdConfig {
+	if data == nil {
+		return IdConfig{}
+	}
+
+	return IdConfig{Length: data.Length, Alphabet: data.Alphabet, Timestamp: data.Timestamp, CaseInsensitive: data.CaseInsensitive}
+}
+
+var enumStorageNames = map[EnumStorage]string{
+	EnumStorageText:   "text",
+	EnumStorageNative: "native",
+}
+
+// cascading deletes are encoded as cascadeDelete
+var deleteActionNames = map[DeleteAction]string{
+	DeleteNoAction: "noAction",
+	DeleteCascade:  "cascade",
+	DeleteSetNull:  "setNull",
+}
+
+// MarshalSchema encodes the given collection as JSON; see UnmarshalSchema.
+func MarshalSchema(collection Collection) ([]byte, error) {
+	data := collectionJSON{Name: collection.Name, Fields: []fieldJSON{}, PrimaryKey: collection.Schema.PrimaryKey, RelationPrimaryKey: collection.Schema.RelationPrimaryKey, UniqueConstraints: collection.Schema.UniqueConstraints, Timestamps: collection.Schema.Timestamps, Versioned: collection.Schema.Versioned}
+
+	for _, field := range collection.Schema.Fields {
+		encoded, err := marshalField(field)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+
+		data.Fields = append(data.Fields, encoded)
+	}
+
+	for _, index := range collection.Schema.Indexes {
+		data.Indexes = append(data.Indexes, indexJSON{Name: index.Name, Columns: index.Columns, Unique: index.Unique})
+	}
+
+	return json.MarshalIndent(data, "", "  ")
+}
+
+// UnmarshalSchema decodes a collection encoded by MarshalSchema. Unknown keys
+// are rejected so that typos in config files do not go unnoticed.
+func UnmarshalSchema(data []byte) (Collection, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var decoded collectionJSON
+	if err := decoder.Decode(&decoded); err != nil {
+		return Collection{}, err
+	}
+
+	collection := Collection{Name: decoded.Name, Schema: &CollectionSchema{Fields: []*Field{}, PrimaryKey: decoded.PrimaryKey, RelationPrimaryKey: decoded.RelationPrimaryKey, UniqueConstraints: decoded.UniqueConstraints, Timestamps: decoded.Timestamps, Versioned: decoded.Versioned}}
+
+	for _, encoded := range decoded.Fields {
+		field, err := unmarshalField(encoded)
+		if err != nil {
+			return Collection{}, fmt.Errorf("field %q: %w", encoded.Name, err)
+		}
+
+		collection.Schema.Fields = append(collection.Schema.Fields, field)
+	}
+
+	for _, index := range decoded.Indexes {
+		collection.Schema.Indexes = append(collection.Schema.Indexes, IndexSchema{Name: index.Name, Columns: index.Columns, Unique: index.Unique})
+	}
+
+	return collection, nil
+}
+
+func marshalField(field *Field) (fieldJSON, error) {
+	data := fieldJSON{
+		Name:            field.Name,
+		Unique:          field.Schema.Unique,
+		DatabaseDefault: field.Schema.DatabaseDefault,
+		DatabaseCheck:   field.Schema.DatabaseCheck,
+		After:           field.Schema.After,
+		Description:     field.Schema.Description,
+		Meta:            field.Schema.Meta,
+	}
+
+	var err error
+	encode := func(value any) json.RawMessage {
+		if err != nil || value == nil {
+			return nil
+		}
+
+		var encoded []byte
+		encoded, err = json.Marshal(value)
+		return encoded
+	}
+
+	switch ft := field.Schema.Type.(type) {
+	case FieldTypeId:
+		data.Type = "id"
+		data.Nullable, data.PrimaryKey = ft.Nullable, ft.PrimaryKey
+		data.IdConfig = marshalIdConfig(ft.IdConfig)
+
+	case FieldTypeText:
+		data.Type = "text"
+		data.Nullable, data.CaseInsensitive = ft.Nullable, ft.CaseInsensitive
+		data.MinLength = encode(evaluate(ft.CreateMinLength))
+		data.MaxLength = encode(evaluate(ft.CreateMaxLength))
+		data.Pattern = encode(evaluate(ft.CreatePattern))
+
+	case FieldTypeInt:
+		data.Type = "int"
+		data.Nullable, data.ParseStrings = ft.Nullable, ft.ParseStrings
+		data.Min = encode(evaluate(ft.CreateMinValue))
+		data.Max = encode(evaluate(ft.CreateMaxValue))
+
+	case FieldTypeFloat:
+		data.Type = "float"
+		data.Nullable, data.ParseStrings = ft.Nullable, ft.ParseStrings
+		data.Min = encode(evaluate(ft.CreateMinValue))
+		data.Max = encode(evaluate(ft.CreateMaxValue))
+
+	case FieldTypeBool:
+		data.Type = "bool"
+		data.Nullable = ft.Nullable
+
+	case FieldTypeDateTime:
+		data.Type = "datetime"
+		data.Nullable = ft.Nullable
+		data.Min = encode(evaluate(ft.CreateMinValue))
+		data.Max = encode(evaluate(ft.CreateMaxValue))
+
+	case FieldTypeEnum:
+		data.Type = "enum"
+		data.Nullable, data.Values, data.CaseInsensitive = ft.Nullable, ft.EnumValues, ft.CaseInsensitive
+
+		if ft.Storage != EnumStorageText {
+			data.Storage = enumStorageNames[ft.Storage]
+		}
+
+	case FieldTypeSingleRelation:
+		data.Type = "singleRelation"
+		data.Nullable, data.Collection, data.CascadeUpdate = ft.Nullable, ft.Collection, ft.CascadeUpdate
+		data.IdConfig = marshalIdConfig(ft.IdConfig)
+
+		switch action := ft.deleteAction(); action {
+		case DeleteNoAction:
+		case DeleteCascade:
+			data.CascadeDelete = true
+		default:
+			data.OnDelete = deleteActionNames[action]
+		}
+
+	case FieldTypeJSON:
+		data.Type = "json"
+		data.Nullable = ft.Nullable
+
+	case FieldTypeUUID:
+		data.Type = "uuid"
+		data.Nullable, data.PrimaryKey = ft.Nullable, ft.PrimaryKey
+
+	case FieldTypeSerial:
+		data.Type = "serial"
+		data.PrimaryKey = ft.PrimaryKey
+
+	case FieldTypeDecimal:
+		data.Type = "decimal"
+		data.Nullable, data.Precision, data.Scale = ft.Nullable, ft.Precision, ft.Scale
+
+		if ft.CreateMinValue != nil {
+			data.Min = encode(decimalString(ft.CreateMinValue()))
+		}
+
+		if ft.CreateMaxValue != nil {
+			data.Max = encode(decimalString(ft.CreateMaxValue()))
+		}
+
+	case FieldTypeBytes:
+		data.Type = "bytes"
+		data.Nullable = ft.Nullable
+		data.MaxLength = encode(evaluate(ft.CreateMaxLength))
+
+	case FieldTypeMultiRelation:
+		data.Type = "multiRelation"
+		data.Collection, data.CascadeDelete = ft.Collection, ft.CascadeDelete
+		data.IdConfig = marshalIdConfig(ft.IdConfig)
+
+	case FieldTypeEmail:
+		data.Type = "email"
+		data.Nullable = ft.Nullable
+
+	case FieldTypeDuration:
+		data.Type = "duration"
+		data.Nullable = ft.Nullable
+		data.Min = encode(evaluate(ft.CreateMinValue))
+		data.Max = encode(evaluate(ft.CreateMaxValue))
+
+	case FieldTypeArray:
+		data.Type = "array"
+		data.Nullable = ft.Nullable
+
+		if ft.Element != nil {
+			var element fieldJSON
+			if element, err = marshalField(&Field{Schema: &FieldSchema{Type: ft.Element}}); err == nil {
+				data.Element = &element
+			}
+		}
+
+	case FieldTypeComputed:
+		data.Type = "computed"
+		data.Expression, data.Stored = ft.Expression, ft.Stored
+
+		if ft.Base != nil {
+			var base fieldJSON
+			if base, err = marshalField(&Field{Schema: &FieldSchema{Type: ft.Base}}); err == nil {
+				data.Base = &base
+			}
+		}
+
+	case FieldTypePoint:
+		data.Type = "point"
+		data.Nullable, data.SRID = ft.Nullable, ft.SRID
+
+	case FieldTypeIP:
+		data.Type = "ip"
+		data.Nullable, data.AllowCIDR = ft.Nullable, ft.AllowCIDR
+
+	case FieldTypeURL:
+		data.Type = "url"
+		data.Nullable = ft.Nullable
+
+		if ft.CreateAllowedSchemes != nil {
+			data.Schemes = ft.CreateAllowedSchemes()
+		}
+
+	default:
+		return data, fmt.Errorf("unsupported field type %T", field.Schema.Type)
+	}
+
+	// defaults stored as column DEFAULT are constant by definition
+	if field.Schema.DatabaseDefault && err == nil {
+		var value any
+		if value, err = field.Schema.Type.ValidateValue(nil); err == nil {
+			data.Default = encode(value)
+		}
+	}
+
+	return data, err
+}
+
+func unmarshalField(data fieldJSON) (*Field, error) {
+	var err error
+	decode := func(encoded json.RawMessage, dest any) bool {
+		if err != nil || encoded == nil {
+			return false
+		}
+
+		err = json.Unmarshal(encoded, dest)
+		return err == nil
+	}
+
+	var fieldType FieldType
+
+	switch data.Type {
+	case "id":
+		fieldType = FieldTypeId{Nullable: data.Nullable, PrimaryKey: data.PrimaryKey, IdConfig: unmarshalIdConfig(data.IdConfig)}
+
+	case "text":
+		ft := FieldTypeText{Nullable: data.Nullable, CaseInsensitive: data.CaseInsensitive}
+
+		var minLength, maxLength int
+		var pattern, defaultValue string
+		if decode(data.MinLength, &minLength) {
+			ft.CreateMinLength = constant(minLength)
+		}
+		if decode(data.MaxLength, &maxLength) {
+			ft.CreateMaxLength = constant(maxLength)
+		}
+		if decode(data.Pattern, &pattern) {
+			ft.CreatePattern = constant(pattern)
+		}
+		if decode(data.Default, &defaultValue) {
+			ft.CreateDefaultValue = constant(defaultValue)
+		}
+
+		fieldType = ft
+
+	case "int":
+		ft := FieldTypeInt{Nullable: data.Nullable, ParseStrings: data.ParseStrings}
+
+		var minValue, maxValue, defaultValue int64
+		if decode(data.Min, &minValue) {
+			ft.CreateMinValue = constant(minValue)
+		}
+		if decode(data.Max, &maxValue) {
+			ft.CreateMaxValue = constant(maxValue)
+		}
+		if decode(data.Default, &defaultValue) {
+			ft.CreateDefaultValue = constant(defaultValue)
+		}
+
+		fieldType = ft
+
+	case "float":
+		ft := FieldTypeFloat{Nullable: data.Nullable, ParseStrings: data.ParseStrings}
+
+		var minValue, maxValue, defaultValue float64
+		if decode(data.Min, &minValue) {
+			ft.CreateMinValue = constant(minValue)
+		}
+		if decode(data.Max, &maxValue) {
+			ft.CreateMaxValue = constant(maxValue)
+		}
+		if decode(data.Default, &defaultValue) {
+			ft.CreateDefaultValue = constant(defaultValue)
+		}
+
+		fieldType = ft
+
+	case "bool":
+		ft := FieldTypeBool{Nullable: data.Nullable}
+
+		var defaultValue bool
+		if decode(data.Default, &defaultValue) {
+			ft.CreateDefaultValue = constant(defaultValue)
+		}
+
+		fieldType = ft
+
+	case "datetime":
+		ft := FieldTypeDateTime{Nullable: data.Nullable}
+
+		var minValue, maxValue time.Time
+		if decode(data.Min, &minValue) {
+			ft.CreateMinValue = constant(minValue)
+		}
+		if decode(data.Max, &maxValue) {
+			ft.CreateMaxValue = constant(maxValue)
+		}
+
+		fieldType = ft
+
+	case "enum":
+		ft := FieldTypeEnum{Nullable: data.Nullable, EnumValues: data.Values, CaseInsensitive: data.CaseInsensitive}
+
+		if data.Storage != "" {
+			storage, ok := lo.FindKey(enumStorageNames, data.Storage)
+			if !ok {
+				return nil, fmt.Errorf("unknown enum storage %q", data.Storage)
+			}
+
+			ft.Storage = storage
+		}
+
+		var defaultValue string
+		if decode(data.Default, &defaultValue) {
+			ft.CreateDefaultValue = constant(defaultValue)
+		}
+
+		fieldType = ft
+
+	case "singleRelation":
+		ft := FieldTypeSingleRelation{Nullable: data.Nullable, Collection: data.Collection, CascadeDelete: data.CascadeDelete, CascadeUpdate: data.CascadeUpdate, IdConfig: unmarshalIdConfig(data.IdConfig)}
+
+		if data.OnDelete != "" {
+			action, ok := lo.FindKey(deleteActionNames, data.OnDelete)
+			if !ok {
+				return nil, fmt.Errorf("unknown delete action %q", data.OnDelete)
+			}
+
+			ft.OnDelete = action
+		}
+
+		fieldType = ft
+
+	case "json":
+		fieldType = FieldTypeJSON{Nullable: data.Nullable}
+
+	case "uuid":
+		fieldType = FieldTypeUUID{Nullable: data.Nullable, PrimaryKey: data.PrimaryKey}
+
+	case "serial":
+		fieldType = FieldTypeSerial{PrimaryKey: data.PrimaryKey}
+
+	case "decimal":
+		ft := FieldTypeDecimal{Nullable: data.Nullable, Precision: data.Precision, Scale: data.Scale}
+
+		var minValue, maxValue, defaultValue string
+		if decode(data.Min, &minValue) {
+			ft.CreateMinValue, err = parseDecimalConstant(minValue)
+		}
+		if decode(data.Max, &maxValue) {
+			ft.CreateMaxValue, err = parseDecimalConstant(maxValue)
+		}
+		if decode(data.Default, &defaultValue) {
+			ft.CreateDefaultValue, err = parseDecimalConstant(defaultValue)
+		}
+
+		fieldType = ft
+
+	case "bytes":
+		ft := FieldTypeBytes{Nullable: data.Nullable}
+
+		var maxLength int
+		if decode(data.MaxLength, &maxLength) {
+			ft.CreateMaxLength = constant(maxLength)
+		}
+
+		fieldType = ft
+
+	case "multiRelation":
+		fieldType = FieldTypeMultiRelation{Collection: data.Collection, CascadeDelete: data.CascadeDelete, IdConfig: unmarshalIdConfig(data.IdConfig)}
+
+	case "email":
+		ft := FieldTypeEmail{Nullable: data.Nullable}
+
+		var defaultValue string
+		if decode(data.Default, &defaultValue) {
+			ft.CreateDefaultValue = constant(defaultValue)
+		}
+
+		fieldType = ft
+
+	case "duration":
+		ft := FieldTypeDuration{Nullable: data.Nullable}
+
+		var minValue, maxValue time.Duration
+		if decode(data.Min, &minValue) {
+			ft.CreateMinValue = constant(minValue)
+		}
+		if decode(data.Max, &maxValue) {
+			ft.CreateMaxValue = constant(maxValue)
+		}
+
+		fieldType = ft
+
+	case "ip":
+		ft := FieldTypeIP{Nullable: data.Nullable, AllowCIDR: data.AllowCIDR}
+
+		var defaultValue string
+		if decode(data.Default, &defaultValue) {
+			ft.CreateDefaultValue = constant(defaultValue)
+		}
+
+		fieldType = ft
+
+	case "url":
+		ft := FieldTypeURL{Nullable: data.Nullable}
+
+		if data.Schemes != nil {
+			ft.CreateAllowedSchemes = constant(data.Schemes)
+		}
+
+		var defaultValue string
+		if decode(data.Default, &defaultValue) {
+			ft.CreateDefaultValue = constant(defaultValue)
+		}
+
+		fieldType = ft
+
+	case "point":
+		fieldType = FieldTypePoint{Nullable: data.Nullable, SRID: data.SRID}
+
+	case "array":
+		if data.Element == nil {
+			return nil, fmt.Errorf("expected element type")
+		}
+
+		element, err := unmarshalField(*data.Element)
+		if err != nil {
+			return nil, fmt.Errorf("element: %w", err)
+		}
+
+		fieldType = FieldTypeArray{Nullable: data.Nullable, Element: element.Schema.Type}
+
+	case "computed":
+		if data.Base == nil {
+			return nil, fmt.Errorf("expected base type")
+		}
+
+		base, err := unmarshalField(*data.Base)
+		if err != nil {
+			return nil, fmt.Errorf("base: %w", err)
+		}
+
+		fieldType = FieldTypeComputed{Expression: data.Expression, Base: base.Schema.Type, Stored: data.Stored}
+
+	default:
+		return nil, fmt.Errorf("unknown field type %q", data.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	field := &Field{
+		Name: data.Name,
+		Schema: &FieldSchema{
+			Type:            fieldType,
+			Unique:          data.Unique,
+			DatabaseDefault: data.DatabaseDefault,
+			DatabaseCheck:   data.DatabaseCheck,
+			After:           data.After,
+			Description:     data.Description,
+			Meta:            data.Meta,
+		},
+	}
+
+	return field, nil
+}
+
+// returns the result of fn or nil if fn is nil
+func evaluate[T any](fn func() T) any {
+	if fn == nil {
+		return nil
+	}
+
+	return fn()
+}
+
+// returns a function returning the given value
+func constant[T any](value T) func() T {
+	return func() T { return value }
+}
+
+// formats the given rational as exact decimal string if possible
+func decimalString(r *big.Rat) string {
+	if prec, exact := r.FloatPrec(); exact {
+		return r.FloatString(prec)
+	}
+
+	return r.RatString()
+}
+
+// returns a function returning a copy of the given decimal so that callers
+// cannot modify the constant
+func parseDecimalConstant(str string) (func() *big.Rat, error) {
+	r, ok := new(big.Rat).SetString(str)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", str)
+	}
+
+	return func() *big.Rat { return new(big.Rat).Set(r) }, nil
+}
@@ -0,0 +1,158 @@
+package ldb_test
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"lehnert.dev/ldb"
+)
+
+func TestSchemaJSONRoundTrip(t *testing.T) {
+	minTime := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	collection := ldb.Collection{
+		Name: "everything",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "text", Schema: &ldb.FieldSchema{Unique: true, Description: "lowercase word", Meta: map[string]string{"widget": "input"}, Type: ldb.FieldTypeText{
+					CreateMinLength: func() int { return 1 },
+					CreateMaxLength: func() int { return 8 },
+					CreatePattern:   func() string { return "^[a-z]+$" },
+				}}},
+				{Name: "int", Schema: &ldb.FieldSchema{DatabaseDefault: true, DatabaseCheck: true, Type: ldb.FieldTypeInt{
+					ParseStrings:       true,
+					CreateDefaultValue: func() int64 { return 3 },
+					CreateMinValue:     func() int64 { return 1 },
+					CreateMaxValue:     func() int64 { return 5 },
+				}}},
+				{Name: "float", Schema: &ldb.FieldSchema{After: "int", Type: ldb.FieldTypeFloat{Nullable: true, CreateMaxValue: func() float64 { return 1.5 }}}},
+				{Name: "bool", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeBool{Nullable: true}}},
+				{Name: "datetime", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeDateTime{
+					CreateDefaultValue: time.Now,
+					CreateMinValue:     func() time.Time { return minTime },
+				}}},
+				{Name: "enum", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeEnum{EnumValues: []string{"a", "b"}, Storage: ldb.EnumStorageNative, CaseInsensitive: true}}},
+				{Name: "relation", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Nullable: true, Collection: "authors", CascadeDelete: true}}},
+				{Name: "editor", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Nullable: true, Collection: "authors", OnDelete: ldb.DeleteSetNull, CascadeUpdate: true}}},
+				{Name: "json", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeJSON{}}},
+				{Name: "computed", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeComputed{Expression: "int + 1", Base: ldb.FieldTypeInt{}, Stored: true}}},
+				{Name: "uuid", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeUUID{Nullable: true}}},
+				{Name: "number", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSerial{}}},
+				{Name: "decimal", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeDecimal{
+					Precision:      10,
+					Scale:          2,
+					CreateMinValue: func() *big.Rat { return big.NewRat(-1, 4) },
+				}}},
+				{Name: "bytes", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeBytes{CreateMaxLength: func() int { return 4 }}}},
+				{Name: "tags", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeMultiRelation{Collection: "tags", IdConfig: ldb.IdConfig{Length: 8, Alphabet: "abc"}}}},
+				{Name: "ip", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeIP{Nullable: true, AllowCIDR: true}}},
+				{Name: "location", Schema: &ldb.FieldSchema{Type: ldb.FieldTypePoint{Nullable: true, SRID: 4326}}},
+				{Name: "matrix", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeArray{Element: ldb.FieldTypeArray{Nullable: true, Element: ldb.FieldTypeInt{}}}}},
+				{Name: "url", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeURL{CreateAllowedSchemes: func() []string { return []string{"https"} }}}},
+				{Name: "email", Schema: &ldb.FieldSchema{DatabaseDefault: true, Type: ldb.FieldTypeEmail{
+					Nullable:           true,
+					CreateDefaultValue: func() string { return "info@example.com" },
+				}}},
+			},
+			Indexes:           []ldb.IndexSchema{{Name: "by_int", Columns: []string{"int", "float"}, Unique: true}},
+			UniqueConstraints: [][]string{{"text", "enum"}},
+			Timestamps:        true,
+			Versioned:         true,
+		},
+	}
+
+	data, err := ldb.MarshalSchema(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := ldb.UnmarshalSchema(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := ldb.MarshalSchema(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, again) {
+		t.Fatalf("round trip changed the schema:\n%s\n%s", data, again)
+	}
+
+	if len(decoded.Schema.Indexes) != 1 || !decoded.Schema.Timestamps || !decoded.Schema.Versioned || decoded.Schema.Indexes[0].Name != "by_int" || !decoded.Schema.Fields[1].Schema.Unique {
+		t.Fatalf("unexpected decoded schema %s", again)
+	}
+
+	if !decoded.Equal(collection) {
+		t.Fatalf("expected decoded collection to equal the original, got %s", decoded)
+	}
+
+	if text := decoded.Schema.Fields[1].Schema; text.Description != "lowercase word" || len(text.Meta) != 1 || text.Meta["widget"] != "input" {
+		t.Fatalf("expected description and metadata to be restored, got %+v", text)
+	}
+
+	if after := decoded.Schema.Fields[3].Schema.After; after != "int" {
+		t.Fatalf("expected position hint int, got %q", after)
+	}
+
+	// constraints are restored as constant functions
+	fields := decoded.Fields()
+
+	if !fields["tags"].Equal(collection.Fields()["tags"]) {
+		t.Fatalf("expected id config to be restored, got %v", fields["tags"])
+	}
+
+	if value, err := fields["int"].ValidateValue(nil); err != nil || value != int64(3) {
+		t.Fatalf("expected database default 3, got %v (%v)", value, err)
+	}
+
+	if _, err := fields["int"].ValidateValue("9"); err == nil || !strings.Contains(err.Error(), "max value is 5") {
+		t.Fatalf("expected max value error, got %v", err)
+	}
+
+	if _, err := fields["text"].ValidateValue("ABC"); err == nil || !strings.Contains(err.Error(), "pattern") {
+		t.Fatalf("expected pattern error, got %v", err)
+	}
+
+	if _, err := fields["datetime"].ValidateValue(minTime.Add(-time.Hour)); err == nil {
+		t.Fatal("expected min value error")
+	}
+
+	// non-constant defaults are not stored
+	if _, err := fields["datetime"].ValidateValue(nil); err == nil {
+		t.Fatal("expected datetime to be required")
+	}
+
+	if value, err := fields["decimal"].ValidateValue("-0.25"); err != nil || value != "-0.25" {
+		t.Fatalf("expected valid decimal, got %v (%v)", value, err)
+	}
+
+	if _, err := fields["decimal"].ValidateValue("-0.26"); err == nil {
+		t.Fatal("expected min value error")
+	}
+
+	if enum := fields["enum"].(ldb.FieldTypeEnum); enum.Storage != ldb.EnumStorageNative || !enum.CaseInsensitive {
+		t.Fatalf("expected native case-insensitive enum, got %+v", enum)
+	}
+}
+
+func TestUnmarshalSchemaErrors(t *testing.T) {
+	cases := map[string]string{
+		`{"name": "a", "fields": [{"name": "x", "type": "unknown"}]}`:                 `unknown field type "unknown"`,
+		`{"name": "a", "fields": [{"name": "x", "type": "int", "mni": 1}]}`:           `unknown field "mni"`,
+		`{"name": "a", "fields": [{"name": "x", "type": "int", "min": "one"}]}`:       `field "x"`,
+		`{"name": "a", "fields": [{"name": "x", "type": "decimal", "max": "1.2.3"}]}`: `invalid decimal`,
+		`{"name": "a", "fields": [{"name": "x", "type": "enum", "storage": "x"}]}`:    `unknown enum storage`,
+	}
+
+	for data, expected := range cases {
+		if _, err := ldb.UnmarshalSchema([]byte(data)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error containing %q for %s, got %v", expected, data, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ldb
+
+import "sync"
+
+var _ DatabaseService = (*databaseService)(nil)
+
+// performs each operation in its own transaction on the given adapter
+type databaseService struct {
+	adapter DatabaseAdapter
+
+	mu sync.Mutex
+	// collections created by this service keyed by name; needed to drop
+	// resources belonging to a collection such as junction tables
+	collections map[string]*Collection
+}
+
+func NewDatabaseService(adapter DatabaseAdapter) DatabaseService {
+	return &databaseService{
+		adapter:     adapter,
+		collections: map[string]*Collection{},
+	}
+}
+
+// runs fn in a new transaction which is committed if fn succeeds
+func (s *databaseService) transaction(fn func(tx DatabaseTransaction) error) error {
+	tx, err := s.adapter.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateCollection implements DatabaseService.
+func (s *databaseService) CreateCollection(name string, schema CollectionSchema) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collection := Collection{Name: name, Schema: &schema}
+
+	err := s.transaction(func(tx DatabaseTransaction) error {
+		return tx.SaveCollection(collection)
+	})
+	if err != nil {
+		return err
+	}
+
+	collection.Forward()
+	s.collections[name] = &collection
+	return nil
+}
+
+// DropCollection implements DatabaseService.
+func (s *databaseService) DropCollection(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collection, ok := s.collections[name]
+	if !ok {
+		// collections created elsewhere are assumed to exist
+		collection = &Collection{Name: name, Schema: &CollectionSchema{}}
+		collection.Forward()
+	}
+
+	err := s.transaction(func(tx DatabaseTransaction) error {
+		return tx.DropCollection(*collection)
+	})
+	if err != nil {
+		return err
+	}
+
+	delete(s.collections, name)
+	return nil
+}
@@ -0,0 +1,53 @@
+package ldb_test
+
+import (
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestDatabaseService(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			service := ldb.NewDatabaseService(adapter)
+
+			if err := service.CreateCollection("authors", *testAuthors.Schema); err != nil {
+				t.Fatal(err)
+			}
+
+			insert := func() error {
+				tx, err := adapter.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer tx.Rollback()
+
+				_, err = tx.CreateRecord("authors", fieldTypes(testAuthors), map[string]any{"id": testId0, "name": "Jane"})
+				return err
+			}
+
+			if err := insert(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := service.CreateCollection("authors", *testAuthors.Schema); err == nil {
+				t.Fatal("expected error when creating existing collection")
+			}
+
+			if err := service.DropCollection("authors"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := insert(); err == nil {
+				t.Fatal("expected error when inserting into dropped collection")
+			}
+		})
+	}
+}
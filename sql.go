@@ -0,0 +1,1010 @@
+package ldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// helpers shared by adapters built on top of database/sql
+
+// quotes the given table or column name so that it is safe to be embedded
+// into SQL statements; embedded double quotes are escaped by doubling them
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quotes the given value as SQL string literal
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// returns the DEFAULT clause of a column if its default is stored in the
+// database; the default value is evaluated once at migration time
+func defaultSQL(schema *FieldSchema) string {
+	if !schema.DatabaseDefault {
+		return ""
+	}
+
+	value, err := schema.Type.ValidateValue(nil)
+	if err != nil {
+		return ""
+	}
+
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return " DEFAULT TRUE"
+		}
+
+		return " DEFAULT FALSE"
+
+	case int64:
+		return " DEFAULT " + strconv.FormatInt(v, 10)
+
+	case float64:
+		return " DEFAULT " + strconv.FormatFloat(v, 'g', -1, 64)
+
+	case string:
+		return " DEFAULT " + quoteLiteral(v)
+
+	default:
+		return ""
+	}
+}
+
+// returns the fields whose database default changed since the last migration
+func (c Collection) defaultChangedFields() []*Field {
+	return lo.Filter(c.Schema.Fields, func(field *Field, i int) bool {
+		return field.original != nil && !isMultiRelation(field.Schema.Type) && defaultSQL(field.original.Schema) != defaultSQL(field.Schema)
+	})
+}
+
+// reports whether the column of the given field type accepts null values;
+// primary keys never do
+func nullableColumn(fieldType FieldType) bool {
+	switch ft := fieldType.(type) {
+	case FieldTypeId:
+		return ft.Nullable && !ft.PrimaryKey
+	case FieldTypeUUID:
+		return ft.Nullable && !ft.PrimaryKey
+	case FieldTypeText:
+		return ft.Nullable
+	case FieldTypeInt:
+		return ft.Nullable
+	case FieldTypeFloat:
+		return ft.Nullable
+	case FieldTypeBool:
+		return ft.Nullable
+	case FieldTypeDateTime:
+		return ft.Nullable
+	case FieldTypeEnum:
+		return ft.Nullable
+	case FieldTypeSingleRelation:
+		return ft.Nullable
+	case FieldTypeJSON:
+		return ft.Nullable
+	case FieldTypeDecimal:
+		return ft.Nullable
+	case FieldTypeBytes:
+		return ft.Nullable
+	case FieldTypeEmail:
+		return ft.Nullable
+	case FieldTypeURL:
+		return ft.Nullable
+	case FieldTypeIP:
+		return ft.Nullable
+	case FieldTypeDuration:
+		return ft.Nullable
+	case FieldTypePoint:
+		return ft.Nullable
+	case FieldTypeArray:
+		return ft.Nullable
+	case FieldTypeSerial:
+		return false
+	default:
+		return true
+	}
+}
+
+// returns the fields whose NOT NULL constraint was added or removed since the
+// last migration
+func (c Collection) nullChangedFields() []*Field {
+	return lo.Filter(c.Schema.Fields, func(field *Field, i int) bool {
+		return field.original != nil && !isMultiRelation(field.Schema.Type) && !isMultiRelation(field.original.Schema.Type) &&
+			nullableColumn(field.original.Schema.Type) != nullableColumn(field.Schema.Type)
+	})
+}
+
+// ensures that fields becoming non-nullable hold no null values before their
+// NOT NULL constraint is added. If the collection is saved with null backfill,
+// returns the encoded default values replacing the null values keyed by field
+// name; the default value is evaluated once per field. Expects the table to be
+// renamed already, but not its columns. Null values are replaced by rebuilding
+// the table since DuckDB cannot add NOT NULL constraints to columns updated
+// within the same transaction.
+func nullBackfills(tx contextTx, codec valueCodec, collection Collection) (map[string]any, error) {
+	backfills := map[string]any{}
+
+	for _, field := range collection.nullChangedFields() {
+		if nullableColumn(field.Schema.Type) {
+			continue
+		}
+
+		var count int64
+		sql := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s IS NULL", tx.dialect.Quote(collection.Name), tx.dialect.Quote(field.original.Name))
+		if err := tx.QueryRow(sql).Scan(&count); err != nil {
+			return nil, err
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		if !collection.backfillNulls {
+			return nil, fmt.Errorf("field %q: cannot add NOT NULL constraint, %d records hold null values; enable null backfill to replace them by the default value", field.Name, count)
+		}
+
+		value, err := field.Schema.Type.Encode(nil)
+		if err != nil || value == nil {
+			return nil, fmt.Errorf("field %q: cannot backfill %d null values, expected a default value", field.Name, count)
+		}
+
+		backfills[field.Name] = codec.encodeValue(field.Schema.Type, value)
+	}
+
+	return backfills, nil
+}
+
+// returns the CHECK clause of a column if the bounds of its field type are
+// enforced by the database; the bounds are evaluated once at migration time.
+// Text lengths are checked in bytes since they are validated in bytes as well.
+func checkSQL(dialect Dialect, column string, schema *FieldSchema) string {
+	if !schema.DatabaseCheck {
+		return ""
+	}
+
+	ident := dialect.Quote(column)
+	conditions := []string{}
+
+	switch ft := schema.Type.(type) {
+	case FieldTypeInt:
+		if ft.CreateMinValue != nil {
+			conditions = append(conditions, ident+" >= "+strconv.FormatInt(ft.CreateMinValue(), 10))
+		}
+
+		if ft.CreateMaxValue != nil {
+			conditions = append(conditions, ident+" <= "+strconv.FormatInt(ft.CreateMaxValue(), 10))
+		}
+
+	case FieldTypeFloat:
+		if ft.CreateMinValue != nil {
+			if minValue := ft.CreateMinValue(); !math.IsInf(minValue, 0) && !math.IsNaN(minValue) {
+				conditions = append(conditions, ident+" >= "+strconv.FormatFloat(minValue, 'g', -1, 64))
+			}
+		}
+
+		if ft.CreateMaxValue != nil {
+			if maxValue := ft.CreateMaxValue(); !math.IsInf(maxValue, 0) && !math.IsNaN(maxValue) {
+				conditions = append(conditions, ident+" <= "+strconv.FormatFloat(maxValue, 'g', -1, 64))
+			}
+		}
+
+	case FieldTypeText:
+		length := dialect.ByteLength(ident)
+
+		switch {
+		case ft.CreateMinLength != nil && ft.CreateMaxLength != nil:
+			conditions = append(conditions, fmt.Sprintf("%s BETWEEN %d AND %d", length, ft.CreateMinLength(), ft.CreateMaxLength()))
+
+		case ft.CreateMinLength != nil:
+			conditions = append(conditions, fmt.Sprintf("%s >= %d", length, ft.CreateMinLength()))
+
+		case ft.CreateMaxLength != nil:
+			conditions = append(conditions, fmt.Sprintf("%s <= %d", length, ft.CreateMaxLength()))
+		}
+	}
+
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return " CHECK (" + strings.Join(conditions, " AND ") + ")"
+}
+
+// returns the fields whose CHECK clause changed since the last migration;
+// renamed fields are compared using their new name
+func (c Collection) checkChangedFields(dialect Dialect) []*Field {
+	return lo.Filter(c.Schema.Fields, func(field *Field, i int) bool {
+		return field.original != nil && !isMultiRelation(field.Schema.Type) &&
+			checkSQL(dialect, field.Name, field.original.Schema) != checkSQL(dialect, field.Name, field.Schema)
+	})
+}
+
+// returns the ON DELETE and ON UPDATE clauses of the foreign key of the given
+// relation
+func referentialActionsSQL(ft FieldTypeSingleRelation) string {
+	sql := ""
+	switch ft.deleteAction() {
+	case DeleteCascade:
+		sql += " ON DELETE CASCADE"
+	case DeleteSetNull:
+		sql += " ON DELETE SET NULL"
+	}
+
+	if ft.CascadeUpdate {
+		sql += " ON UPDATE CASCADE"
+	}
+
+	return sql
+}
+
+// returns the single relation fields whose referential actions changed since
+// the last migration; foreign keys cannot be altered, so their tables are
+// rebuilt
+func (c Collection) referenceChangedFields() []*Field {
+	actions := func(fieldType FieldType) string {
+		ft, ok := fieldType.(FieldTypeSingleRelation)
+		if !ok {
+			return ""
+		}
+
+		return referentialActionsSQL(ft)
+	}
+
+	return lo.Filter(c.Schema.Fields, func(field *Field, i int) bool {
+		return field.original != nil && actions(field.original.Schema.Type) != actions(field.Schema.Type)
+	})
+}
+
+// transaction of database/sql; implemented by *sql.Tx and connTx
+type sqlTx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	Commit() error
+	Rollback() error
+}
+
+// transaction started by executing statements on a dedicated connection; used
+// for transaction modes database/sql cannot express for the driver, e.g. read
+// only transactions of DuckDB
+type connTx struct {
+	*sql.Conn
+	// executed after the transaction ended, before the connection is returned
+	// to the pool; resets connection state set up for the transaction
+	reset string
+}
+
+// starts a transaction on a dedicated connection by executing the given
+// statements
+func beginConnTx(db *sql.DB, ctx context.Context, reset string, statements ...string) (connTx, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return connTx{}, err
+	}
+
+	tx := connTx{conn, reset}
+	for _, statement := range statements {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			tx.end("")
+			return connTx{}, err
+		}
+	}
+
+	return tx, nil
+}
+
+// executes the given statement, resets the connection and returns it to the
+// pool; the connection is discarded if it cannot be reset
+func (t connTx) end(statement string) error {
+	var err error
+	if statement != "" {
+		_, err = t.ExecContext(context.Background(), statement)
+	}
+
+	if t.reset != "" {
+		if _, resetErr := t.ExecContext(context.Background(), t.reset); resetErr != nil {
+			t.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}
+
+	t.Close()
+	return err
+}
+
+func (t connTx) Commit() error {
+	return t.end("COMMIT")
+}
+
+func (t connTx) Rollback() error {
+	return t.end("ROLLBACK")
+}
+
+// transaction whose statements are bound to the context it was started with
+type contextTx struct {
+	sqlTx
+	ctx context.Context
+
+	// records executed statements if not nil; used for dry runs
+	statements *[]string
+
+	dialect Dialect
+}
+
+func (t contextTx) Exec(query string, args ...any) (sql.Result, error) {
+	query = rebindPlaceholders(t.dialect, query)
+	if t.statements != nil {
+		*t.statements = append(*t.statements, query)
+	}
+
+	return t.ExecContext(t.ctx, query, args...)
+}
+
+// returns a copy of the recorded statements
+func (t contextTx) recordedStatements() []string {
+	if t.statements == nil {
+		return nil
+	}
+
+	return slices.Clone(*t.statements)
+}
+
+func (t contextTx) Query(query string, args ...any) (*sql.Rows, error) {
+	return t.QueryContext(t.ctx, rebindPlaceholders(t.dialect, query), args...)
+}
+
+func (t contextTx) QueryRow(query string, args ...any) *sql.Row {
+	return t.QueryRowContext(t.ctx, rebindPlaceholders(t.dialect, query), args...)
+}
+
+// rebuilds the table with the given name so that it only consists of the
+// given fields: a new table is created, the data of the fields that existed
+// before is copied over except into computed fields, the old table is dropped
+// and the new one is renamed to the original name. Null values of the fields
+// in backfills are replaced by the given encoded values while copying; see
+// nullBackfills. The table receives the composite primary key and the unique
+// constraints of the given schema. Indexes are dropped along with the old
+// table and have to be recreated by the caller; see indexStatements.
+func rebuildTable(tx contextTx, name string, fields []*Field, schema *CollectionSchema, backfills map[string]any) error {
+	dialect := tx.dialect
+	primaryKey := schema.PrimaryKey
+	tmpName := name + "_ldb_rebuild"
+
+	columns := []string{}
+	columnNames := []string{}
+	selects := []string{}
+	args := []any{}
+	for _, field := range fields {
+		definition, err := tableFieldSQL(dialect, name, field, primaryKey)
+		if err != nil {
+			return err
+		}
+
+		columns = append(columns, definition)
+
+		// generated columns cannot be written
+		if field.original == nil || isComputed(field.Schema.Type) {
+			continue
+		}
+
+		column := dialect.Quote(field.Name)
+		columnNames = append(columnNames, column)
+
+		value, ok := backfills[field.Name]
+		if !ok {
+			selects = append(selects, column)
+			continue
+		}
+
+		valueSQL, valueArgs := bindSQL(value)
+		selects = append(selects, "COALESCE("+column+", "+valueSQL+")")
+		args = append(args, valueArgs...)
+	}
+
+	columns = append(columns, tableConstraintsSQL(dialect, name, schema)...)
+
+	if _, err := tx.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", dialect.Quote(tmpName), strings.Join(columns, ", "))); err != nil {
+		return err
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", dialect.Quote(tmpName), strings.Join(columnNames, ", "), strings.Join(selects, ", "), dialect.Quote(name))
+	if _, err := tx.Exec(sql, args...); err != nil {
+		return err
+	}
+
+	for _, sql := range []string{
+		fmt.Sprintf("DROP TABLE %s", dialect.Quote(name)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", dialect.Quote(tmpName), dialect.Quote(name)),
+	} {
+		if _, err := tx.Exec(sql); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// returns the statements creating the table of a new collection together with
+// its junction tables and indexes; junctionSQL returns the statement creating
+// a junction table
+func createTableStatements(dialect Dialect, collection Collection, junctionSQL func(table string, collection string, ft FieldTypeMultiRelation) (string, error)) ([]string, error) {
+	columns := []string{}
+	for _, field := range collection.Schema.Fields {
+		if isMultiRelation(field.Schema.Type) {
+			continue
+		}
+
+		column, err := tableFieldSQL(dialect, collection.Name, field, collection.Schema.PrimaryKey)
+		if err != nil {
+			return nil, err
+		}
+
+		columns = append(columns, column)
+	}
+
+	columns = append(columns, tableConstraintsSQL(dialect, collection.Name, collection.Schema)...)
+
+	junctions, err := junctionStatements(dialect, collection, junctionSQL)
+	if err != nil {
+		return nil, err
+	}
+
+	_, createIndexes := indexStatements(dialect, collection, false)
+
+	statements := []string{fmt.Sprintf("CREATE TABLE %s (%s)", dialect.Quote(collection.Name), strings.Join(columns, ", "))}
+	statements = append(statements, junctions...)
+	return append(statements, createIndexes...), nil
+}
+
+// returns the column definition of the given field including its DEFAULT and
+// CHECK clauses
+func fieldSQL(dialect Dialect, field *Field) (string, error) {
+	sql, err := columnSQL(dialect, field.Name, field.Schema.Type)
+	if err != nil {
+		return "", fmt.Errorf("field %q: %w", field.Name, err)
+	}
+
+	return sql + defaultSQL(field.Schema) + checkSQL(dialect, field.Name, field.Schema), nil
+}
+
+// returns the column definition of the given field within the given table
+// having the given composite primary key; per-column primary keys are omitted
+// if there is a composite one. Serial columns default to the next value of
+// their sequence.
+func tableFieldSQL(dialect Dialect, table string, field *Field, primaryKey []string) (string, error) {
+	schema := *field.Schema
+	if len(primaryKey) > 0 {
+		switch ft := schema.Type.(type) {
+		case FieldTypeId:
+			ft.PrimaryKey = false
+			schema.Type = ft
+
+		case FieldTypeUUID:
+			ft.PrimaryKey = false
+			schema.Type = ft
+
+		case FieldTypeSerial:
+			ft.PrimaryKey = false
+			schema.Type = ft
+		}
+	}
+
+	sql, err := fieldSQL(dialect, &Field{Name: field.Name, Schema: &schema})
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := schema.Type.(FieldTypeSerial); ok {
+		if value := dialect.SerialValue(serialSequenceName(table, field.Name)); value != "" {
+			sql += " DEFAULT " + value
+		}
+	}
+
+	return sql, nil
+}
+
+// returns the name of the sequence of the given serial column
+func serialSequenceName(table string, column string) string {
+	return table + "_" + column + "_seq"
+}
+
+// sequence of a serial field; see serialSequenceChanges
+type serialSequence struct {
+	name  string
+	field *Field
+	// sequence of the field on last migration if the field has been serial
+	// before; empty otherwise
+	previous string
+}
+
+// returns the sequences to create for serial fields that are new or whose
+// sequence name changed since the last migration, e.g. since the table has
+// been renamed, and the names of the sequences to drop afterwards
+func (c Collection) serialSequenceChanges() (create []serialSequence, drop []string) {
+	previousName := func(field *Field) string {
+		if field.original == nil || !isSerial(field.original.Schema.Type) {
+			return ""
+		}
+
+		return serialSequenceName(c.original.Name, field.original.Name)
+	}
+
+	for _, field := range c.Schema.Fields {
+		name := ""
+		if isSerial(field.Schema.Type) {
+			name = serialSequenceName(c.Name, field.Name)
+		}
+
+		previous := previousName(field)
+		if name == previous {
+			continue
+		}
+
+		if name != "" {
+			create = append(create, serialSequence{name: name, field: field, previous: previous})
+		}
+
+		if previous != "" {
+			drop = append(drop, previous)
+		}
+	}
+
+	_, _, removeFields := c.fieldChanges()
+	for _, field := range removeFields {
+		if isSerial(field.Schema.Type) {
+			drop = append(drop, serialSequenceName(c.original.Name, field.Name))
+		}
+	}
+
+	return create, drop
+}
+
+func isSerial(fieldType FieldType) bool {
+	_, ok := fieldType.(FieldTypeSerial)
+	return ok
+}
+
+// returns the statement adding the column of the given field to the table of
+// the given collection, placed after the column given by its position hint if
+// the dialect supports column positions
+func addColumnSQL(dialect Dialect, collection Collection, field *Field) (string, error) {
+	column, err := tableFieldSQL(dialect, collection.Name, field, collection.Schema.PrimaryKey)
+	if err != nil {
+		return "", err
+	}
+
+	sql := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", dialect.Quote(collection.Name), column)
+	if field.Schema.After != "" {
+		sql += dialect.ColumnPosition(dialect.Quote(field.Schema.After))
+	}
+
+	return sql, nil
+}
+
+// returns the table-level PRIMARY KEY clause of the given columns
+func primaryKeySQL(dialect Dialect, columns []string) string {
+	return "PRIMARY KEY (" + strings.Join(lo.Map(columns, func(column string, i int) string {
+		return dialect.Quote(column)
+	}), ", ") + ")"
+}
+
+// returns the name of the unique constraint of the given table over the given
+// columns; constraints are not renamed along with their table or columns
+func uniqueConstraintName(table string, columns []string) string {
+	return table + "_" + strings.Join(columns, "_") + "_unique"
+}
+
+// returns the table-level PRIMARY KEY clause of the composite primary key and
+// the named UNIQUE clauses of the unique constraints of the given schema
+func tableConstraintsSQL(dialect Dialect, table string, schema *CollectionSchema) []string {
+	constraints := []string{}
+	if len(schema.PrimaryKey) > 0 {
+		constraints = append(constraints, primaryKeySQL(dialect, schema.PrimaryKey))
+	}
+
+	for _, columns := range schema.UniqueConstraints {
+		quoted := lo.Map(columns, func(column string, i int) string {
+			return dialect.Quote(column)
+		})
+
+		constraints = append(constraints, "CONSTRAINT "+dialect.Quote(uniqueConstraintName(table, columns))+" UNIQUE ("+strings.Join(quoted, ", ")+")")
+	}
+
+	return constraints
+}
+
+// returns the unique constraints added and removed since the last migration;
+// columns of renamed fields are compared using their original name, removed
+// constraints are given by the original names of their columns
+func (c Collection) uniqueConstraintChanges() (added [][]string, removed [][]string) {
+	current := lo.Map(c.Schema.UniqueConstraints, func(columns []string, i int) []string {
+		return lo.Map(columns, func(name string, i int) string {
+			if field := c.Schema.field(name); field != nil && field.original != nil {
+				return field.original.Name
+			}
+
+			return name
+		})
+	})
+
+	contains := func(constraints [][]string, columns []string) bool {
+		return slices.ContainsFunc(constraints, func(other []string) bool {
+			return slices.Equal(other, columns)
+		})
+	}
+
+	for i, columns := range current {
+		if !contains(c.original.Schema.UniqueConstraints, columns) {
+			added = append(added, c.Schema.UniqueConstraints[i])
+		}
+	}
+
+	for _, columns := range c.original.Schema.UniqueConstraints {
+		if !contains(current, columns) {
+			removed = append(removed, columns)
+		}
+	}
+
+	return added, removed
+}
+
+// reports whether unique constraints were added or removed since the last
+// migration; neither database can alter the constraints of a table
+func (c Collection) uniqueConstraintsChanged() bool {
+	added, removed := c.uniqueConstraintChanges()
+	return len(added) > 0 || len(removed) > 0
+}
+
+// reports whether the composite primary key changed since the last migration;
+// renamed fields are compared using their original name
+func (c Collection) primaryKeyChanged() bool {
+	primaryKey := lo.Map(c.Schema.PrimaryKey, func(name string, i int) string {
+		if field := c.Schema.field(name); field != nil && field.original != nil {
+			return field.original.Name
+		}
+
+		return name
+	})
+
+	return !slices.Equal(primaryKey, c.original.Schema.PrimaryKey)
+}
+
+// returns the column definition of the given field type; returns an error if
+// the dialect does not support the field type
+func columnSQL(dialect Dialect, column string, fieldType FieldType) (string, error) {
+	columnType := dialect.ColumnType(fieldType)
+	if columnType == "" {
+		return "", fmt.Errorf("configuration error, unsupported field type %T", fieldType)
+	}
+
+	sql := dialect.Quote(column) + " " + columnType
+
+	switch ft := fieldType.(type) {
+	case FieldTypeId:
+		// SQLite allows NULL in primary key columns unless stated otherwise
+		sql = withNullConstraint(sql, ft.Nullable && !ft.PrimaryKey)
+
+		if ft.PrimaryKey {
+			sql += " PRIMARY KEY"
+		}
+
+		return sql, nil
+
+	case FieldTypeUUID:
+		sql = withNullConstraint(sql, ft.Nullable && !ft.PrimaryKey)
+
+		if ft.PrimaryKey {
+			sql += " PRIMARY KEY"
+		}
+
+		return sql, nil
+
+	case FieldTypeSerial:
+		sql = withNullConstraint(sql, false)
+
+		if ft.PrimaryKey {
+			sql += " PRIMARY KEY"
+		}
+
+		return sql, nil
+
+	case FieldTypeSingleRelation:
+		sql = withNullConstraint(sql, ft.Nullable)
+		sql += " REFERENCES " + dialect.Quote(ft.Collection) + "(" + dialect.Quote("id") + ")"
+		return sql + referentialActionsSQL(ft), nil
+
+	case FieldTypeBool:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeDateTime:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeEnum:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeFloat:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeInt:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeText:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeEmail:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeURL:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeIP:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeDuration:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypePoint:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeArray:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeDecimal:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeBytes:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeJSON:
+		return withNullConstraint(sql, ft.Nullable), nil
+
+	case FieldTypeComputed:
+		// DuckDB does not support constraints on generated columns
+		return sql + generatedSQL(ft), nil
+
+	default:
+		return "", fmt.Errorf("configuration error, unsupported field type %T", fieldType)
+	}
+}
+
+// returns the GENERATED clause of computed fields; empty for other field types
+func generatedSQL(fieldType FieldType) string {
+	ft, ok := fieldType.(FieldTypeComputed)
+	if !ok {
+		return ""
+	}
+
+	if ft.Stored {
+		return " GENERATED ALWAYS AS (" + ft.Expression + ") STORED"
+	}
+
+	return " GENERATED ALWAYS AS (" + ft.Expression + ") VIRTUAL"
+}
+
+// returns the computed fields added since the last migration and the fields
+// whose GENERATED clause changed; neither DuckDB nor SQLite can add stored or
+// alter generated columns, so their tables are rebuilt
+func (c Collection) computedChangedFields() []*Field {
+	return lo.Filter(c.Schema.Fields, func(field *Field, i int) bool {
+		if field.original == nil {
+			return isComputed(field.Schema.Type)
+		}
+
+		return generatedSQL(field.original.Schema.Type) != generatedSQL(field.Schema.Type)
+	})
+}
+
+func withNullConstraint(sql string, nullable bool) string {
+	if nullable {
+		return sql + " NULL"
+	}
+
+	return sql + " NOT NULL"
+}
+
+// creates the migration history table if it does not exist yet. Tables
+// created before checksums were introduced get the checksum column; tables
+// created before versions were introduced are rebuilt, numbering the applied
+// migrations in the order they were applied.
+func createMigrationTable(tx contextTx) error {
+	sql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY, name TEXT NOT NULL, direction TEXT NOT NULL, applied_at TIMESTAMP NOT NULL, checksum TEXT NULL)", migrationTableName)
+	if _, err := tx.Exec(sql); err != nil {
+		return err
+	}
+
+	// pragma_table_info is supported by both DuckDB and SQLite
+	hasColumn := func(column string) (bool, error) {
+		var count int64
+		sql := fmt.Sprintf("SELECT COUNT(*) FROM pragma_table_info(%s) WHERE name = %s", quoteLiteral(migrationTableName), quoteLiteral(column))
+		err := tx.QueryRow(sql).Scan(&count)
+		return count > 0, err
+	}
+
+	if ok, err := hasColumn("checksum"); err != nil {
+		return err
+	} else if !ok {
+		sql = fmt.Sprintf("ALTER TABLE %s ADD COLUMN checksum TEXT NULL", migrationTableName)
+		if _, err := tx.Exec(sql); err != nil {
+			return err
+		}
+	}
+
+	if ok, err := hasColumn("version"); err != nil || ok {
+		return err
+	}
+
+	tmpName := migrationTableName + "_new"
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s (version BIGINT NOT NULL PRIMARY KEY, name TEXT NOT NULL, direction TEXT NOT NULL, applied_at TIMESTAMP NOT NULL, checksum TEXT NULL)", tmpName),
+		fmt.Sprintf("INSERT INTO %s SELECT ROW_NUMBER() OVER (ORDER BY applied_at, name), name, '%s', applied_at, checksum FROM %s", tmpName, MigrationUp, migrationTableName),
+		fmt.Sprintf("DROP TABLE %s", migrationTableName),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", tmpName, migrationTableName),
+	}
+
+	for _, sql := range statements {
+		if _, err := tx.Exec(sql); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// condition matching the entries of the migration history that are the
+// latest of their migration and applied it
+var appliedMigrationCondition = fmt.Sprintf("direction = '%s' AND version = (SELECT MAX(version) FROM %s WHERE name = m.name)", MigrationUp, migrationTableName)
+
+func migrationExists(tx contextTx, migrationName string) (bool, error) {
+	if err := createMigrationTable(tx); err != nil {
+		return false, err
+	}
+
+	sql := fmt.Sprintf("SELECT COUNT(*) FROM %s m WHERE name = ? AND %s", migrationTableName, appliedMigrationCondition)
+
+	var count int64
+	if err := tx.QueryRow(sql, migrationName).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// appends an entry with the next version to the migration history
+func appendMigrationEntry(tx contextTx, migrationName string, direction MigrationDirection, checksum string) error {
+	sql := fmt.Sprintf("INSERT INTO %s (version, name, direction, applied_at, checksum) SELECT COALESCE(MAX(version), 0) + 1, ?, ?, ?, ? FROM %s", migrationTableName, migrationTableName)
+	_, err := tx.Exec(sql, migrationName, string(direction), time.Now(), checksum)
+	return err
+}
+
+func finishMigration(tx contextTx, migrationName string, checksum string) error {
+	if err := createMigrationTable(tx); err != nil {
+		return err
+	}
+
+	return appendMigrationEntry(tx, migrationName, MigrationUp, checksum)
+}
+
+func verifyMigration(tx contextTx, migrationName string, checksum string) error {
+	if err := createMigrationTable(tx); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("SELECT COALESCE(checksum, '') FROM %s m WHERE name = ? AND %s", migrationTableName, appliedMigrationCondition)
+
+	var stored string
+	err := tx.QueryRow(query, migrationName).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && (stored == "" || stored == checksum)) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: the schema changes of migration %s differ from when it was applied; add a new migration instead of editing applied ones", ErrMigrationChanged, migrationName)
+}
+
+// records the revert of the given migration if it is applied
+func revertMigration(tx contextTx, migrationName string) error {
+	exists, err := migrationExists(tx, migrationName)
+	if err != nil || !exists {
+		return err
+	}
+
+	return appendMigrationEntry(tx, migrationName, MigrationDown, "")
+}
+
+// runs the given query selecting table or view names and returns the names
+// except those of internal tables of ldb
+func listTableNames(tx contextTx, query string) ([]string, error) {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		if !strings.HasPrefix(name, "_ldb_") {
+			names = append(names, name)
+		}
+	}
+
+	return names, rows.Err()
+}
+
+func appliedMigrations(tx contextTx) ([]string, error) {
+	if err := createMigrationTable(tx); err != nil {
+		return nil, err
+	}
+
+	sql := fmt.Sprintf("SELECT name FROM %s m WHERE %s ORDER BY version", migrationTableName, appliedMigrationCondition)
+
+	rows, err := tx.Query(sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+func migrationHistory(tx contextTx) ([]MigrationHistoryEntry, error) {
+	if err := createMigrationTable(tx); err != nil {
+		return nil, err
+	}
+
+	sql := fmt.Sprintf("SELECT version, name, direction, applied_at, COALESCE(checksum, '') FROM %s ORDER BY version", migrationTableName)
+
+	rows, err := tx.Query(sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []MigrationHistoryEntry{}
+	for rows.Next() {
+		var entry MigrationHistoryEntry
+		if err := rows.Scan(&entry.Version, &entry.Name, &entry.Direction, &entry.AppliedAt, &entry.Checksum); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func currentSchemaVersion(tx contextTx) (int, error) {
+	if err := createMigrationTable(tx); err != nil {
+		return 0, err
+	}
+
+	var version int
+	sql := fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s", migrationTableName)
+	err := tx.QueryRow(sql).Scan(&version)
+	return version, err
+}
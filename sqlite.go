@@ -0,0 +1,733 @@
+package ldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/samber/lo"
+)
+
+// RFC-3339 with a fixed number of fractional digits
+const sqliteTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+var _ DatabaseAdapter = SQLiteAdapter{}
+var _ DatabaseTransaction = SQLiteTransaction{}
+var _ DryRunTransaction = SQLiteTransaction{}
+
+type SQLiteAdapter struct {
+	db *sql.DB
+}
+
+func OpenSQLiteAdapter(databaseFilePath string) (*SQLiteAdapter, error) {
+	// foreign keys are disabled by default in SQLite
+	db, err := sql.Open("sqlite3", "file:"+databaseFilePath+"?_foreign_keys=on")
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLiteAdapter{db}, nil
+}
+
+func (s SQLiteAdapter) Close() error {
+	return s.db.Close()
+}
+
+// DB returns the underlying database as an escape hatch for queries not modeled
+// by the adapter. Statements executed on it bypass validation and must not
+// alter tables managed by SaveCollection.
+func (s SQLiteAdapter) DB() *sql.DB {
+	return s.db
+}
+
+// Dialect returns the SQL dialect of the adapter.
+func (s SQLiteAdapter) Dialect() Dialect {
+	return sqliteDialect{}
+}
+
+// the database or a table is locked by another connection
+func (s SQLiteAdapter) isRetryable(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+func (s SQLiteAdapter) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s SQLiteAdapter) Begin() (DatabaseTransaction, error) {
+	return s.BeginTx(context.Background())
+}
+
+func (s SQLiteAdapter) BeginTx(ctx context.Context) (DatabaseTransaction, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return DatabaseTransaction(SQLiteTransaction{contextTx{tx, ctx, nil, sqliteDialect{}}}), nil
+}
+
+func (s SQLiteAdapter) BeginDryRun() (DryRunTransaction, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	return DryRunTransaction(SQLiteTransaction{contextTx{tx, context.Background(), &[]string{}, sqliteDialect{}}}), nil
+}
+
+// SQLite has no read-only transactions; the connection of the transaction
+// rejects writes instead until the transaction ends
+func (s SQLiteAdapter) BeginReadOnly() (DatabaseTransaction, error) {
+	ctx := context.Background()
+	tx, err := beginConnTx(s.db, ctx, "PRAGMA query_only = OFF", "PRAGMA query_only = ON", "BEGIN")
+	if err != nil {
+		return nil, err
+	}
+
+	return DatabaseTransaction(readOnlyTransaction{SQLiteTransaction{contextTx{tx, ctx, nil, sqliteDialect{}}}}), nil
+}
+
+// reflectColumns implements reflectingAdapter using the schema pragmas.
+func (s SQLiteAdapter) reflectColumns() ([]reflectedColumn, error) {
+	// composite primary keys are not reflected
+	rows, err := s.db.Query(`SELECT m.name, p.name, p.type, NOT p."notnull", p.pk > 0 AND (SELECT COUNT(*) FROM pragma_table_info(m.name) WHERE pk > 0) = 1
+		FROM sqlite_master m JOIN pragma_table_info(m.name) p
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%'
+		ORDER BY m.name, p.cid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []reflectedColumn{}
+	for rows.Next() {
+		var column reflectedColumn
+		var declaredType string
+		if err := rows.Scan(&column.table, &column.name, &declaredType, &column.nullable, &column.primaryKey); err != nil {
+			return nil, err
+		}
+
+		if column.fieldType, err = sqliteFieldType(declaredType); err != nil {
+			return nil, fmt.Errorf("table %q column %q: %w", column.table, column.name, err)
+		}
+
+		columns = append(columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	find := func(table, name string) *reflectedColumn {
+		for i := range columns {
+			if columns[i].table == table && columns[i].name == name {
+				return &columns[i]
+			}
+		}
+
+		return nil
+	}
+
+	rows, err = s.db.Query(`SELECT m.name, f."from", f."table", f.on_delete, f.on_update
+		FROM sqlite_master m JOIN pragma_foreign_key_list(m.name) f
+		WHERE m.type = 'table' AND (SELECT COUNT(*) FROM pragma_foreign_key_list(m.name) g WHERE g.id = f.id) = 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, name, references, onDelete, onUpdate string
+		if err := rows.Scan(&table, &name, &references, &onDelete, &onUpdate); err != nil {
+			return nil, err
+		}
+
+		if column := find(table, name); column != nil {
+			column.references = references
+			column.onDelete = reflectedDeleteAction(onDelete)
+			column.cascadeUpdate = onUpdate == "CASCADE"
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// unique constraints and the indexes of unique fields; indexes of the
+	// collection are skipped even if they are unique
+	rows, err = s.db.Query(`SELECT m.name, c.name
+		FROM sqlite_master m JOIN pragma_index_list(m.name) i JOIN pragma_index_info(i.name) c
+		WHERE m.type = 'table' AND i."unique" AND i.origin != 'pk' AND i.name NOT LIKE '\_ldb\_index\_%' ESCAPE '\'
+		AND (SELECT COUNT(*) FROM pragma_index_info(i.name)) = 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, name string
+		if err := rows.Scan(&table, &name); err != nil {
+			return nil, err
+		}
+
+		if column := find(table, name); column != nil {
+			column.unique = true
+		}
+	}
+
+	return columns, rows.Err()
+}
+
+type SQLiteTransaction struct {
+	tx contextTx
+}
+
+// Commit implements DatabaseTransaction.
+func (s SQLiteTransaction) Commit() error {
+	// dry runs are never committed
+	if s.tx.statements != nil {
+		return s.tx.Rollback()
+	}
+
+	return s.tx.Commit()
+}
+
+// Rollback implements DatabaseTransaction.
+func (s SQLiteTransaction) Rollback() error {
+	return s.tx.Rollback()
+}
+
+// Statements implements DryRunTransaction.
+func (s SQLiteTransaction) Statements() []string {
+	return s.tx.recordedStatements()
+}
+
+// SaveCollection implements DatabaseTransaction.
+//
+// Columns are removed using DROP COLUMN if possible. Since older SQLite
+// versions do not support DROP COLUMN and newer ones refuse to drop columns
+// that are part of a constraint, the table is rebuilt as fallback: a new table
+// with the remaining columns is created, the data is copied over, the old
+// table is dropped and the new one is renamed to the original name. Foreign
+// key checks are deferred until commit while the table is being rebuilt, but
+// dropping the old table still triggers ON DELETE CASCADE actions of tables
+// referencing it.
+//
+// Changing the type, the default or the CHECK constraint of a column also
+// rebuilds the table. Values are converted according to the type affinity of
+// the new column; since SQLite is dynamically typed, values that cannot be
+// converted are kept as they are.
+func (s SQLiteTransaction) SaveCollection(collection Collection) error {
+	collection = collection.withImplicitFields()
+
+	if err := validateCollectionIdentifiers(collection); err != nil {
+		return err
+	}
+
+	if err := validateFieldTypes(collection); err != nil {
+		return err
+	}
+
+	if err := validateIndexes(collection); err != nil {
+		return err
+	}
+
+	if err := validateCompositePrimaryKey(collection); err != nil {
+		return err
+	}
+
+	if err := validateUniqueConstraints(collection); err != nil {
+		return err
+	}
+
+	dialect := s.tx.dialect
+
+	// create collection if not exists
+	if collection.original == nil {
+		statements, err := createTableStatements(dialect, collection, s.junctionTableSQL)
+		if err != nil {
+			return err
+		}
+
+		return s.execAll(statements)
+	}
+
+	dropIndexes, createIndexes := indexStatements(dialect, collection, false)
+	if err := s.execAll(dropIndexes); err != nil {
+		return err
+	}
+
+	// rename collection if neccessary
+	if collection.original.Name != collection.Name {
+		sql := fmt.Sprintf("ALTER TABLE %s RENAME TO %s", dialect.Quote(collection.original.Name), dialect.Quote(collection.Name))
+		if _, err := s.tx.Exec(sql); err != nil {
+			return err
+		}
+	}
+
+	createFields, renameFields, removeFields := collection.fieldChanges()
+
+	backfills, err := nullBackfills(s.tx, s, collection)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range renameFields {
+		sql := fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", dialect.Quote(collection.Name), dialect.Quote(field.original.Name), dialect.Quote(field.Name))
+		if _, err := s.tx.Exec(sql); err != nil {
+			return err
+		}
+	}
+
+	rebuild := len(removeFields) > 0 && !dialect.SupportsDropColumn()
+	if !rebuild {
+		for _, field := range removeFields {
+			sql := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", dialect.Quote(collection.Name), dialect.Quote(field.Name))
+			if _, err := s.tx.Exec(sql); err != nil {
+				rebuild = true
+			}
+		}
+	}
+
+	// SQLite does not support changing column types, defaults or constraints
+	if len(collection.retypedFields(dialect)) > 0 || len(collection.defaultChangedFields()) > 0 ||
+		len(collection.checkChangedFields(dialect)) > 0 || len(collection.nullChangedFields()) > 0 || collection.primaryKeyChanged() ||
+		len(collection.referenceChangedFields()) > 0 || len(collection.computedChangedFields()) > 0 || collection.uniqueConstraintsChanged() {
+		rebuild = true
+	}
+
+	if rebuild {
+		// stored generated columns cannot be added, so new computed fields
+		// are created by the rebuild
+		keepFields := lo.Filter(collection.Schema.Fields, func(field *Field, i int) bool {
+			return (field.original != nil || isComputed(field.Schema.Type)) && !isMultiRelation(field.Schema.Type)
+		})
+
+		if err := s.rebuildTable(collection.Name, keepFields, collection.Schema, backfills); err != nil {
+			return err
+		}
+
+		// the indexes of the old table have been dropped along with it
+		_, createIndexes = indexStatements(dialect, collection, true)
+
+		createFields = lo.Reject(createFields, func(field *Field, i int) bool {
+			return isComputed(field.Schema.Type)
+		})
+	}
+
+	for _, field := range createFields {
+		sql, err := addColumnSQL(dialect, collection, field)
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.tx.Exec(sql); err != nil {
+			return err
+		}
+	}
+
+	junctions, err := junctionStatements(dialect, collection, s.junctionTableSQL)
+	if err != nil {
+		return err
+	}
+
+	return s.execAll(append(junctions, createIndexes...))
+}
+
+// rebuilds the table with the given name so that it only consists of the given fields
+func (s SQLiteTransaction) rebuildTable(name string, fields []*Field, schema *CollectionSchema, backfills map[string]any) error {
+	if _, err := s.tx.Exec("PRAGMA defer_foreign_keys = ON"); err != nil {
+		return err
+	}
+
+	return rebuildTable(s.tx, name, fields, schema, backfills)
+}
+
+func (s SQLiteTransaction) junctionTableSQL(table string, collection string, ft FieldTypeMultiRelation) (string, error) {
+	source, err := columnSQL(s.tx.dialect, "source", FieldTypeSingleRelation{Collection: collection, CascadeDelete: true})
+	if err != nil {
+		return "", err
+	}
+
+	target, err := columnSQL(s.tx.dialect, "target", FieldTypeSingleRelation{Collection: ft.Collection, CascadeDelete: ft.CascadeDelete})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (%s, %s, PRIMARY KEY (%s, %s))",
+		s.tx.dialect.Quote(table),
+		source,
+		target,
+		s.tx.dialect.Quote("source"),
+		s.tx.dialect.Quote("target"),
+	), nil
+}
+
+// executes the given statements in order
+func (s SQLiteTransaction) execAll(statements []string) error {
+	for _, sql := range statements {
+		if _, err := s.tx.Exec(sql); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DropCollection implements DatabaseTransaction.
+func (s SQLiteTransaction) DropCollection(collection Collection) error {
+	// nothing to drop if the collection has never been migrated
+	if collection.original == nil {
+		return nil
+	}
+
+	if err := s.execAll(dropJunctionStatements(s.tx.dialect, collection)); err != nil {
+		return err
+	}
+
+	sql := fmt.Sprintf("DROP TABLE IF EXISTS %s", s.tx.dialect.Quote(collection.Name))
+	_, err := s.tx.Exec(sql)
+	return err
+}
+
+// SaveView implements DatabaseTransaction.
+//
+// SQLite does not support CREATE OR REPLACE VIEW, so the view is dropped and
+// created again.
+func (s SQLiteTransaction) SaveView(view View) error {
+	if err := validateView(view); err != nil {
+		return err
+	}
+
+	statements := []string{}
+
+	// drop view if renamed
+	if view.originalName != "" && view.originalName != view.Name {
+		statements = append(statements, fmt.Sprintf("DROP VIEW IF EXISTS %s", s.tx.dialect.Quote(view.originalName)))
+	}
+
+	statements = append(statements,
+		fmt.Sprintf("DROP VIEW IF EXISTS %s", s.tx.dialect.Quote(view.Name)),
+		fmt.Sprintf("CREATE VIEW %s AS %s", s.tx.dialect.Quote(view.Name), view.Schema.Query),
+	)
+
+	return s.execAll(statements)
+}
+
+// DropView implements DatabaseTransaction.
+func (s SQLiteTransaction) DropView(view View) error {
+	// nothing to drop if the view has never been migrated
+	if view.originalName == "" {
+		return nil
+	}
+
+	sql := fmt.Sprintf("DROP VIEW IF EXISTS %s", s.tx.dialect.Quote(view.Name))
+	_, err := s.tx.Exec(sql)
+	return err
+}
+
+// ListCollections implements DatabaseTransaction.
+func (s SQLiteTransaction) ListCollections() ([]string, error) {
+	return listTableNames(s.tx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+}
+
+// ListViews implements DatabaseTransaction.
+func (s SQLiteTransaction) ListViews() ([]string, error) {
+	return listTableNames(s.tx, `SELECT name FROM sqlite_master WHERE type = 'view' ORDER BY name`)
+}
+
+// MigrationExists implements DatabaseTransaction.
+func (s SQLiteTransaction) MigrationExists(migrationName string) (bool, error) {
+	return migrationExists(s.tx, migrationName)
+}
+
+// FinishMigration implements DatabaseTransaction.
+func (s SQLiteTransaction) FinishMigration(migrationName string, checksum string) error {
+	return finishMigration(s.tx, migrationName, checksum)
+}
+
+// VerifyMigration implements DatabaseTransaction.
+func (s SQLiteTransaction) VerifyMigration(migrationName string, checksum string) error {
+	return verifyMigration(s.tx, migrationName, checksum)
+}
+
+// RevertMigration implements DatabaseTransaction.
+func (s SQLiteTransaction) RevertMigration(migrationName string) error {
+	return revertMigration(s.tx, migrationName)
+}
+
+// AppliedMigrations implements DatabaseTransaction.
+func (s SQLiteTransaction) AppliedMigrations() ([]string, error) {
+	return appliedMigrations(s.tx)
+}
+
+// MigrationHistory implements DatabaseTransaction.
+func (s SQLiteTransaction) MigrationHistory() ([]MigrationHistoryEntry, error) {
+	return migrationHistory(s.tx)
+}
+
+// CurrentSchemaVersion implements DatabaseTransaction.
+func (s SQLiteTransaction) CurrentSchemaVersion() (int, error) {
+	return currentSchemaVersion(s.tx)
+}
+
+// Savepoint implements DatabaseTransaction.
+func (s SQLiteTransaction) Savepoint(name string) error {
+	if err := ValidateIdentifier(name); err != nil {
+		return err
+	}
+
+	_, err := s.tx.Exec("SAVEPOINT " + s.tx.dialect.Quote(name))
+	return err
+}
+
+// RollbackTo implements DatabaseTransaction.
+func (s SQLiteTransaction) RollbackTo(name string) error {
+	if err := ValidateIdentifier(name); err != nil {
+		return err
+	}
+
+	_, err := s.tx.Exec("ROLLBACK TO " + s.tx.dialect.Quote(name))
+	return err
+}
+
+// CreateRecord implements DatabaseTransaction.
+func (s SQLiteTransaction) CreateRecord(collection string, fields map[string]FieldType, data map[string]any) (string, error) {
+	return createRecord(s.tx, s, collection, fields, data)
+}
+
+// CreateRecords implements DatabaseTransaction.
+func (s SQLiteTransaction) CreateRecords(collection string, fields map[string]FieldType, rows []map[string]any) ([]string, error) {
+	return createRecords(s.tx, s, collection, fields, rows)
+}
+
+// GetRecord implements DatabaseTransaction.
+func (s SQLiteTransaction) GetRecord(collection string, fields map[string]FieldType, id string) (map[string]any, error) {
+	return getRecord(s.tx, s, collection, fields, id)
+}
+
+// UpsertRecord implements DatabaseTransaction.
+func (s SQLiteTransaction) UpsertRecord(collection string, fields map[string]FieldType, conflictColumns []string, data map[string]any) (string, error) {
+	return upsertRecord(s.tx, s, collection, fields, conflictColumns, data)
+}
+
+// UpdateRecord implements DatabaseTransaction.
+func (s SQLiteTransaction) UpdateRecord(collection string, fields map[string]FieldType, id string, data map[string]any) error {
+	return updateRecord(s.tx, s, collection, fields, id, data, nil)
+}
+
+// UpdateRecordVersion implements DatabaseTransaction.
+func (s SQLiteTransaction) UpdateRecordVersion(collection string, fields map[string]FieldType, id string, version int64, data map[string]any) error {
+	return updateRecord(s.tx, s, collection, fields, id, data, &version)
+}
+
+// ListRecords implements DatabaseTransaction.
+func (s SQLiteTransaction) ListRecords(collection string, fields map[string]FieldType, opts ListOptions) ([]map[string]any, int64, error) {
+	return listRecords(s.tx, s, collection, fields, opts)
+}
+
+// IterateRecords implements DatabaseTransaction.
+func (s SQLiteTransaction) IterateRecords(collection string, fields map[string]FieldType, opts ListOptions, fn func(record map[string]any) error) error {
+	return iterateRecords(s.tx, s, collection, fields, opts, fn)
+}
+
+// CountRecords implements DatabaseTransaction.
+func (s SQLiteTransaction) CountRecords(collection string, fields map[string]FieldType, opts ListOptions) (int64, error) {
+	return countRecords(s.tx, s, collection, fields, opts)
+}
+
+// Query implements DatabaseTransaction.
+func (s SQLiteTransaction) Query(collection string, fields map[string]FieldType, sql string, args ...any) ([]map[string]any, error) {
+	return queryRecords(s.tx, s, collection, fields, sql, args)
+}
+
+// Aggregate implements DatabaseTransaction.
+func (s SQLiteTransaction) Aggregate(collection string, fields map[string]FieldType, spec AggregateSpec) ([]map[string]any, error) {
+	// decimals are stored as TEXT
+	return aggregateRecords(s.tx, s, collection, fields, spec, func(column string, fieldType FieldType) string {
+		if _, ok := fieldType.(FieldTypeDecimal); ok {
+			return "CAST(" + column + " AS REAL)"
+		}
+
+		return column
+	})
+}
+
+// DeleteRecord implements DatabaseTransaction.
+func (s SQLiteTransaction) DeleteRecord(collection string, fields map[string]FieldType, id string) error {
+	return sqliteReferencedError(deleteRecord(s.tx, collection, fields, id))
+}
+
+// TruncateCollection implements DatabaseTransaction.
+//
+// Foreign key checks are deferred until commit, so related collections may be
+// truncated in any order; ON DELETE CASCADE and SET NULL actions of tables
+// referencing the collection are still performed. Serial primary keys alias
+// the rowid, which restarts once the table is empty, so sequences are always
+// restarted.
+func (s SQLiteTransaction) TruncateCollection(collection string, fields map[string]FieldType, opts TruncateOptions) error {
+	if _, err := s.tx.Exec("PRAGMA defer_foreign_keys = ON"); err != nil {
+		return err
+	}
+
+	return sqliteReferencedError(truncateRecords(s.tx, collection, fields))
+}
+
+// wraps foreign key violations with ErrRecordReferenced
+func sqliteReferencedError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey {
+		return fmt.Errorf("%w: %w", ErrRecordReferenced, err)
+	}
+
+	return err
+}
+
+// datetimes are stored as RFC-3339 strings in UTC with a fixed number of
+// fractional digits so that they sort lexicographically
+func (s SQLiteTransaction) encodeValue(fieldType FieldType, value any) any {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format(sqliteTimeFormat)
+
+	case time.Duration:
+		return int64(v)
+
+	case Point:
+		return v.WKT()
+
+	case []any:
+		if ft, ok := baseFieldType(fieldType).(FieldTypeArray); ok {
+			// validated elements are always encodable
+			data, _ := json.Marshal(s.encodeElements(ft, v))
+			return string(data)
+		}
+	}
+
+	return value
+}
+
+// encodes the elements of an array into values of JSON arrays
+func (s SQLiteTransaction) encodeElements(fieldType FieldTypeArray, values []any) []any {
+	elements := make([]any, len(values))
+	for i, value := range values {
+		if ft, ok := fieldType.Element.(FieldTypeArray); ok && value != nil {
+			elements[i] = s.encodeElements(ft, value.([]any))
+		} else {
+			elements[i] = s.encodeValue(fieldType.Element, value)
+		}
+	}
+
+	return elements
+}
+
+func (s SQLiteTransaction) selectSQL(fieldType FieldType, column string) string {
+	return column
+}
+
+// arrays are stored as JSON text; other values are decoded by the field type
+func (s SQLiteTransaction) decodeValue(fieldType FieldType, value any) (any, error) {
+	if str, ok := value.(string); ok {
+		if _, ok := baseFieldType(fieldType).(FieldTypeArray); ok {
+			decoder := json.NewDecoder(strings.NewReader(str))
+			decoder.UseNumber()
+
+			var values []any
+			if err := decoder.Decode(&values); err != nil {
+				return nil, err
+			}
+
+			value = values
+		}
+	}
+
+	return fieldType.Decode(value)
+}
+
+// SQLite has no dedicated types for ids, datetimes, decimals and enums; ids are
+// stored as TEXT, datetimes as TEXT in RFC-3339 format, decimals as TEXT to
+// preserve exactness, enums as TEXT regardless of their storage strategy and
+// points as TEXT in well-known text format; arrays are stored as JSON text.
+// Returns an empty string if the field type is not supported.
+func sqliteColumnType(fieldType FieldType) string {
+	switch ft := fieldType.(type) {
+	case FieldTypeBool, FieldTypeInt, FieldTypeDuration:
+		return "INTEGER"
+
+	case FieldTypeFloat:
+		return "REAL"
+
+	case FieldTypeDateTime, FieldTypeEmail, FieldTypeEnum, FieldTypeId, FieldTypeSingleRelation, FieldTypeText, FieldTypeUUID, FieldTypeDecimal, FieldTypeJSON, FieldTypeURL, FieldTypeIP, FieldTypePoint, FieldTypeArray:
+		return "TEXT"
+
+	case FieldTypeBytes:
+		return "BLOB"
+
+	case FieldTypeComputed:
+		return sqliteColumnType(ft.Base)
+
+	// only INTEGER PRIMARY KEY columns are assigned values automatically
+	case FieldTypeSerial:
+		if ft.PrimaryKey {
+			return "INTEGER"
+		}
+
+		return ""
+
+	default:
+		return ""
+	}
+}
+
+// maps a declared SQLite column type to the closest field type following the
+// type affinity rules of SQLite; note that sqliteColumnType stores most field
+// types as TEXT, so their original type cannot be recovered
+func sqliteFieldType(declaredType string) (FieldType, error) {
+	upper := strings.ToUpper(declaredType)
+
+	switch {
+	case strings.Contains(upper, "BOOL"):
+		return FieldTypeBool{}, nil
+
+	case strings.Contains(upper, "INT"):
+		return FieldTypeInt{}, nil
+
+	case strings.Contains(upper, "CHAR"), strings.Contains(upper, "CLOB"), strings.Contains(upper, "TEXT"):
+		return FieldTypeText{}, nil
+
+	case upper == "", strings.Contains(upper, "BLOB"):
+		return FieldTypeBytes{}, nil
+
+	case strings.Contains(upper, "REAL"), strings.Contains(upper, "FLOA"), strings.Contains(upper, "DOUB"):
+		return FieldTypeFloat{}, nil
+
+	case strings.HasPrefix(upper, "DECIMAL"), strings.HasPrefix(upper, "NUMERIC"):
+		var precision, scale int
+		if _, args, ok := strings.Cut(strings.ReplaceAll(upper, " ", ""), "("); ok {
+			if _, err := fmt.Sscanf(args, "%d,%d)", &precision, &scale); err == nil {
+				return FieldTypeDecimal{Precision: precision, Scale: scale}, nil
+			}
+		}
+
+		return FieldTypeFloat{}, nil
+
+	case strings.Contains(upper, "DATE"), strings.Contains(upper, "TIME"):
+		return FieldTypeDateTime{}, nil
+
+	case upper == "UUID":
+		return FieldTypeUUID{}, nil
+
+	case upper == "JSON":
+		return FieldTypeJSON{}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported column type %q", declaredType)
+	}
+}
@@ -0,0 +1,175 @@
+package ldb_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func openTestSQLiteAdapter(t *testing.T) *ldb.SQLiteAdapter {
+	t.Helper()
+
+	adapter, err := ldb.OpenSQLiteAdapter(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return adapter
+}
+
+func TestSQLiteAdapter(t *testing.T) {
+	adapter := openTestSQLiteAdapter(t)
+	defer adapter.Close()
+
+	app := ldb.App{DatabaseAdapter: adapter}
+
+	app.RegisterMigration("0001_init", ldb.Migration{
+		Up: func(tx ldb.DatabaseTransaction) error {
+			if err := tx.SaveCollection(ldb.Collection{
+				Name: "test0",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+					},
+				},
+			}); err != nil {
+				return err
+			}
+
+			return tx.SaveCollection(ldb.Collection{
+				Name: "test1",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "bool", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeBool{}}},
+						{Name: "datetime", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeDateTime{}}},
+						{Name: "enum", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeEnum{EnumValues: []string{"a", "b", "c"}}}},
+						{Name: "float", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeFloat{}}},
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "int", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{}}},
+						{Name: "singleRelation", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeSingleRelation{Collection: "test0"}}},
+						{Name: "text", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+					},
+				},
+			})
+		},
+	})
+
+	if err := app.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if exists, err := tx.MigrationExists("0001_init"); err != nil {
+		t.Fatal(err)
+	} else if !exists {
+		t.Fatal("expected migration to exist")
+	}
+}
+
+func TestSQLiteSaveCollectionChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sqlite")
+
+	adapter, err := ldb.OpenSQLiteAdapter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection := ldb.Collection{
+		Name: "test",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "title", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+				{Name: "obsolete", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{}}},
+			},
+		},
+	}
+
+	migrate := func() {
+		tx, err := adapter.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tx.SaveCollection(collection); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		collection.Forward()
+	}
+
+	migrate()
+
+	// rename, remove (table rebuild) and add within the same migration
+	collection.Schema.Fields[1].Name = "headline"
+	collection.Schema.Fields = append(collection.Schema.Fields[:2], &ldb.Field{
+		Name:   "count",
+		Schema: &ldb.FieldSchema{Type: ldb.FieldTypeInt{Nullable: true}},
+	})
+	migrate()
+
+	if err := adapter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	columns := tableColumns(t, "sqlite3", path, "test")
+	expected := []string{"id", "headline", "count"}
+	if !slices.Equal(columns, expected) {
+		t.Fatalf("expected columns %v, got %v", expected, columns)
+	}
+}
+
+func TestSQLiteJSONField(t *testing.T) {
+	adapter := openTestSQLiteAdapter(t)
+	defer adapter.Close()
+
+	tx, err := adapter.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	documents := ldb.Collection{
+		Name: "documents",
+		Schema: &ldb.CollectionSchema{
+			Fields: []*ldb.Field{
+				{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+				{Name: "body", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeJSON{}}},
+			},
+		},
+	}
+
+	if err := tx.SaveCollection(documents); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tx.CreateRecord("documents", fieldTypes(documents), map[string]any{
+		"id":   testId0,
+		"body": map[string]any{"title": "Hello", "tags": []string{"a"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := tx.GetRecord("documents", fieldTypes(documents), testId0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// JSON values are read back unmarshaled
+	expected := map[string]any{"title": "Hello", "tags": []any{"a"}}
+	if !reflect.DeepEqual(record["body"], expected) {
+		t.Errorf("expected body %v, got %v", expected, record["body"])
+	}
+}
@@ -0,0 +1,189 @@
+package ldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Transactions begun with BeginWithTimeout are bound to a context with
+// deadline. Once the deadline passed, the running statement is interrupted and
+// the transaction is rolled back by database/sql; all further calls fail with
+// an error wrapping ErrTransactionTimeout.
+
+var ErrTransactionTimeout = errors.New("transaction timed out")
+
+// forwards all calls to the underlying transaction and marks errors returned
+// after the deadline passed as timeouts
+type timeoutTransaction struct {
+	DatabaseTransaction
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// BeginWithTimeout begins a transaction that is rolled back automatically if
+// it has not ended within the given duration.
+func BeginWithTimeout(adapter DatabaseAdapter, timeout time.Duration) (DatabaseTransaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	tx, err := adapter.BeginTx(ctx)
+	if err != nil {
+		cancel()
+		return nil, timeoutError(ctx, err)
+	}
+
+	return timeoutTransaction{DatabaseTransaction: tx, ctx: ctx, cancel: cancel}, nil
+}
+
+// wraps the given error with ErrTransactionTimeout if the deadline of the
+// given context passed; the original error is kept, e.g. sql.ErrTxDone for
+// statements issued after the transaction was rolled back
+func timeoutError(ctx context.Context, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, ErrTransactionTimeout) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ErrTransactionTimeout, err)
+}
+
+func (t timeoutTransaction) err(err error) error {
+	return timeoutError(t.ctx, err)
+}
+
+// releases the context once the transaction ended
+func (t timeoutTransaction) Commit() error {
+	defer t.cancel()
+	return t.err(t.DatabaseTransaction.Commit())
+}
+
+func (t timeoutTransaction) Rollback() error {
+	defer t.cancel()
+	return t.err(t.DatabaseTransaction.Rollback())
+}
+
+func (t timeoutTransaction) SaveCollection(collection Collection) error {
+	return t.err(t.DatabaseTransaction.SaveCollection(collection))
+}
+
+func (t timeoutTransaction) DropCollection(collection Collection) error {
+	return t.err(t.DatabaseTransaction.DropCollection(collection))
+}
+
+func (t timeoutTransaction) SaveView(view View) error {
+	return t.err(t.DatabaseTransaction.SaveView(view))
+}
+
+func (t timeoutTransaction) DropView(view View) error {
+	return t.err(t.DatabaseTransaction.DropView(view))
+}
+
+func (t timeoutTransaction) ListCollections() ([]string, error) {
+	names, err := t.DatabaseTransaction.ListCollections()
+	return names, t.err(err)
+}
+
+func (t timeoutTransaction) ListViews() ([]string, error) {
+	names, err := t.DatabaseTransaction.ListViews()
+	return names, t.err(err)
+}
+
+func (t timeoutTransaction) MigrationExists(migrationName string) (bool, error) {
+	exists, err := t.DatabaseTransaction.MigrationExists(migrationName)
+	return exists, t.err(err)
+}
+
+func (t timeoutTransaction) FinishMigration(migrationName string, checksum string) error {
+	return t.err(t.DatabaseTransaction.FinishMigration(migrationName, checksum))
+}
+
+func (t timeoutTransaction) VerifyMigration(migrationName string, checksum string) error {
+	return t.err(t.DatabaseTransaction.VerifyMigration(migrationName, checksum))
+}
+
+func (t timeoutTransaction) RevertMigration(migrationName string) error {
+	return t.err(t.DatabaseTransaction.RevertMigration(migrationName))
+}
+
+func (t timeoutTransaction) AppliedMigrations() ([]string, error) {
+	names, err := t.DatabaseTransaction.AppliedMigrations()
+	return names, t.err(err)
+}
+
+func (t timeoutTransaction) MigrationHistory() ([]MigrationHistoryEntry, error) {
+	entries, err := t.DatabaseTransaction.MigrationHistory()
+	return entries, t.err(err)
+}
+
+func (t timeoutTransaction) CurrentSchemaVersion() (int, error) {
+	version, err := t.DatabaseTransaction.CurrentSchemaVersion()
+	return version, t.err(err)
+}
+
+func (t timeoutTransaction) Savepoint(name string) error {
+	return t.err(t.DatabaseTransaction.Savepoint(name))
+}
+
+func (t timeoutTransaction) RollbackTo(name string) error {
+	return t.err(t.DatabaseTransaction.RollbackTo(name))
+}
+
+func (t timeoutTransaction) CreateRecord(collection string, fields map[string]FieldType, data map[string]any) (string, error) {
+	id, err := t.DatabaseTransaction.CreateRecord(collection, fields, data)
+	return id, t.err(err)
+}
+
+func (t timeoutTransaction) CreateRecords(collection string, fields map[string]FieldType, rows []map[string]any) ([]string, error) {
+	ids, err := t.DatabaseTransaction.CreateRecords(collection, fields, rows)
+	return ids, t.err(err)
+}
+
+func (t timeoutTransaction) UpsertRecord(collection string, fields map[string]FieldType, conflictColumns []string, data map[string]any) (string, error) {
+	id, err := t.DatabaseTransaction.UpsertRecord(collection, fields, conflictColumns, data)
+	return id, t.err(err)
+}
+
+func (t timeoutTransaction) GetRecord(collection string, fields map[string]FieldType, id string) (map[string]any, error) {
+	record, err := t.DatabaseTransaction.GetRecord(collection, fields, id)
+	return record, t.err(err)
+}
+
+func (t timeoutTransaction) UpdateRecord(collection string, fields map[string]FieldType, id string, data map[string]any) error {
+	return t.err(t.DatabaseTransaction.UpdateRecord(collection, fields, id, data))
+}
+
+func (t timeoutTransaction) UpdateRecordVersion(collection string, fields map[string]FieldType, id string, version int64, data map[string]any) error {
+	return t.err(t.DatabaseTransaction.UpdateRecordVersion(collection, fields, id, version, data))
+}
+
+func (t timeoutTransaction) DeleteRecord(collection string, fields map[string]FieldType, id string) error {
+	return t.err(t.DatabaseTransaction.DeleteRecord(collection, fields, id))
+}
+
+func (t timeoutTransaction) TruncateCollection(collection string, fields map[string]FieldType, opts TruncateOptions) error {
+	return t.err(t.DatabaseTransaction.TruncateCollection(collection, fields, opts))
+}
+
+func (t timeoutTransaction) ListRecords(collection string, fields map[string]FieldType, opts ListOptions) ([]map[string]any, int64, error) {
+	records, total, err := t.DatabaseTransaction.ListRecords(collection, fields, opts)
+	return records, total, t.err(err)
+}
+
+func (t timeoutTransaction) IterateRecords(collection string, fields map[string]FieldType, opts ListOptions, fn func(record map[string]any) error) error {
+	return t.err(t.DatabaseTransaction.IterateRecords(collection, fields, opts, fn))
+}
+
+func (t timeoutTransaction) CountRecords(collection string, fields map[string]FieldType, opts ListOptions) (int64, error) {
+	count, err := t.DatabaseTransaction.CountRecords(collection, fields, opts)
+	return count, t.err(err)
+}
+
+func (t timeoutTransaction) Query(collection string, fields map[string]FieldType, sql string, args ...any) ([]map[string]any, error) {
+	records, err := t.DatabaseTransaction.Query(collection, fields, sql, args...)
+	return records, t.err(err)
+}
+
+func (t timeoutTransaction) Aggregate(collection string, fields map[string]FieldType, spec AggregateSpec) ([]map[string]any, error) {
+	rows, err := t.DatabaseTransaction.Aggregate(collection, fields, spec)
+	return rows, t.err(err)
+}
@@ -0,0 +1,81 @@
+package ldb
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// Collections with CollectionSchema.Timestamps get two additional datetime
+// fields that are maintained by the record methods of Collection. The fields
+// are nullable, so that timestamps can be enabled for existing tables; existing
+// records keep null timestamps until they are updated.
+
+const (
+	CreatedAtField = "created_at"
+	UpdatedAtField = "updated_at"
+)
+
+// returns the collection with the timestamp fields appended if timestamps are
+// enabled; the fields are considered to exist since the last migration if the
+// original collection had timestamps enabled as well
+func (c Collection) withTimestamps() Collection {
+	if c.original != nil {
+		original := c.original.withTimestamps()
+		c.original = &original
+	}
+
+	if !c.Schema.Timestamps {
+		return c
+	}
+
+	schema := *c.Schema
+	schema.Fields = slices.Clone(schema.Fields)
+
+	for _, name := range []string{CreatedAtField, UpdatedAtField} {
+		field := &Field{Name: name, Schema: &FieldSchema{Type: FieldTypeDateTime{Nullable: true}}}
+		if c.original != nil && c.original.Schema.Timestamps {
+			field.Forward()
+		}
+
+		schema.Fields = append(schema.Fields, field)
+	}
+
+	c.Schema = &schema
+	return c
+}
+
+// returns a copy of the given data with the timestamps set for a new record;
+// timestamps given by the caller are overwritten
+func (c Collection) createTimestamps(data map[string]any) map[string]any {
+	if !c.Schema.Timestamps {
+		return data
+	}
+
+	now := time.Now().UTC()
+
+	data = cloneData(data)
+	data[CreatedAtField] = now
+	data[UpdatedAtField] = now
+	return data
+}
+
+// returns a copy of the given data with the update timestamp set; a creation
+// timestamp given by the caller is ignored
+func (c Collection) updateTimestamps(data map[string]any) map[string]any {
+	if !c.Schema.Timestamps {
+		return data
+	}
+
+	data = cloneData(data)
+	delete(data, CreatedAtField)
+	data[UpdatedAtField] = time.Now().UTC()
+	return data
+}
+
+// returns a shallow copy of the given data; nil data results in an empty map
+func cloneData(data map[string]any) map[string]any {
+	cloned := map[string]any{}
+	maps.Copy(cloned, data)
+	return cloned
+}
@@ -0,0 +1,103 @@
+package ldb_test
+
+import (
+	"testing"
+	"time"
+
+	"lehnert.dev/ldb"
+)
+
+func TestTimestamps(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			notes := ldb.Collection{
+				Name: "notes",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "text", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+					},
+				},
+			}
+
+			if err := tx.SaveCollection(notes); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := notes.CreateRecord(tx, map[string]any{"id": testId0, "text": "before"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// enabling timestamps adds the fields to the existing table
+			notes.Forward()
+			notes.Schema.Timestamps = true
+
+			if err := tx.SaveCollection(notes); err != nil {
+				t.Fatal(err)
+			}
+
+			notes.Forward()
+			if err := tx.SaveCollection(notes); err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := notes.GetRecord(tx, testId0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record[ldb.CreatedAtField] != nil || record[ldb.UpdatedAtField] != nil {
+				t.Fatalf("expected null timestamps for existing record, got %v", record)
+			}
+
+			// timestamps given by the caller are overwritten
+			past := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+			id, err := notes.CreateRecord(tx, map[string]any{"text": "a", ldb.CreatedAtField: past})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			created, err := notes.GetRecord(tx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			createdAt, ok := created[ldb.CreatedAtField].(time.Time)
+			if !ok || createdAt.Equal(past) || !createdAt.Equal(created[ldb.UpdatedAtField].(time.Time)) {
+				t.Fatalf("unexpected timestamps %v", created)
+			}
+
+			time.Sleep(2 * time.Millisecond)
+
+			if err := notes.UpdateRecord(tx, id, map[string]any{"text": "b", ldb.CreatedAtField: past}); err != nil {
+				t.Fatal(err)
+			}
+
+			updated, err := notes.GetRecord(tx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !updated[ldb.CreatedAtField].(time.Time).Equal(createdAt) {
+				t.Fatalf("expected created_at to be unchanged, got %v", updated)
+			}
+
+			if !updated[ldb.UpdatedAtField].(time.Time).After(createdAt) {
+				t.Fatalf("expected updated_at to change, got %v", updated)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package ldb
+
+import (
+	"time"
+)
+
+// option of WithTransaction
+type TransactionOption func(options *transactionOptions)
+
+type transactionOptions struct {
+	maxAttempts int
+	// delay before the first retry; doubled for each further retry
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// limits the number of attempts including the first one; defaults to 5
+func WithMaxAttempts(n int) TransactionOption {
+	return func(options *transactionOptions) {
+		options.maxAttempts = n
+	}
+}
+
+// sets the delay before the first retry and the maximum delay between retries;
+// defaults to 10ms and 1s
+func WithBackoff(initial time.Duration, max time.Duration) TransactionOption {
+	return func(options *transactionOptions) {
+		options.initialBackoff = initial
+		options.maxBackoff = max
+	}
+}
+
+// implemented by adapters able to recognize transient errors
+type retryingAdapter interface {
+	// returns whether the transaction failing with the given error may succeed
+	// when retried, e.g. after a write conflict
+	isRetryable(err error) bool
+}
+
+// WithTransaction runs fn within a transaction that is committed if fn succeeds
+// and rolled back otherwise. If fn or the commit fail with a transient error
+// of the database, such as a write conflict, the transaction is retried with
+// exponential backoff; other errors are returned immediately. Since fn may run
+// multiple times, it should not have side effects outside the transaction. A
+// panic of fn rolls back the transaction and is not retried.
+func WithTransaction(adapter DatabaseAdapter, fn func(tx DatabaseTransaction) error, opts ...TransactionOption) error {
+	options := transactionOptions{maxAttempts: 5, initialBackoff: 10 * time.Millisecond, maxBackoff: time.Second}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	retrying, _ := adapter.(retryingAdapter)
+	backoff := options.initialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := runTransaction(adapter, fn)
+		if err == nil {
+			return nil
+		}
+
+		if retrying == nil || attempt >= options.maxAttempts || !retrying.isRetryable(err) {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff = min(2*backoff, options.maxBackoff)
+	}
+}
+
+// runs fn within a single transaction; if fn panics, the transaction is
+// rolled back before the panic is propagated, so that its connection is
+// returned to the pool
+func runTransaction(adapter DatabaseAdapter, fn func(tx DatabaseTransaction) error) error {
+	tx, err := adapter.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
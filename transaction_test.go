@@ -0,0 +1,281 @@
+package ldb_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marcboeker/go-duckdb"
+	"github.com/mattn/go-sqlite3"
+	"lehnert.dev/ldb"
+)
+
+func TestWithTransaction(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	transient := map[string]error{
+		"duckdb": &duckdb.Error{Type: duckdb.ErrorTypeTransaction, Msg: "TransactionContext Error: Conflict on update!"},
+		"sqlite": sqlite3.Error{Code: sqlite3.ErrBusy},
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(testAuthors)
+			backoff := ldb.WithBackoff(time.Millisecond, 2*time.Millisecond)
+
+			// every attempt creates the same record, which only succeeds if
+			// failed attempts are rolled back
+			attempts := 0
+			err := ldb.WithTransaction(adapter, func(tx ldb.DatabaseTransaction) error {
+				attempts++
+
+				if _, err := tx.CreateRecord("authors", fields, map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+					return err
+				}
+
+				if attempts < 3 {
+					return transient[name]
+				}
+
+				return nil
+			}, backoff)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if attempts != 3 {
+				t.Fatalf("expected 3 attempts, got %d", attempts)
+			}
+
+			attempts = 0
+			err = ldb.WithTransaction(adapter, func(tx ldb.DatabaseTransaction) error {
+				attempts++
+				return transient[name]
+			}, ldb.WithMaxAttempts(2), backoff)
+			if !errors.Is(err, transient[name]) || attempts != 2 {
+				t.Fatalf("expected transient error after 2 attempts, got %v after %d", err, attempts)
+			}
+
+			// other errors are not retried
+			attempts = 0
+			err = ldb.WithTransaction(adapter, func(tx ldb.DatabaseTransaction) error {
+				attempts++
+				_, err := tx.CreateRecord("authors", fields, map[string]any{"id": testId0, "name": "Jane"})
+				return err
+			}, backoff)
+			if err == nil || attempts != 1 {
+				t.Fatalf("expected duplicate key error after 1 attempt, got %v after %d", err, attempts)
+			}
+		})
+	}
+}
+
+func TestWithTransactionPanic(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(testAuthors)
+
+			recovered := func() (r any) {
+				defer func() { r = recover() }()
+
+				ldb.WithTransaction(adapter, func(tx ldb.DatabaseTransaction) error {
+					if _, err := tx.CreateRecord("authors", fields, map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+						t.Fatal(err)
+					}
+
+					panic("boom")
+				})
+
+				return nil
+			}()
+
+			if recovered != "boom" {
+				t.Fatalf("expected panic to be propagated, got %v", recovered)
+			}
+
+			// the partial insert has been rolled back rather than left pending in
+			// a leaked transaction, which would conflict with inserting the
+			// record again
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if _, err := tx.GetRecord("authors", fields, testId0); !errors.Is(err, ldb.ErrRecordNotFound) {
+				t.Fatalf("expected ErrRecordNotFound, got %v", err)
+			}
+
+			if _, err := tx.CreateRecord("authors", fields, map[string]any{"id": testId0, "name": "John"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestBeginReadOnly(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+			fields := fieldTypes(testAuthors)
+			if _, err := tx.CreateRecord("authors", fields, map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			readTx, err := adapter.BeginReadOnly()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer readTx.Rollback()
+
+			if _, err := readTx.CreateRecord("authors", fields, map[string]any{"id": testId1, "name": "John"}); !errors.Is(err, ldb.ErrReadOnly) {
+				t.Fatalf("expected ErrReadOnly, got %v", err)
+			}
+
+			if err := readTx.UpdateRecord("authors", fields, testId0, map[string]any{"name": "John"}); !errors.Is(err, ldb.ErrReadOnly) {
+				t.Fatalf("expected ErrReadOnly, got %v", err)
+			}
+
+			if err := readTx.SaveCollection(testAuthors); !errors.Is(err, ldb.ErrReadOnly) {
+				t.Fatalf("expected ErrReadOnly, got %v", err)
+			}
+
+			// rejected writes do not abort the transaction
+			record, err := readTx.GetRecord("authors", fields, testId0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record["name"] != "Jane" {
+				t.Fatalf("expected Jane, got %v", record["name"])
+			}
+
+			// raw statements are rejected by the database
+			if _, err := readTx.Query("authors", fields, `DELETE FROM "authors"`); err == nil {
+				t.Fatal("expected raw write to fail")
+			}
+
+			if err := readTx.Rollback(); err != nil {
+				t.Fatal(err)
+			}
+
+			// the connection accepts writes again after the transaction ended
+			tx, err = adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := tx.DeleteRecord("authors", fields, testId0); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestBeginWithTimeout(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := fieldTypes(testAuthors)
+
+			// transactions ending before the deadline are not affected
+			tx, err := ldb.BeginWithTimeout(adapter, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tx.CreateRecord("authors", fields, map[string]any{"id": testId0, "name": "Jane"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err = ldb.BeginWithTimeout(adapter, 10*time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if _, err := tx.CreateRecord("authors", fields, map[string]any{"id": testId1, "name": "John"}); err != nil {
+				t.Fatal(err)
+			}
+
+			time.Sleep(50 * time.Millisecond)
+
+			if _, err := tx.CreateRecord("authors", fields, map[string]any{"id": testId2, "name": "Max"}); !errors.Is(err, ldb.ErrTransactionTimeout) {
+				t.Fatalf("expected ErrTransactionTimeout, got %v", err)
+			}
+
+			if err := tx.Commit(); !errors.Is(err, ldb.ErrTransactionTimeout) {
+				t.Fatalf("expected ErrTransactionTimeout, got %v", err)
+			}
+
+			// the transaction has been rolled back
+			readTx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer readTx.Rollback()
+
+			count, err := readTx.CountRecords("authors", fields, ldb.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if count != 1 {
+				t.Fatalf("expected 1 author, got %d", count)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package ldb
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// message of fields missing in the data of a new record that can neither be
+// null nor have a default value
+const requiredMessage = "is required"
+
+// ValidationError reports the invalid fields of a record together with the
+// reason each field is invalid.
+type ValidationError struct {
+	fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	names := lo.Keys(e.fields)
+	slices.Sort(names)
+
+	messages := []string{}
+	for _, name := range names {
+		if e.fields[name] == requiredMessage {
+			messages = append(messages, fmt.Sprintf("field %q %s", name, requiredMessage))
+			continue
+		}
+
+		messages = append(messages, fmt.Sprintf("field %q: %s", name, e.fields[name]))
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// returns the error messages keyed by field name
+func (e *ValidationError) Fields() map[string]string {
+	return maps.Clone(e.fields)
+}
+
+// returns the sorted names of the required fields missing in the data
+func (e *ValidationError) Required() []string {
+	names := lo.Filter(lo.Keys(e.fields), func(name string, i int) bool {
+		return e.fields[name] == requiredMessage
+	})
+
+	slices.Sort(names)
+	return names
+}
+
+// returns the error message of the field with the given name; fields missing
+// in the data are reported as required instead of as invalid null values
+func fieldErrorMessage(err error, present bool) string {
+	if !present {
+		return requiredMessage
+	}
+
+	return err.Error()
+}
+
+// reports whether a value must be given for fields of the given type when
+// creating records, i.e. the field is neither nullable nor has a default value
+func requiredField(fieldType FieldType) bool {
+	_, err := fieldType.ValidateValue(nil)
+	return err != nil
+}
+
+// ValidateRecord validates the given data as new record and returns a
+// *ValidationError listing all invalid and unknown fields. Missing fields are
+// validated as nil, but distinguished from explicit nil values: missing fields
+// that can neither be null nor have a default value are reported as required.
+func ValidateRecord(fields map[string]FieldType, data map[string]any) error {
+	fieldErrors := map[string]string{}
+
+	for name := range data {
+		if _, ok := fields[name]; !ok {
+			fieldErrors[name] = "unknown field"
+		}
+	}
+
+	for name, fieldType := range fields {
+		value, present := data[name]
+		if _, err := fieldType.ValidateValue(value); err != nil {
+			fieldErrors[name] = fieldErrorMessage(err, present)
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return &ValidationError{fields: fieldErrors}
+	}
+
+	return nil
+}
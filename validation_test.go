@@ -0,0 +1,69 @@
+package ldb_test
+
+import (
+	"errors"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestValidateRecord(t *testing.T) {
+	fields := fieldTypes(testPosts)
+
+	if err := ldb.ValidateRecord(fields, map[string]any{"title": "a", "views": int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ldb.ValidateRecord(fields, map[string]any{"views": "many", "state": "deleted", "unknown": 1})
+
+	var validationErr *ldb.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	if len(validationErr.Fields()) != 4 {
+		t.Fatalf("expected errors for title, views, state and unknown, got %v", validationErr.Fields())
+	}
+
+	// title is missing and can neither be null nor has a default value
+	expected := `field "state": ` + validationErr.Fields()["state"] + `; field "title" is required` +
+		`; field "unknown": unknown field; field "views": ` + validationErr.Fields()["views"]
+	if err.Error() != expected {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+
+	if required := validationErr.Required(); len(required) != 1 || required[0] != "title" {
+		t.Fatalf("expected title to be required, got %v", required)
+	}
+
+	// explicit nil values are invalid rather than missing
+	err = ldb.ValidateRecord(fields, map[string]any{"title": nil})
+	if !errors.As(err, &validationErr) || len(validationErr.Required()) != 0 || validationErr.Fields()["title"] != "invalid value, expected non-null" {
+		t.Fatalf("expected invalid null title, got %v", err)
+	}
+
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx := beginTestRecords(t, adapter)
+
+			// record operations report all invalid fields as well
+			_, err := tx.CreateRecord("posts", fields, map[string]any{"views": "many", "rating": "high"})
+			if !errors.As(err, &validationErr) || len(validationErr.Fields()) != 3 {
+				t.Fatalf("expected errors for title, views and rating, got %v", err)
+			}
+
+			// missing fields with default value are not required
+			_, err = tx.CreateRecord("posts", fields, map[string]any{"rating": 1.5})
+			if !errors.As(err, &validationErr) || err.Error() != `field "title" is required` {
+				t.Fatalf("expected title to be required, got %v", err)
+			}
+		})
+	}
+}
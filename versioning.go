@@ -0,0 +1,99 @@
+package ldb
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Collections with CollectionSchema.Versioned get an integer version field
+// used for optimistic locking: records are created with version 1 and
+// Collection.UpdateRecord only updates a record if the given version matches
+// the stored one, incrementing it. The field is nullable with a database
+// default, so that versioning can be enabled for existing tables; existing
+// records start at version 1 as well.
+
+const VersionField = "version"
+
+var ErrVersionConflict = errors.New("version conflict")
+
+// returns the collection with all fields maintained by the record methods of
+// Collection appended
+func (c Collection) withImplicitFields() Collection {
+	return c.withTimestamps().withVersion()
+}
+
+// returns the collection with the version field appended if versioning is
+// enabled; the field is considered to exist since the last migration if the
+// original collection was versioned as well
+func (c Collection) withVersion() Collection {
+	if c.original != nil {
+		original := c.original.withVersion()
+		c.original = &original
+	}
+
+	if !c.Schema.Versioned {
+		return c
+	}
+
+	schema := *c.Schema
+	schema.Fields = slices.Clone(schema.Fields)
+
+	field := &Field{Name: VersionField, Schema: &FieldSchema{
+		DatabaseDefault: true,
+		Type:            FieldTypeInt{Nullable: true, CreateDefaultValue: constant(int64(1))},
+	}}
+	if c.original != nil && c.original.Schema.Versioned {
+		field.Forward()
+	}
+
+	schema.Fields = append(schema.Fields, field)
+
+	c.Schema = &schema
+	return c
+}
+
+// returns a copy of the given data with the initial version set; a version
+// given by the caller is overwritten
+func (c Collection) createVersion(data map[string]any) map[string]any {
+	if !c.Schema.Versioned {
+		return data
+	}
+
+	data = cloneData(data)
+	data[VersionField] = int64(1)
+	return data
+}
+
+// splits the expected version off the given update data
+func (c Collection) updateVersion(data map[string]any) (int64, map[string]any, error) {
+	value, ok := data[VersionField]
+	if !ok || value == nil {
+		return 0, nil, &ValidationError{fields: map[string]string{VersionField: "expected current version"}}
+	}
+
+	version, err := FieldTypeInt{}.ValidateValue(value)
+	if err != nil {
+		return 0, nil, &ValidationError{fields: map[string]string{VersionField: err.Error()}}
+	}
+
+	data = cloneData(data)
+	delete(data, VersionField)
+	return version.(int64), data, nil
+}
+
+// checks whether a record exists after a versioned update matched no rows
+func versionConflict(tx contextTx, collection string, primaryKey string, id string) error {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?", tx.dialect.Quote(collection), tx.dialect.Quote(primaryKey))
+
+	var count int64
+	if err := tx.QueryRow(query, id).Scan(&count); err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return ErrRecordNotFound
+	}
+
+	return ErrVersionConflict
+}
@@ -0,0 +1,107 @@
+package ldb_test
+
+import (
+	"errors"
+	"testing"
+
+	"lehnert.dev/ldb"
+)
+
+func TestVersioning(t *testing.T) {
+	adapters := map[string]ldb.DatabaseAdapter{
+		"duckdb": openTestAdapter(t),
+		"sqlite": openTestSQLiteAdapter(t),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			defer adapter.Close()
+
+			tx, err := adapter.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			notes := ldb.Collection{
+				Name: "notes",
+				Schema: &ldb.CollectionSchema{
+					Fields: []*ldb.Field{
+						{Name: "id", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeId{PrimaryKey: true}}},
+						{Name: "text", Schema: &ldb.FieldSchema{Type: ldb.FieldTypeText{}}},
+					},
+				},
+			}
+
+			if err := tx.SaveCollection(notes); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := notes.CreateRecord(tx, map[string]any{"id": testId0, "text": "before"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// enabling versioning adds the field to the existing table
+			notes.Forward()
+			notes.Schema.Versioned = true
+
+			if err := tx.SaveCollection(notes); err != nil {
+				t.Fatal(err)
+			}
+
+			notes.Forward()
+			if err := tx.SaveCollection(notes); err != nil {
+				t.Fatal(err)
+			}
+
+			expectVersion := func(id string, expected int64) {
+				t.Helper()
+
+				record, err := notes.GetRecord(tx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if record[ldb.VersionField] != expected {
+					t.Fatalf("expected version %d, got %v", expected, record[ldb.VersionField])
+				}
+			}
+
+			expectVersion(testId0, 1)
+
+			// versions given by the caller are overwritten
+			id, err := notes.CreateRecord(tx, map[string]any{"text": "a", ldb.VersionField: 7})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectVersion(id, 1)
+
+			var validationErr *ldb.ValidationError
+			if err := notes.UpdateRecord(tx, id, map[string]any{"text": "b"}); !errors.As(err, &validationErr) {
+				t.Fatalf("expected validation error for missing version, got %v", err)
+			}
+
+			if err := notes.UpdateRecord(tx, id, map[string]any{"text": "b", ldb.VersionField: 1}); err != nil {
+				t.Fatal(err)
+			}
+
+			expectVersion(id, 2)
+
+			// a concurrent edit based on the first version is rejected
+			if err := notes.UpdateRecord(tx, id, map[string]any{"text": "c", ldb.VersionField: 1}); !errors.Is(err, ldb.ErrVersionConflict) {
+				t.Fatalf("expected version conflict, got %v", err)
+			}
+
+			if err := notes.UpdateRecord(tx, testId1, map[string]any{"text": "c", ldb.VersionField: 1}); !errors.Is(err, ldb.ErrRecordNotFound) {
+				t.Fatalf("expected record not found, got %v", err)
+			}
+
+			if _, err := notes.UpsertRecord(tx, []string{"id"}, map[string]any{"id": id, "text": "d"}); err != nil {
+				t.Fatal(err)
+			}
+
+			expectVersion(id, 3)
+		})
+	}
+}